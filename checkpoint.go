@@ -0,0 +1,110 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	checkpointCreatedRegex = regexp.MustCompile(`^created (.+?\d{4}), consumes \S+$`)
+)
+
+// CheckpointInfo represents the checkpoint of a pool.
+type CheckpointInfo struct {
+	// True if the pool has a checkpoint (including one that is being
+	// discarded), false otherwise.
+	Exists bool
+	// True if the checkpoint is being discarded, false otherwise.
+	Discarding bool
+	// Time the checkpoint was created, zero if it is being discarded.
+	Created time.Time
+	// Number of bytes consumed by the checkpoint.
+	Space uint64
+}
+
+// String returns the string representation of the checkpoint info.
+func (c *CheckpointInfo) String() string {
+	return fmt.Sprintf("{CheckpointInfo Exists: %t, Discarding: %t, Space: %d}", c.Exists, c.Discarding, c.Space)
+}
+
+// Checkpoint creates a checkpoint of the pool, which the pool can be
+// rewound to when imported using ImportPoolOptions.RewindToCheckpoint. A
+// pool can only have one checkpoint at a time.
+func (p *Pool) Checkpoint() error {
+	_, err := p.cmd().zpool.checkpoint(p.Name, false)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// DiscardCheckpoint discards the checkpoint of the pool, the space consumed
+// by the checkpoint is freed in the background.
+func (p *Pool) DiscardCheckpoint() error {
+	_, err := p.cmd().zpool.checkpoint(p.Name, true)
+	if err != nil {
+		return fmt.Errorf("failed to discard checkpoint of pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// CheckpointInfo returns the checkpoint of the pool.
+func (p *Pool) CheckpointInfo() (*CheckpointInfo, error) {
+	status, err := p.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseCheckpointStatus(status.Checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint of pool %q, reason: %w", p, err)
+	}
+
+	if !result.Exists {
+		return result, nil
+	}
+
+	out, err := p.cmd().zpool.get(p.Name, []string{"checkpoint"}, getPoolPropOutputCols, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint of pool %q, reason: %w", p, err)
+	}
+
+	val, err := valueFromOnlyRow(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint of pool %q, reason: %w", p, err)
+	}
+
+	result.Space, err = parseUint64OrNone(val, "pool checkpoint space")
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseCheckpointStatus parses the checkpoint status reported by 'zpool
+// status', e.g. "created Sun Oct 11 00:24:01 2026, consumes 1.20M".
+func parseCheckpointStatus(checkpoint string) (*CheckpointInfo, error) {
+	if checkpoint == "" {
+		return &CheckpointInfo{}, nil
+	}
+
+	if checkpoint == "discarding" {
+		return &CheckpointInfo{Exists: true, Discarding: true}, nil
+	}
+
+	m := checkpointCreatedRegex.FindStringSubmatch(checkpoint)
+	if m == nil {
+		return nil, fmt.Errorf("parsing \"checkpoint status\", unrecognized checkpoint status: %q", checkpoint)
+	}
+
+	created, err := parseTimestamp(m[1], scanTimestampLayout, "checkpoint status created time")
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckpointInfo{Exists: true, Created: created}, nil
+}
@@ -0,0 +1,129 @@
+package zfs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseCheckpointStatusTests = []struct {
+	name       string
+	checkpoint string
+	want       *CheckpointInfo
+}{
+	{
+		name: "No checkpoint",
+		want: &CheckpointInfo{},
+	},
+	{
+		name:       "Existing checkpoint",
+		checkpoint: "created Sun Oct 11 00:24:01 2026, consumes 1.20M",
+		want: &CheckpointInfo{
+			Exists:  true,
+			Created: time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+		},
+	},
+	{
+		name:       "Discarding",
+		checkpoint: "discarding",
+		want:       &CheckpointInfo{Exists: true, Discarding: true},
+	},
+}
+
+func TestParseCheckpointStatus(t *testing.T) {
+	for _, test := range parseCheckpointStatusTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseCheckpointStatus(tc.checkpoint)
+			if err != nil {
+				t.Fatalf("parseCheckpointStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", tc.name, err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("parseCheckpointStatus()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", tc.name, diff)
+			}
+		})
+	}
+
+	if _, err := parseCheckpointStatus("created recently"); err == nil {
+		t.Errorf("parseCheckpointStatus()\nTest Case: %q\nFailure: gotErr == nil", "Unrecognized")
+	}
+}
+
+func TestPoolCheckpointInfo(t *testing.T) {
+	t.Parallel()
+
+	status := "  pool: tank\n" +
+		" state: ONLINE\n" +
+		"  scan: none requested\n" +
+		"checkpoint: created Sun Oct 11 00:24:01 2026, consumes 1.20M\n" +
+		"config:\n\n" +
+		"\tNAME        STATE     READ WRITE CKSUM\n" +
+		"\ttank        ONLINE       0     0     0\n" +
+		"\t  sda       ONLINE       0     0     0\n\n" +
+		"errors: No known data errors\n"
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zpool status -p tank":                     {Stdout: status},
+			"zpool get -H -p -o value checkpoint tank": {Stdout: "1258291\n"},
+		},
+		DisableJSON: true,
+	})
+	pool := &Pool{Name: "tank", System: system}
+
+	got, err := pool.CheckpointInfo()
+	if err != nil {
+		t.Fatalf("CheckpointInfo()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Existing checkpoint", err)
+	}
+
+	want := &CheckpointInfo{
+		Exists:  true,
+		Created: time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+		Space:   1258291,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CheckpointInfo()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "Existing checkpoint", diff)
+	}
+}
+
+func TestJSONCheckpointStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "None",
+			in:   `{"state": "NONE", "start_time": "0", "space": "0"}`,
+		},
+		{
+			name: "Exists",
+			in:   `{"state": "EXISTS", "start_time": "Sun Oct 11 00:24:01 2026", "space": "1258291"}`,
+			want: "created Sun Oct 11 00:24:01 2026, consumes 1258291",
+		},
+		{
+			name: "Discarding",
+			in:   `{"state": "DISCARDING", "start_time": "0", "space": "1258291"}`,
+			want: "discarding",
+		},
+	}
+
+	for _, tc := range tests {
+		var checkpoint jsonObject
+		if err := json.Unmarshal([]byte(tc.in), &checkpoint); err != nil {
+			t.Fatalf("json.Unmarshal()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", tc.name, err)
+		}
+
+		if got := jsonCheckpointStatus(&checkpoint); got != tc.want {
+			t.Errorf("jsonCheckpointStatus()\nTest Case: %q\nFailure: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
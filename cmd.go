@@ -1,21 +1,31 @@
 package zfs
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"os/exec"
+	"io"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type systemZfsCmd struct {
+	executor Executor
+	json     *jsonOutput
 }
 
-func (s *systemZfsCmd) list(fsOrSnap string, recursive bool, listType zfsListType, cols []string) (string, error) {
+func (s *systemZfsCmd) list(fsOrSnap string, recursive bool, listType zfsListType, cols []string) ([][]string, error) {
 	if len(cols) == 0 {
-		return "", fmt.Errorf("at least one column must be specified for 'zfs list'")
+		return nil, fmt.Errorf("at least one column must be specified for 'zfs list'")
 	}
 
-	args := []string{"list", "-H", "-p"}
+	format := "-H"
+	if s.json.enabled() {
+		format = "-j"
+	}
+
+	args := []string{"list", format, "-p"}
 	if recursive {
 		args = append(args, "-r")
 	}
@@ -23,67 +33,520 @@ func (s *systemZfsCmd) list(fsOrSnap string, recursive bool, listType zfsListTyp
 	args = append(
 		args, []string{"-t", zfsLisTypeToStr[listType], "-o", strings.Join(cols, ","), fsOrSnap}...)
 
-	return s.run(args...)
+	out, err := s.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "-H" {
+		return splitRows(out, len(cols)), nil
+	}
+
+	return jsonListRows(out, jsonDatasetsKey, cols)
 }
 
-func (s *systemZfsCmd) get(fsOrSnap string, props []string, cols []string) (string, error) {
+func (s *systemZfsCmd) get(fsOrSnap string, props []string, cols []string) ([][]string, error) {
 	if len(cols) == 0 {
-		return "", fmt.Errorf("at least one column must be specified for 'zfs get'")
+		return nil, fmt.Errorf("at least one column must be specified for 'zfs get'")
 	}
 
-	return s.run("get", "-H", "-o", strings.Join(cols, ","), strings.Join(props, ","), fsOrSnap)
+	if !s.json.enabled() {
+		out, err := s.run("get", "-H", "-o", strings.Join(cols, ","), strings.Join(props, ","), fsOrSnap)
+		if err != nil {
+			return nil, err
+		}
+
+		return splitRows(out, len(cols)), nil
+	}
+
+	out, err := s.run("get", "-j", strings.Join(props, ","), fsOrSnap)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonGetRows(out, jsonDatasetsKey, props, cols)
+}
+
+func (s *systemZfsCmd) set(fsOrSnap string, props map[string]string) (string, error) {
+	if len(props) == 0 {
+		return "", fmt.Errorf("at least one property must be specified for 'zfs set'")
+	}
+
+	args := []string{"set"}
+	args = append(args, propArgs("", props)...)
+	args = append(args, fsOrSnap)
+
+	return s.run(args...)
 }
 
 func (s *systemZfsCmd) holds(snap string) (string, error) {
 	return s.run("holds", "-H", snap)
 }
 
+func (s *systemZfsCmd) clone(snap string, target string, props map[string]string) (string, error) {
+	args := []string{"clone"}
+	args = append(args, propArgs("-o", props)...)
+	args = append(args, snap, target)
+
+	return s.run(args...)
+}
+
+func (s *systemZfsCmd) promote(fs string) (string, error) {
+	return s.run("promote", fs)
+}
+
+func (s *systemZfsCmd) diff(snap string, other string) (string, error) {
+	return s.run("diff", "-H", "-F", "-t", snap, other)
+}
+
+func (s *systemZfsCmd) userspace(fs string, spaceType zfsSpaceType, cols []string) (string, error) {
+	if len(cols) == 0 {
+		return "", fmt.Errorf("at least one column must be specified for 'zfs %s'", zfsSpaceTypeToStr[spaceType])
+	}
+
+	return s.run(zfsSpaceTypeToStr[spaceType], "-H", "-p", "-o", strings.Join(cols, ","), fs)
+}
+
+func (s *systemZfsCmd) loadKey(fs string, key io.Reader) (string, error) {
+	if key == nil {
+		return s.run("load-key", fs)
+	}
+
+	return s.runWithStdin(key, "load-key", "-L", "prompt", fs)
+}
+
+func (s *systemZfsCmd) unloadKey(fs string) (string, error) {
+	return s.run("unload-key", fs)
+}
+
+func (s *systemZfsCmd) changeKey(fs string, inherit bool, load bool, props map[string]string, key io.Reader) (string, error) {
+	args := []string{"change-key"}
+	if load {
+		args = append(args, "-l")
+	}
+
+	if inherit {
+		args = append(args, "-i")
+	} else {
+		args = append(args, propArgs("-o", props)...)
+	}
+
+	args = append(args, fs)
+
+	if key == nil {
+		return s.run(args...)
+	}
+
+	return s.runWithStdin(key, args...)
+}
+
+func (s *systemZfsCmd) mount(fs string) (string, error) {
+	return s.run("mount", fs)
+}
+
+func (s *systemZfsCmd) unmount(fs string, force bool) (string, error) {
+	if force {
+		return s.run("unmount", "-f", fs)
+	}
+
+	return s.run("unmount", fs)
+}
+
+func (s *systemZfsCmd) version() (string, error) {
+	return s.run("version")
+}
+
+func (s *systemZfsCmd) wait(ctx context.Context, fs string) (string, error) {
+	return runCmd(ctx, s.executor, nil, "zfs", "wait", "-t", "deleteq", fs)
+}
+
 func (s *systemZfsCmd) run(args ...string) (string, error) {
-	return runSystemCmd("zfs", args...)
+	return runCmd(context.Background(), s.executor, nil, "zfs", args...)
+}
+
+func (s *systemZfsCmd) runWithStdin(stdin io.Reader, args ...string) (string, error) {
+	return runCmd(context.Background(), s.executor, stdin, "zfs", args...)
 }
 
 type systemZpoolCmd struct {
+	executor Executor
+	json     *jsonOutput
 }
 
-func (s *systemZpoolCmd) list(cols []string) (string, error) {
+func (s *systemZpoolCmd) list(cols []string) ([][]string, error) {
 	if len(cols) == 0 {
-		return "", fmt.Errorf("at least one column must be specified for 'zpool list'")
+		return nil, fmt.Errorf("at least one column must be specified for 'zpool list'")
+	}
+
+	if !s.json.enabled() {
+		out, err := s.run("list", "-H", "-p", "-o", strings.Join(cols, ","))
+		if err != nil {
+			return nil, err
+		}
+
+		return splitRows(out, len(cols)), nil
+	}
+
+	out, err := s.run("list", "-j", "-p", "-o", strings.Join(cols, ","))
+	if err != nil {
+		return nil, err
 	}
 
-	return s.run("list", "-H", "-p", "-o", strings.Join(cols, ","))
+	return jsonListRows(out, jsonPoolsKey, cols)
 }
 
-func (s *systemZpoolCmd) get(pool string, props []string, cols []string) (string, error) {
+func (s *systemZpoolCmd) get(pool string, props []string, cols []string, parsable bool) ([][]string, error) {
 	if len(cols) == 0 {
-		return "", fmt.Errorf("at least one column must be specified for 'zpool get'")
+		return nil, fmt.Errorf("at least one column must be specified for 'zpool get'")
+	}
+
+	jsonOut := s.json.enabled()
+
+	args := []string{"get", "-H"}
+	if jsonOut {
+		args = []string{"get", "-j"}
+	}
+
+	if parsable {
+		args = append(args, "-p")
+	}
+
+	if !jsonOut {
+		args = append(args, "-o", strings.Join(cols, ","))
+	}
+
+	out, err := s.run(append(args, strings.Join(props, ","), pool)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !jsonOut {
+		return splitRows(out, len(cols)), nil
+	}
+
+	return jsonGetRows(out, jsonPoolsKey, props, cols)
+}
+
+func (s *systemZpoolCmd) set(pool string, prop string, value string) (string, error) {
+	return s.run("set", prop+"="+value, pool)
+}
+
+func (s *systemZpoolCmd) upgrade(pool string) (string, error) {
+	return s.run("upgrade", pool)
+}
+
+func (s *systemZpoolCmd) upgradeList() (string, error) {
+	return s.run("upgrade", "-v")
+}
+
+func (s *systemZpoolCmd) importList(searchDirs []string) (string, error) {
+	args := []string{"import"}
+	for _, d := range searchDirs {
+		args = append(args, "-d", d)
+	}
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) importPool(nameOrGUID string, opts *ImportPoolOptions) (string, error) {
+	args := []string{"import"}
+	for _, d := range opts.SearchDirs {
+		args = append(args, "-d", d)
+	}
+
+	if opts.AltRoot != "" {
+		args = append(args, "-R", opts.AltRoot)
+	}
+
+	if opts.Force {
+		args = append(args, "-f")
+	}
+
+	if opts.NoMount {
+		args = append(args, "-N")
+	}
+
+	if opts.RewindToCheckpoint {
+		args = append(args, "--rewind-to-checkpoint")
+	}
+
+	props := make(map[string]string)
+	for k, v := range opts.Props {
+		props[k] = v
+	}
+
+	if opts.ReadOnly {
+		props["readonly"] = "on"
+	}
+
+	args = append(args, propArgs("-o", props)...)
+	args = append(args, nameOrGUID)
+
+	if opts.NewName != "" {
+		args = append(args, opts.NewName)
+	}
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) export(pool string, force bool) (string, error) {
+	if force {
+		return s.run("export", "-f", pool)
+	}
+
+	return s.run("export", pool)
+}
+
+func (s *systemZpoolCmd) create(
+	pool string, vdevArgs []string, poolProps map[string]string, rootFsProps map[string]string,
+) (string, error) {
+	args := []string{"create"}
+	args = append(args, propArgs("-o", poolProps)...)
+	args = append(args, propArgs("-O", rootFsProps)...)
+	args = append(args, pool)
+	args = append(args, vdevArgs...)
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) destroy(pool string) (string, error) {
+	return s.run("destroy", pool)
+}
+
+func (s *systemZpoolCmd) status(pool string) (string, error) {
+	if s.json.enabled() {
+		// The JSON output is parsed by parsePoolStatus.
+		return s.run("status", "-j", "-p", pool)
+	}
+
+	return s.run("status", "-p", pool)
+}
+
+func (s *systemZpoolCmd) statusProgress(pool string, trim bool, initialize bool) (string, error) {
+	// The per-vdev trim and initialize progress is parsed from the text
+	// output, regardless of the JSON support.
+	args := []string{"status"}
+	if trim {
+		args = append(args, "-t")
+	}
+
+	if initialize {
+		args = append(args, "-i")
+	}
+
+	args = append(args, "-p", pool)
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) attach(pool string, device string, newDevice string, force bool) (string, error) {
+	if force {
+		return s.run("attach", "-f", pool, device, newDevice)
+	}
+
+	return s.run("attach", pool, device, newDevice)
+}
+
+func (s *systemZpoolCmd) detach(pool string, device string) (string, error) {
+	return s.run("detach", pool, device)
+}
+
+func (s *systemZpoolCmd) replace(pool string, device string, newDevice string, force bool) (string, error) {
+	args := []string{"replace"}
+	if force {
+		args = append(args, "-f")
+	}
+
+	args = append(args, pool, device)
+	if newDevice != "" {
+		args = append(args, newDevice)
+	}
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) online(pool string, expand bool, devices []string) (string, error) {
+	if len(devices) == 0 {
+		return "", fmt.Errorf("at least one device must be specified for 'zpool online'")
+	}
+
+	args := []string{"online"}
+	if expand {
+		args = append(args, "-e")
+	}
+
+	args = append(args, pool)
+	args = append(args, devices...)
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) offline(pool string, temporary bool, devices []string) (string, error) {
+	if len(devices) == 0 {
+		return "", fmt.Errorf("at least one device must be specified for 'zpool offline'")
+	}
+
+	args := []string{"offline"}
+	if temporary {
+		args = append(args, "-t")
+	}
+
+	args = append(args, pool)
+	args = append(args, devices...)
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) clear(pool string, device string) (string, error) {
+	if device == "" {
+		return s.run("clear", pool)
+	}
+
+	return s.run("clear", pool, device)
+}
+
+func (s *systemZpoolCmd) remove(pool string, devices []string) (string, error) {
+	if len(devices) == 0 {
+		return "", fmt.Errorf("at least one device must be specified for 'zpool remove'")
+	}
+
+	return s.run(append([]string{"remove", pool}, devices...)...)
+}
+
+func (s *systemZpoolCmd) iostat(
+	ctx context.Context, pool string, interval time.Duration, count int, latency bool, queue bool, histogram bool,
+) (io.ReadCloser, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive for 'zpool iostat'")
+	}
+
+	args := []string{"iostat", "-H", "-p", "-v", "-y"}
+	if latency {
+		args = append(args, "-l")
+	}
+
+	if queue {
+		args = append(args, "-q")
+	}
+
+	if histogram {
+		args = append(args, "-w")
+	}
+
+	// Report a sample after each interval, until cancelled unless count is
+	// positive.
+	args = append(args, pool, strconv.FormatFloat(interval.Seconds(), 'f', -1, 64))
+	if count > 0 {
+		args = append(args, strconv.Itoa(count))
+	}
+
+	return streamCmd(ctx, s.executor, "zpool", args...)
+}
+
+func (s *systemZpoolCmd) events(ctx context.Context) (io.ReadCloser, error) {
+	return streamCmd(ctx, s.executor, "zpool", "events", "-H", "-v", "-f")
+}
+
+func (s *systemZpoolCmd) history(pool string, internal bool) (string, error) {
+	if internal {
+		return s.run("history", "-il", pool)
+	}
+
+	return s.run("history", "-l", pool)
+}
+
+func (s *systemZpoolCmd) checkpoint(pool string, discard bool) (string, error) {
+	if discard {
+		return s.run("checkpoint", "-d", pool)
+	}
+
+	return s.run("checkpoint", pool)
+}
+
+func (s *systemZpoolCmd) trim(
+	pool string, action vdevActivityAction, secure bool, rate uint64, devices []string,
+) (string, error) {
+	args := []string{"trim"}
+	if secure {
+		args = append(args, "-d")
 	}
 
-	return s.run("get", "-H", "-o", strings.Join(cols, ","), strings.Join(props, ","), pool)
+	if rate > 0 {
+		args = append(args, "-r", strconv.FormatUint(rate, 10))
+	}
+
+	args = append(args, activityActionArgs(action)...)
+	args = append(args, pool)
+	args = append(args, devices...)
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) initialize(pool string, action vdevActivityAction, devices []string) (string, error) {
+	args := []string{"initialize"}
+	args = append(args, activityActionArgs(action)...)
+	args = append(args, pool)
+	args = append(args, devices...)
+
+	return s.run(args...)
+}
+
+func (s *systemZpoolCmd) wait(ctx context.Context, pool string, activities []string) (string, error) {
+	if len(activities) == 0 {
+		return "", fmt.Errorf("at least one activity must be specified for 'zpool wait'")
+	}
+
+	return runCmd(ctx, s.executor, nil, "zpool", "wait", "-t", strings.Join(activities, ","), pool)
 }
 
 func (s *systemZpoolCmd) run(args ...string) (string, error) {
-	return runSystemCmd("zpool", args...)
+	return runCmd(context.Background(), s.executor, nil, "zpool", args...)
 }
 
-func realSystemCmd() *cmd {
+func newExecutorCmd(executor Executor, disableJSON bool) *cmd {
+	json := &jsonOutput{disabled: disableJSON}
+	zfs := &systemZfsCmd{executor: executor, json: json}
+	json.versions = &versionDetector{zfs: zfs}
+
 	return &cmd{
-		zfs:   &systemZfsCmd{},
-		zpool: &systemZpoolCmd{},
+		zfs:      zfs,
+		zpool:    &systemZpoolCmd{executor: executor, json: json},
+		versions: json.versions,
 	}
 }
 
-func runSystemCmd(bin string, args ...string) (string, error) {
-	cmd := exec.Command(bin, args...)
+// activityActionArgs returns the arguments of 'zpool trim' and 'zpool
+// initialize' for the action.
+func activityActionArgs(action vdevActivityAction) []string {
+	switch action {
+	case activitySuspend:
+		return []string{"-s"}
+	case activityCancel:
+		return []string{"-c"}
+	}
 
-	out, err := cmd.Output()
-	if err != nil {
-		var ee *exec.ExitError
-		if errors.As(err, &ee) {
-			return "", fmt.Errorf("command failed %s %q, reason: %w, stderr: %q", bin, args, err, ee.Stderr)
+	return nil
+}
+
+// propArgs returns the properties as a list of "<flag> prop=value" arguments
+// (or just "prop=value" if flag is empty) sorted by the property name, so
+// that the generated command line is deterministic.
+func propArgs(flag string, props map[string]string) []string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var result []string
+	for _, k := range keys {
+		if flag != "" {
+			result = append(result, flag)
 		}
 
-		return "", fmt.Errorf("command failed %s %q, reason: %w", bin, args, err)
+		result = append(result, fmt.Sprintf("%s=%s", k, props[k]))
 	}
 
-	return string(out), nil
+	return result
 }
@@ -0,0 +1,31 @@
+// Command zfs-exporter serves the metrics of the zfs pools, file systems and
+// snapshots on the system for Prometheus to scrape.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tuxdude/zfs"
+	"github.com/tuxdude/zfs/exporter"
+)
+
+func main() {
+	listen := flag.String("listen", ":9134", "Address to listen on for the HTTP server.")
+	path := flag.String("path", "/metrics", "HTTP path to serve the metrics on.")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, exporter.New(zfs.NewSystem(&zfs.SystemConfig{})))
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("Serving zfs metrics on %s%s", *listen, *path)
+	log.Fatal(server.ListenAndServe())
+}
@@ -1,5 +1,11 @@
 package zfs
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 const (
 	zfsListFilesystems zfsListType = iota
 	zfsListSnapshots
@@ -7,25 +13,77 @@ const (
 
 type zfsListType uint8
 
+const (
+	zfsUserSpace zfsSpaceType = iota
+	zfsGroupSpace
+	zfsProjectSpace
+)
+
+type zfsSpaceType uint8
+
 var (
 	zfsLisTypeToStr = map[zfsListType]string{
 		zfsListFilesystems: "filesystem",
 		zfsListSnapshots:   "snapshot",
 	}
+	zfsSpaceTypeToStr = map[zfsSpaceType]string{
+		zfsUserSpace:    "userspace",
+		zfsGroupSpace:   "groupspace",
+		zfsProjectSpace: "projectspace",
+	}
 )
 
 type zfsCmd interface {
-	list(pool string, recursive bool, listType zfsListType, cols []string) (string, error)
-	get(fsOrSnap string, props []string, cols []string) (string, error)
+	list(pool string, recursive bool, listType zfsListType, cols []string) ([][]string, error)
+	get(fsOrSnap string, props []string, cols []string) ([][]string, error)
+	set(fsOrSnap string, props map[string]string) (string, error)
 	holds(snap string) (string, error)
+	clone(snap string, target string, props map[string]string) (string, error)
+	promote(fs string) (string, error)
+	diff(snap string, other string) (string, error)
+	userspace(fs string, spaceType zfsSpaceType, cols []string) (string, error)
+	loadKey(fs string, key io.Reader) (string, error)
+	unloadKey(fs string) (string, error)
+	changeKey(fs string, inherit bool, load bool, props map[string]string, key io.Reader) (string, error)
+	mount(fs string) (string, error)
+	unmount(fs string, force bool) (string, error)
+	version() (string, error)
+	wait(ctx context.Context, fs string) (string, error)
 }
 
 type zpoolCmd interface {
-	list(cols []string) (string, error)
-	get(pool string, props []string, cols []string) (string, error)
+	list(cols []string) ([][]string, error)
+	get(pool string, props []string, cols []string, parsable bool) ([][]string, error)
+	set(pool string, prop string, value string) (string, error)
+	upgrade(pool string) (string, error)
+	upgradeList() (string, error)
+	importList(searchDirs []string) (string, error)
+	importPool(nameOrGUID string, opts *ImportPoolOptions) (string, error)
+	export(pool string, force bool) (string, error)
+	create(pool string, vdevArgs []string, poolProps map[string]string, rootFsProps map[string]string) (string, error)
+	destroy(pool string) (string, error)
+	status(pool string) (string, error)
+	statusProgress(pool string, trim bool, initialize bool) (string, error)
+	attach(pool string, device string, newDevice string, force bool) (string, error)
+	detach(pool string, device string) (string, error)
+	replace(pool string, device string, newDevice string, force bool) (string, error)
+	online(pool string, expand bool, devices []string) (string, error)
+	offline(pool string, temporary bool, devices []string) (string, error)
+	clear(pool string, device string) (string, error)
+	remove(pool string, devices []string) (string, error)
+	iostat(
+		ctx context.Context, pool string, interval time.Duration, count int, latency bool, queue bool, histogram bool,
+	) (io.ReadCloser, error)
+	events(ctx context.Context) (io.ReadCloser, error)
+	history(pool string, internal bool) (string, error)
+	checkpoint(pool string, discard bool) (string, error)
+	trim(pool string, action vdevActivityAction, secure bool, rate uint64, devices []string) (string, error)
+	initialize(pool string, action vdevActivityAction, devices []string) (string, error)
+	wait(ctx context.Context, pool string, activities []string) (string, error)
 }
 
 type cmd struct {
-	zfs   zfsCmd
-	zpool zpoolCmd
+	zfs      zfsCmd
+	zpool    zpoolCmd
+	versions *versionDetector
 }
@@ -0,0 +1,88 @@
+package zfs
+
+import (
+	"fmt"
+)
+
+// Attach attaches the new device to the existing device (or mirror) within
+// the pool, converting it into a mirror (or a wider mirror). Force allows
+// using a new device that appears to be in use. Returns the updated vdev
+// layout of the pool.
+func (p *Pool) Attach(device string, newDevice string, force bool) (*VdevTree, error) {
+	_, err := p.cmd().zpool.attach(p.Name, device, newDevice, force)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach %q to %q in pool %q, reason: %w", newDevice, device, p, err)
+	}
+
+	return p.Vdevs()
+}
+
+// Detach detaches the device from a mirror within the pool. Returns the
+// updated vdev layout of the pool.
+func (p *Pool) Detach(device string) (*VdevTree, error) {
+	_, err := p.cmd().zpool.detach(p.Name, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detach %q from pool %q, reason: %w", device, p, err)
+	}
+
+	return p.Vdevs()
+}
+
+// Replace replaces the device within the pool with the new device, or with
+// a new device at the same location if newDevice is empty. Force allows
+// using a new device that appears to be in use. Returns the updated vdev
+// layout of the pool.
+func (p *Pool) Replace(device string, newDevice string, force bool) (*VdevTree, error) {
+	_, err := p.cmd().zpool.replace(p.Name, device, newDevice, force)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace %q with %q in pool %q, reason: %w", device, newDevice, p, err)
+	}
+
+	return p.Vdevs()
+}
+
+// Online brings the devices within the pool online, additionally expanding
+// them to use all the available space if expand is true. Returns the
+// updated vdev layout of the pool.
+func (p *Pool) Online(expand bool, devices ...string) (*VdevTree, error) {
+	_, err := p.cmd().zpool.online(p.Name, expand, devices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to online %q in pool %q, reason: %w", devices, p, err)
+	}
+
+	return p.Vdevs()
+}
+
+// Offline takes the devices within the pool offline, only until the next
+// reboot if temporary is true. Returns the updated vdev layout of the pool.
+func (p *Pool) Offline(temporary bool, devices ...string) (*VdevTree, error) {
+	_, err := p.cmd().zpool.offline(p.Name, temporary, devices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to offline %q in pool %q, reason: %w", devices, p, err)
+	}
+
+	return p.Vdevs()
+}
+
+// Clear clears the error counters of the device within the pool, or of all
+// the devices if device is empty. Returns the updated vdev layout of the
+// pool.
+func (p *Pool) Clear(device string) (*VdevTree, error) {
+	_, err := p.cmd().zpool.clear(p.Name, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear errors of %q in pool %q, reason: %w", device, p, err)
+	}
+
+	return p.Vdevs()
+}
+
+// Remove removes the devices (or top-level vdevs) from the pool. Returns the
+// updated vdev layout of the pool.
+func (p *Pool) Remove(devices ...string) (*VdevTree, error) {
+	_, err := p.cmd().zpool.remove(p.Name, devices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove %q from pool %q, reason: %w", devices, p, err)
+	}
+
+	return p.Vdevs()
+}
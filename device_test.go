@@ -0,0 +1,133 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testZpoolStatusCmd = "zpool status -p tank"
+)
+
+var deviceTests = []struct {
+	name    string
+	op      func(p *Pool) (*VdevTree, error)
+	want    []string
+	wantErr bool
+}{
+	{
+		name: "Attach",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Attach("sda", "sdc", false) },
+		want: []string{"zpool attach tank sda sdc", testZpoolStatusCmd},
+	},
+	{
+		name: "Attach forced",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Attach("sda", "sdc", true) },
+		want: []string{"zpool attach -f tank sda sdc", testZpoolStatusCmd},
+	},
+	{
+		name: "Detach",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Detach("sdb") },
+		want: []string{"zpool detach tank sdb", testZpoolStatusCmd},
+	},
+	{
+		name: "Replace",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Replace("sda", "sdc", false) },
+		want: []string{"zpool replace tank sda sdc", testZpoolStatusCmd},
+	},
+	{
+		name: "Replace forced",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Replace("sda", "sdc", true) },
+		want: []string{"zpool replace -f tank sda sdc", testZpoolStatusCmd},
+	},
+	{
+		name: "Replace at the same location",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Replace("sda", "", false) },
+		want: []string{"zpool replace tank sda", testZpoolStatusCmd},
+	},
+	{
+		name: "Online",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Online(false, "sda", "sdb") },
+		want: []string{"zpool online tank sda sdb", testZpoolStatusCmd},
+	},
+	{
+		name: "Online expanded",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Online(true, "sda") },
+		want: []string{"zpool online -e tank sda", testZpoolStatusCmd},
+	},
+	{
+		name:    "Online without devices",
+		op:      func(p *Pool) (*VdevTree, error) { return p.Online(false) },
+		wantErr: true,
+	},
+	{
+		name: "Offline",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Offline(false, "sda") },
+		want: []string{"zpool offline tank sda", testZpoolStatusCmd},
+	},
+	{
+		name: "Offline temporary",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Offline(true, "sda", "sdb") },
+		want: []string{"zpool offline -t tank sda sdb", testZpoolStatusCmd},
+	},
+	{
+		name:    "Offline without devices",
+		op:      func(p *Pool) (*VdevTree, error) { return p.Offline(true) },
+		wantErr: true,
+	},
+	{
+		name: "Clear all devices",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Clear("") },
+		want: []string{"zpool clear tank", testZpoolStatusCmd},
+	},
+	{
+		name: "Clear device",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Clear("sda") },
+		want: []string{"zpool clear tank sda", testZpoolStatusCmd},
+	},
+	{
+		name: "Remove",
+		op:   func(p *Pool) (*VdevTree, error) { return p.Remove("mirror-1", "sdc") },
+		want: []string{"zpool remove tank mirror-1 sdc", testZpoolStatusCmd},
+	},
+	{
+		name:    "Remove without devices",
+		op:      func(p *Pool) (*VdevTree, error) { return p.Remove() },
+		wantErr: true,
+	},
+}
+
+func TestDeviceOperations(t *testing.T) {
+	for _, test := range deviceTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool, rec := newRecordedPool(stubExecutor{
+				testZpoolStatusCmd: {Stdout: testHealthStatus("tank", "ONLINE", "ONLINE", 0)},
+			})
+
+			got, gotErr := tc.op(pool)
+			if gotErr != nil != tc.wantErr {
+				t.Errorf(
+					"Pool.%s()\nTest Case: %q\nFailure: unexpected error\nReason: %v",
+					strings.Fields(tc.name)[0], tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, rec.commands); diff != "" {
+				t.Errorf(
+					"Pool.%s()\nTest Case: %q\nFailure: want and got commands differ\nReason:\n%s",
+					strings.Fields(tc.name)[0], tc.name, diff)
+			}
+
+			if !tc.wantErr && (got == nil || got.Root == nil || len(got.All()) != 4) {
+				t.Errorf(
+					"Pool.%s()\nTest Case: %q\nFailure: unexpected vdev layout\nReason: got %v",
+					strings.Fields(tc.name)[0], tc.name, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package zfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DiffChangeRemoved indicates that the path was removed.
+	DiffChangeRemoved DiffChangeType = "-"
+	// DiffChangeCreated indicates that the path was created.
+	DiffChangeCreated DiffChangeType = "+"
+	// DiffChangeModified indicates that the path was modified.
+	DiffChangeModified DiffChangeType = "M"
+	// DiffChangeRenamed indicates that the path was renamed.
+	DiffChangeRenamed DiffChangeType = "R"
+)
+
+const (
+	// DiffFileBlockDevice indicates a block device.
+	DiffFileBlockDevice DiffFileType = "B"
+	// DiffFileCharDevice indicates a character device.
+	DiffFileCharDevice DiffFileType = "C"
+	// DiffFileDirectory indicates a directory.
+	DiffFileDirectory DiffFileType = "/"
+	// DiffFileDoor indicates a door.
+	DiffFileDoor DiffFileType = ">"
+	// DiffFileNamedPipe indicates a named pipe.
+	DiffFileNamedPipe DiffFileType = "|"
+	// DiffFileSymlink indicates a symbolic link.
+	DiffFileSymlink DiffFileType = "@"
+	// DiffFileEventPort indicates an event port.
+	DiffFileEventPort DiffFileType = "P"
+	// DiffFileSocket indicates a socket.
+	DiffFileSocket DiffFileType = "="
+	// DiffFileRegular indicates a regular file.
+	DiffFileRegular DiffFileType = "F"
+)
+
+var (
+	validDiffChangeTypes = map[DiffChangeType]bool{
+		DiffChangeRemoved:  true,
+		DiffChangeCreated:  true,
+		DiffChangeModified: true,
+		DiffChangeRenamed:  true,
+	}
+	validDiffFileTypes = map[DiffFileType]bool{
+		DiffFileBlockDevice: true,
+		DiffFileCharDevice:  true,
+		DiffFileDirectory:   true,
+		DiffFileDoor:        true,
+		DiffFileNamedPipe:   true,
+		DiffFileSymlink:     true,
+		DiffFileEventPort:   true,
+		DiffFileSocket:      true,
+		DiffFileRegular:     true,
+	}
+)
+
+// DiffChangeType represents the type of change reported by 'zfs diff'.
+type DiffChangeType string
+
+// DiffFileType represents the type of file reported by 'zfs diff'.
+type DiffFileType string
+
+// DiffTarget represents the target to compare a snapshot against, which is
+// either a later snapshot or a file system.
+type DiffTarget interface {
+	FullName() string
+}
+
+// DiffEntry represents a single changed path between a snapshot and its
+// diff target.
+type DiffEntry struct {
+	// Type of change.
+	Change DiffChangeType
+	// Type of the file that changed.
+	FileType DiffFileType
+	// Path of the file that changed.
+	Path string
+	// New path of the file, only set when the change type is DiffChangeRenamed.
+	NewPath string
+	// Change in the link count of the file, only set when the change type
+	// is DiffChangeModified due to a hard link of the file being created
+	// (positive) or removed (negative).
+	LinkCountDelta int
+	// Inode change time of the file.
+	ChangeTime time.Time
+}
+
+// DiffEntryList represents a list of DiffEntry objects.
+type DiffEntryList []*DiffEntry
+
+// String returns the string representation of the diff entry.
+func (d *DiffEntry) String() string {
+	if d.Change == DiffChangeRenamed {
+		return fmt.Sprintf("{DiffEntry Change: %s, FileType: %s, Path: %q -> %q}", d.Change, d.FileType, d.Path, d.NewPath)
+	}
+
+	return fmt.Sprintf("{DiffEntry Change: %s, FileType: %s, Path: %q}", d.Change, d.FileType, d.Path)
+}
+
+// Diff returns the list of paths that changed between the snapshot and the
+// specified target, which is either a later snapshot or a file system.
+func (s *Snapshot) Diff(other DiffTarget) (DiffEntryList, error) {
+	out, err := s.cmd().zfs.diff(s.FullName(), other.FullName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff snapshot %q against %q, reason: %w", s, other.FullName(), err)
+	}
+
+	var result DiffEntryList
+
+	for _, line := range splitOnNewLine(out) {
+		d, err := parseDiffEntry(line)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+func parseDiffEntry(line string) (*DiffEntry, error) {
+	cols := strings.Split(line, "\t")
+	if len(cols) != 4 && len(cols) != 5 {
+		return nil, fmt.Errorf("expected 4 or 5 columns per line in diff entry, but found %d, line: %q", len(cols), line)
+	}
+
+	ctime, err := parseUnixTimestampWithNanos(cols[0], "diff entry change time")
+	if err != nil {
+		return nil, err
+	}
+
+	change := DiffChangeType(cols[1])
+	if !validDiffChangeTypes[change] {
+		return nil, fmt.Errorf("parsing \"diff entry change type\", invalid change type: %q", cols[1])
+	}
+
+	fileType := DiffFileType(cols[2])
+	if !validDiffFileTypes[fileType] {
+		return nil, fmt.Errorf("parsing \"diff entry file type\", invalid file type: %q", cols[2])
+	}
+
+	if change == DiffChangeRenamed && len(cols) != 5 {
+		return nil, fmt.Errorf("parsing \"diff entry\", renames must include the new path, line: %q", line)
+	}
+
+	if len(cols) == 5 && change != DiffChangeRenamed && change != DiffChangeModified {
+		return nil, fmt.Errorf(
+			"parsing \"diff entry\", only renames and modifications can have a fifth column, line: %q", line)
+	}
+
+	path, err := unescapeDiffPath(cols[3])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiffEntry{
+		Change:     change,
+		FileType:   fileType,
+		Path:       path,
+		ChangeTime: ctime,
+	}
+
+	switch {
+	case change == DiffChangeRenamed:
+		result.NewPath, err = unescapeDiffPath(cols[4])
+	case len(cols) == 5:
+		result.LinkCountDelta, err = parseDiffLinkCountDelta(cols[4])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseDiffLinkCountDelta parses the change in the link count reported by
+// 'zfs diff' for a modified file, e.g. "(+1)" or "(-1)".
+func parseDiffLinkCountDelta(col string) (int, error) {
+	if len(col) < 4 || col[0] != '(' || col[len(col)-1] != ')' || (col[1] != '+' && col[1] != '-') {
+		return 0, fmt.Errorf("parsing \"diff entry link count change\", invalid value: %q", col)
+	}
+
+	delta, err := strconv.Atoi(col[1 : len(col)-1])
+	if err != nil {
+		return 0, fmt.Errorf("parsing \"diff entry link count change\", invalid value: %q", col)
+	}
+
+	return delta, nil
+}
+
+// unescapeDiffPath reverses the escaping performed by 'zfs diff', which
+// emits every byte that is not a printable ASCII character (including the
+// space and the backslash) as a backslash followed by four octal digits.
+func unescapeDiffPath(path string) (string, error) {
+	if !strings.Contains(path, "\\") {
+		return path, nil
+	}
+
+	var result strings.Builder
+
+	l := len(path)
+	for i := 0; i < l; i++ {
+		if path[i] != '\\' {
+			result.WriteByte(path[i])
+			continue
+		}
+
+		if i+4 >= l {
+			return "", fmt.Errorf("parsing \"diff entry path\", truncated escape sequence in %q", path)
+		}
+
+		var b uint
+		for _, c := range path[i+1 : i+5] {
+			if c < '0' || c > '7' {
+				return "", fmt.Errorf("parsing \"diff entry path\", invalid escape sequence %q in %q", path[i:i+5], path)
+			}
+			b = b*8 + uint(c-'0')
+		}
+
+		if b > 0xff {
+			return "", fmt.Errorf("parsing \"diff entry path\", invalid escape sequence %q in %q", path[i:i+5], path)
+		}
+
+		result.WriteByte(byte(b))
+		i += 4
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,185 @@
+package zfs
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseDiffEntryTests = []struct {
+	name string
+	line string
+	want *DiffEntry
+}{
+	{
+		name: "Modified directory",
+		line: "1700000000.123456789\tM\t/\t/tank/data/",
+		want: &DiffEntry{
+			Change:     DiffChangeModified,
+			FileType:   DiffFileDirectory,
+			Path:       "/tank/data/",
+			ChangeTime: time.Unix(1700000000, 123456789),
+		},
+	},
+	{
+		name: "Created file with escaped space",
+		line: "1700000001.5\t+\tF\t/tank/data/my\\0040file",
+		want: &DiffEntry{
+			Change:     DiffChangeCreated,
+			FileType:   DiffFileRegular,
+			Path:       "/tank/data/my file",
+			ChangeTime: time.Unix(1700000001, 500000000),
+		},
+	},
+	{
+		name: "Renamed file with escaped backslash and non-ASCII bytes",
+		line: "1700000002\tR\tF\t/tank/data/a\\0134b\t/tank/data/\\0303\\0251t\\0303\\0251",
+		want: &DiffEntry{
+			Change:     DiffChangeRenamed,
+			FileType:   DiffFileRegular,
+			Path:       "/tank/data/a\\b",
+			NewPath:    "/tank/data/été",
+			ChangeTime: time.Unix(1700000002, 0),
+		},
+	},
+	{
+		name: "Hard link created",
+		line: "1700000004.000000000\tM\tF\t/tank/data/file\t(+1)",
+		want: &DiffEntry{
+			Change:         DiffChangeModified,
+			FileType:       DiffFileRegular,
+			Path:           "/tank/data/file",
+			LinkCountDelta: 1,
+			ChangeTime:     time.Unix(1700000004, 0),
+		},
+	},
+	{
+		name: "Hard link removed",
+		line: "1700000005.000000000\tM\tF\t/tank/data/file\t(-1)",
+		want: &DiffEntry{
+			Change:         DiffChangeModified,
+			FileType:       DiffFileRegular,
+			Path:           "/tank/data/file",
+			LinkCountDelta: -1,
+			ChangeTime:     time.Unix(1700000005, 0),
+		},
+	},
+	{
+		name: "Removed symlink",
+		line: "1700000003.000000001\t-\t@\t/tank/data/link",
+		want: &DiffEntry{
+			Change:     DiffChangeRemoved,
+			FileType:   DiffFileSymlink,
+			Path:       "/tank/data/link",
+			ChangeTime: time.Unix(1700000003, 1),
+		},
+	},
+}
+
+func TestParseDiffEntry(t *testing.T) {
+	for _, test := range parseDiffEntryTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := parseDiffEntry(tc.line)
+			if nil != gotErr {
+				t.Errorf(
+					"parseDiffEntry()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"parseDiffEntry()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var parseDiffEntryErrorTests = []struct {
+	name string
+	line string
+	want string
+}{
+	{
+		name: "Invalid column count",
+		line: "1700000000\tM\t/",
+		want: `expected 4 or 5 columns per line in diff entry, but found 3, line: .*`,
+	},
+	{
+		name: "Invalid change time",
+		line: "foo\tM\t/\t/tank/data/",
+		want: `parsing "diff entry change time", unable to convert "foo" to uint64:.*`,
+	},
+	{
+		name: "Invalid change type",
+		line: "1700000000\tX\t/\t/tank/data/",
+		want: `parsing "diff entry change type", invalid change type: "X"`,
+	},
+	{
+		name: "Invalid file type",
+		line: "1700000000\tM\tZ\t/tank/data/",
+		want: `parsing "diff entry file type", invalid file type: "Z"`,
+	},
+	{
+		name: "Rename without new path",
+		line: "1700000000\tR\tF\t/tank/data/a",
+		want: `parsing "diff entry", renames must include the new path, line: .*`,
+	},
+	{
+		name: "Created file with a fifth column",
+		line: "1700000000\t+\tF\t/tank/data/a\t(+1)",
+		want: `parsing "diff entry", only renames and modifications can have a fifth column, line: .*`,
+	},
+	{
+		name: "Invalid link count change",
+		line: "1700000000\tM\tF\t/tank/data/a\t/tank/data/b",
+		want: `parsing "diff entry link count change", invalid value: "/tank/data/b"`,
+	},
+	{
+		name: "Truncated escape sequence",
+		line: "1700000000\t+\tF\t/tank/data/a\\004",
+		want: `parsing "diff entry path", truncated escape sequence in .*`,
+	},
+	{
+		name: "Invalid escape sequence",
+		line: "1700000000\t+\tF\t/tank/data/a\\0093",
+		want: `parsing "diff entry path", invalid escape sequence .*`,
+	},
+}
+
+func TestParseDiffEntryErrors(t *testing.T) {
+	for _, test := range parseDiffEntryErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := parseDiffEntry(tc.line)
+			if gotErr == nil {
+				t.Errorf(
+					"parseDiffEntry()\nTest Case: %q\nFailure: gotErr == nil\nReason: want = %q",
+					tc.name, tc.want)
+				return
+			}
+
+			match, err := regexp.MatchString(tc.want, gotErr.Error())
+			if err != nil {
+				t.Errorf(
+					"parseDiffEntry()\nTest Case: %q\nFailure: unexpected exception while matching against gotErr error string\nReason: error = %v",
+					tc.name, err)
+				return
+			}
+
+			if !match {
+				t.Errorf(
+					"parseDiffEntry()\nTest Case: %q\nFailure: gotErr did not match the want regex\nReason:\n\tgotErr = %q\n\twant   = %q",
+					tc.name, gotErr, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package zfs
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// KeyStatusAvailable indicates that the encryption key is loaded.
+	KeyStatusAvailable = "available"
+	// KeyStatusUnavailable indicates that the encryption key is not loaded.
+	KeyStatusUnavailable = "unavailable"
+)
+
+var (
+	encryptionInfoOutputCols = []string{
+		"encryption",
+		"keyformat",
+		"keylocation",
+		"keystatus",
+		"encryptionroot",
+	}
+)
+
+// EncryptionInfo represents the native encryption state of a file system.
+type EncryptionInfo struct {
+	// Encryption algorithm, "off" if the file system is not encrypted.
+	Encryption string
+	// Format of the key, i.e. "raw", "hex" or "passphrase".
+	KeyFormat string
+	// Location the key is loaded from, e.g. "prompt" or "file:///path".
+	// Only set for encryption roots.
+	KeyLocation string
+	// Status of the key, i.e. KeyStatusAvailable or KeyStatusUnavailable.
+	KeyStatus string
+	// Full name of the file system the encryption key is inherited from.
+	EncryptionRoot string
+}
+
+// ChangeKeyOptions represents the options for changing the encryption key of
+// a file system.
+type ChangeKeyOptions struct {
+	// New key format, unchanged if empty.
+	KeyFormat string
+	// New key location, unchanged if empty.
+	KeyLocation string
+	// New number of PBKDF2 iterations for passphrase keys, unchanged if zero.
+	PBKDF2Iters uint64
+	// New key material, read from the key location if nil. The key is
+	// supplied over the standard input, which zfs only reads if the key
+	// location is "prompt", i.e. set KeyLocation to "prompt" unless the
+	// current key location already is.
+	Key io.Reader
+	// Load the existing key (from its key location) before changing it.
+	Load bool
+	// Make the file system inherit the key of its parent encryption root
+	// instead, all other options except Load are ignored.
+	Inherit bool
+}
+
+// String returns the string representation of the encryption info.
+func (e *EncryptionInfo) String() string {
+	return fmt.Sprintf(
+		"{EncryptionInfo Encryption: %q, KeyFormat: %q, KeyLocation: %q, KeyStatus: %q, EncryptionRoot: %q}",
+		e.Encryption,
+		e.KeyFormat,
+		e.KeyLocation,
+		e.KeyStatus,
+		e.EncryptionRoot,
+	)
+}
+
+// IsEncrypted returns true if the file system is encrypted, false otherwise.
+func (e *EncryptionInfo) IsEncrypted() bool {
+	return e.Encryption != "off"
+}
+
+// Encryption returns the native encryption state of the file system.
+func (f *FileSystem) Encryption() (*EncryptionInfo, error) {
+	out, err := f.cmd().zfs.list(
+		f.FullName(), false, zfsListFilesystems, encryptionInfoOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list encryption info of file system %q, reason: %w", f, err)
+	}
+
+	row, err := onlyRow(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse encryption info %q, reason: %w", out, err)
+	}
+
+	return parseEncryptionInfo(row)
+}
+
+// IsEncryptionRoot returns true if the file system is an encryption root
+// (i.e. it does not inherit its key from an ancestor), false otherwise.
+func (f *FileSystem) IsEncryptionRoot() (bool, error) {
+	e, err := f.Encryption()
+	if err != nil {
+		return false, err
+	}
+
+	return e.EncryptionRoot == f.FullName(), nil
+}
+
+// LoadKey loads the encryption key of the file system, which must be an
+// encryption root. The key material is read from source if non-nil,
+// otherwise from the key location of the file system.
+func (f *FileSystem) LoadKey(source io.Reader) error {
+	_, err := f.cmd().zfs.loadKey(f.FullName(), source)
+	if err != nil {
+		return fmt.Errorf("failed to load key of file system %q, reason: %w", f, err)
+	}
+
+	return nil
+}
+
+// UnloadKey unloads the encryption key of the file system, which must be an
+// encryption root and must not be mounted.
+func (f *FileSystem) UnloadKey() error {
+	_, err := f.cmd().zfs.unloadKey(f.FullName())
+	if err != nil {
+		return fmt.Errorf("failed to unload key of file system %q, reason: %w", f, err)
+	}
+
+	return nil
+}
+
+// ChangeKey changes the encryption key of the file system, a nil opts loads
+// the new key from the current key location.
+func (f *FileSystem) ChangeKey(opts *ChangeKeyOptions) error {
+	if opts == nil {
+		opts = &ChangeKeyOptions{}
+	}
+
+	props := make(map[string]string)
+	if opts.KeyFormat != "" {
+		props["keyformat"] = opts.KeyFormat
+	}
+
+	if opts.KeyLocation != "" {
+		props["keylocation"] = opts.KeyLocation
+	}
+
+	if opts.PBKDF2Iters != 0 {
+		props["pbkdf2iters"] = strconv.FormatUint(opts.PBKDF2Iters, 10)
+	}
+
+	var key io.Reader
+	if !opts.Inherit {
+		key = opts.Key
+	}
+
+	_, err := f.cmd().zfs.changeKey(f.FullName(), opts.Inherit, opts.Load, props, key)
+	if err != nil {
+		return fmt.Errorf("failed to change key of file system %q, reason: %w", f, err)
+	}
+
+	return nil
+}
+
+// EncryptionRoots returns the list of file systems within the pool that are
+// encryption roots.
+func (p *Pool) EncryptionRoots() (FileSystemList, error) {
+	cols := append(append([]string{}, listFileSystemsOutputCols...), "encryptionroot")
+
+	out, err := p.cmd().zfs.list(p.Name, true, zfsListFilesystems, cols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list encryption roots of %q, reason: %w", p, err)
+	}
+
+	var result FileSystemList
+
+	for _, row := range out {
+		if len(row) != len(cols) {
+			return nil, fmt.Errorf(
+				"expected %d columns per line in encryption root info, but found %d, line: %q",
+				len(cols), len(row), strings.Join(row, "\t"))
+		}
+
+		last := len(row) - 1
+
+		fs, err := parseFileSystemInfo(p, row[:last])
+		if err != nil {
+			return nil, err
+		}
+
+		if fs.FullName() == row[last] {
+			result = append(result, fs)
+		}
+	}
+
+	return result, nil
+}
+
+func parseEncryptionInfo(cols []string) (*EncryptionInfo, error) {
+	if len(cols) != 5 {
+		return nil, fmt.Errorf(
+			"expected 5 columns per line in encryption info, but found %d, line: %q", len(cols), strings.Join(cols, "\t"))
+	}
+
+	if len(cols[0]) == 0 {
+		return nil, fmt.Errorf("parsing \"encryption info encryption\", invalid empty encryption: %q", cols[0])
+	}
+
+	return &EncryptionInfo{
+		Encryption:     cols[0],
+		KeyFormat:      dashToEmpty(cols[1]),
+		KeyLocation:    dashToEmpty(cols[2]),
+		KeyStatus:      dashToEmpty(cols[3]),
+		EncryptionRoot: dashToEmpty(cols[4]),
+	}, nil
+}
@@ -0,0 +1,213 @@
+package zfs
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseEncryptionInfoTests = []struct {
+	name          string
+	line          string
+	want          *EncryptionInfo
+	wantEncrypted bool
+}{
+	{
+		name: "Unencrypted",
+		line: "off\tnone\tnone\t-\t-",
+		want: &EncryptionInfo{
+			Encryption:  "off",
+			KeyFormat:   "none",
+			KeyLocation: "none",
+		},
+		wantEncrypted: false,
+	},
+	{
+		name: "Encryption root with key loaded",
+		line: "aes-256-gcm\tpassphrase\tprompt\tavailable\ttank/secure",
+		want: &EncryptionInfo{
+			Encryption:     "aes-256-gcm",
+			KeyFormat:      "passphrase",
+			KeyLocation:    "prompt",
+			KeyStatus:      KeyStatusAvailable,
+			EncryptionRoot: "tank/secure",
+		},
+		wantEncrypted: true,
+	},
+	{
+		name: "Inherited key not loaded",
+		line: "aes-256-gcm\traw\tnone\tunavailable\ttank/secure",
+		want: &EncryptionInfo{
+			Encryption:     "aes-256-gcm",
+			KeyFormat:      "raw",
+			KeyLocation:    "none",
+			KeyStatus:      KeyStatusUnavailable,
+			EncryptionRoot: "tank/secure",
+		},
+		wantEncrypted: true,
+	},
+}
+
+func TestParseEncryptionInfo(t *testing.T) {
+	for _, test := range parseEncryptionInfoTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := parseEncryptionInfo(strings.Split(tc.line, "\t"))
+			if nil != gotErr {
+				t.Errorf(
+					"parseEncryptionInfo()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"parseEncryptionInfo()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+
+			if got.IsEncrypted() != tc.wantEncrypted {
+				t.Errorf(
+					"parseEncryptionInfo()\nTest Case: %q\nFailure: IsEncrypted() differs\nReason: want=%t got=%t",
+					tc.name, tc.wantEncrypted, got.IsEncrypted())
+			}
+		})
+	}
+}
+
+var changeKeyTests = []struct {
+	name      string
+	opts      *ChangeKeyOptions
+	key       string
+	want      string
+	wantStdin []string
+}{
+	{
+		name: "Nil options",
+		want: "zfs change-key tank/data",
+	},
+	{
+		name: "Key from the current key location",
+		opts: &ChangeKeyOptions{KeyFormat: "hex", PBKDF2Iters: 350000, Load: true},
+		want: "zfs change-key -l -o keyformat=hex -o pbkdf2iters=350000 tank/data",
+	},
+	{
+		name:      "Key without a key location",
+		opts:      &ChangeKeyOptions{},
+		key:       "secret\n",
+		want:      "zfs change-key tank/data",
+		wantStdin: []string{"secret\n"},
+	},
+	{
+		name:      "Key with the prompt key location",
+		opts:      &ChangeKeyOptions{KeyFormat: "passphrase", KeyLocation: "prompt"},
+		key:       "secret\n",
+		want:      "zfs change-key -o keyformat=passphrase -o keylocation=prompt tank/data",
+		wantStdin: []string{"secret\n"},
+	},
+	{
+		name: "File key location",
+		opts: &ChangeKeyOptions{KeyLocation: "file:///etc/zfs/data.key"},
+		want: "zfs change-key -o keylocation=file:///etc/zfs/data.key tank/data",
+	},
+	{
+		name: "Inherit",
+		opts: &ChangeKeyOptions{KeyFormat: "hex", Load: true, Inherit: true},
+		key:  "ignored",
+		want: "zfs change-key -l -i tank/data",
+	},
+}
+
+func TestChangeKey(t *testing.T) {
+	for _, test := range changeKeyTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, rec := newRecordedFileSystem("data", nil)
+
+			opts := tc.opts
+			if tc.key != "" {
+				withKey := *tc.opts
+				withKey.Key = strings.NewReader(tc.key)
+				opts = &withKey
+			}
+
+			if gotErr := fs.ChangeKey(opts); gotErr != nil {
+				t.Errorf(
+					"ChangeKey()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff([]string{tc.want}, rec.commands); diff != "" {
+				t.Errorf(
+					"ChangeKey()\nTest Case: %q\nFailure: want and got commands differ\nReason:\n%s",
+					tc.name, diff)
+			}
+
+			if diff := cmp.Diff(tc.wantStdin, rec.stdin); diff != "" {
+				t.Errorf(
+					"ChangeKey()\nTest Case: %q\nFailure: want and got stdin differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var loadKeyTests = []struct {
+	name      string
+	source    string
+	want      string
+	wantStdin []string
+}{
+	{
+		name: "Key location",
+		want: "zfs load-key tank/data",
+	},
+	{
+		name:      "Key source",
+		source:    "secret\n",
+		want:      "zfs load-key -L prompt tank/data",
+		wantStdin: []string{"secret\n"},
+	},
+}
+
+func TestLoadKey(t *testing.T) {
+	for _, test := range loadKeyTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, rec := newRecordedFileSystem("data", nil)
+
+			var source io.Reader
+			if tc.source != "" {
+				source = strings.NewReader(tc.source)
+			}
+
+			if gotErr := fs.LoadKey(source); gotErr != nil {
+				t.Errorf(
+					"LoadKey()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff([]string{tc.want}, rec.commands); diff != "" {
+				t.Errorf(
+					"LoadKey()\nTest Case: %q\nFailure: want and got commands differ\nReason:\n%s",
+					tc.name, diff)
+			}
+
+			if diff := cmp.Diff(tc.wantStdin, rec.stdin); diff != "" {
+				t.Errorf(
+					"LoadKey()\nTest Case: %q\nFailure: want and got stdin differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound indicates that the pool, dataset or device does not exist.
+	ErrNotFound = errors.New("does not exist")
+	// ErrBusy indicates that the pool or dataset is busy, e.g. mounted file
+	// systems that are in use. Destroying a snapshot with user holds also
+	// fails with ErrBusy, since zfs reports it as "dataset is busy".
+	ErrBusy = errors.New("is busy")
+	// ErrExists indicates that the pool or dataset already exists.
+	ErrExists = errors.New("already exists")
+	// ErrPermission indicates that the user lacks the privileges to perform
+	// the operation.
+	ErrPermission = errors.New("permission denied")
+	// ErrHasClones indicates that the file system or snapshot cannot be
+	// destroyed since it has dependent clones.
+	ErrHasClones = errors.New("has dependent clones")
+	// ErrPoolUnavailable indicates that the pool is unavailable or its I/O
+	// is suspended due to missing or faulted devices.
+	ErrPoolUnavailable = errors.New("pool is unavailable")
+)
+
+// stderrErrors maps the messages reported by zfs and zpool in the standard
+// error output to the corresponding errors. The more specific messages are
+// listed first, since e.g. the clones of a snapshot are reported as
+// "snapshot is cloned" with the error code of an existing dataset.
+var stderrErrors = []struct {
+	messages []string
+	err      error
+}{
+	{
+		messages: []string{
+			"pool is unavailable",
+			"one or more devices is currently unavailable",
+			"pool i/o is currently suspended",
+		},
+		err: ErrPoolUnavailable,
+	},
+	{
+		messages: []string{"has dependent clones", "snapshot is cloned"},
+		err:      ErrHasClones,
+	},
+	{
+		messages: []string{
+			"permission denied",
+			"must be superuser",
+			"insufficient privileges",
+			"operation not permitted",
+		},
+		err: ErrPermission,
+	},
+	{
+		messages: []string{"is busy"},
+		err:      ErrBusy,
+	},
+	{
+		messages: []string{"already exists", "checkpoint exists"},
+		err:      ErrExists,
+	},
+	{
+		messages: []string{
+			"does not exist",
+			"no such pool",
+			"no such device in pool",
+			"could not find any snapshots",
+		},
+		err: ErrNotFound,
+	},
+}
+
+// CommandError represents a zfs or zpool command that failed.
+type CommandError struct {
+	// Name of the command, i.e. "zfs" or "zpool".
+	Name string
+	// Arguments of the command.
+	Args []string
+	// Exit status of the command, or -1 if the command did not run to
+	// completion, e.g. it could not be started.
+	ExitCode int
+	// Standard error output of the command.
+	Stderr string
+	// Kind of the failure derived from the standard error output, i.e. one
+	// of ErrNotFound, ErrBusy, ErrExists, ErrPermission, ErrHasClones
+	// or ErrPoolUnavailable, nil if unknown.
+	Kind error
+	// Underlying error returned by the Executor.
+	Err error
+}
+
+// Error returns the string representation of the command error.
+func (e *CommandError) Error() string {
+	if e.ExitCode < 0 {
+		return fmt.Sprintf("command failed %s %q, reason: %v", e.Name, e.Args, e.Err)
+	}
+
+	return fmt.Sprintf("command failed %s %q, reason: %v, stderr: %q", e.Name, e.Args, e.Err, e.Stderr)
+}
+
+// Unwrap returns the underlying error returned by the Executor.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is the kind of the failure, so that e.g.
+// errors.Is(err, ErrNotFound) matches the commands that failed since the
+// dataset does not exist.
+func (e *CommandError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// cmdError returns a *CommandError for the command that failed with err.
+func cmdError(name string, args []string, err error) error {
+	result := &CommandError{Name: name, Args: args, ExitCode: -1, Err: err}
+
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		result.ExitCode = ee.ExitCode
+		result.Stderr = ee.Stderr
+		result.Kind = classifyStderr(ee.Stderr)
+	}
+
+	return result
+}
+
+// classifyStderr returns the error corresponding to the message in the
+// standard error output, or nil if unknown.
+func classifyStderr(stderr string) error {
+	msg := strings.ToLower(stderr)
+
+	for _, e := range stderrErrors {
+		for _, m := range e.messages {
+			if strings.Contains(msg, m) {
+				return e.err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package zfs
+
+import (
+	"errors"
+	"testing"
+)
+
+var allKindErrors = []error{
+	ErrNotFound,
+	ErrBusy,
+	ErrExists,
+	ErrPermission,
+	ErrHasClones,
+	ErrPoolUnavailable,
+}
+
+var cmdErrorTests = []struct {
+	name   string
+	stderr string
+	want   error
+}{
+	{
+		name:   "Dataset does not exist",
+		stderr: "cannot open 'tank/missing': dataset does not exist\n",
+		want:   ErrNotFound,
+	},
+	{
+		name:   "No such pool",
+		stderr: "cannot open 'missing': no such pool\n",
+		want:   ErrNotFound,
+	},
+	{
+		name:   "No such device",
+		stderr: "cannot online sdz: no such device in pool\n",
+		want:   ErrNotFound,
+	},
+	{
+		name:   "Dataset busy",
+		stderr: "cannot unmount '/tank/home': pool or dataset is busy\n",
+		want:   ErrBusy,
+	},
+	{
+		name:   "Dataset exists",
+		stderr: "cannot create 'tank/home': dataset already exists\n",
+		want:   ErrExists,
+	},
+	{
+		name:   "Pool exists",
+		stderr: "cannot create 'tank': pool already exists\n",
+		want:   ErrExists,
+	},
+	{
+		name:   "Checkpoint exists",
+		stderr: "cannot checkpoint 'tank': checkpoint exists\n",
+		want:   ErrExists,
+	},
+	{
+		name:   "Permission denied",
+		stderr: "cannot create 'tank/home': permission denied\n",
+		want:   ErrPermission,
+	},
+	{
+		name:   "Not root",
+		stderr: "Permission denied the ZFS utilities must be run as root.\n",
+		want:   ErrPermission,
+	},
+	{
+		name:   "Snapshot held",
+		stderr: "cannot destroy snapshot tank/home@daily: dataset is busy\n",
+		want:   ErrBusy,
+	},
+	{
+		name:   "Dependent clones",
+		stderr: "cannot destroy 'tank/home@daily': snapshot has dependent clones\nuse '-R' to destroy the following datasets:\ntank/clone\n",
+		want:   ErrHasClones,
+	},
+	{
+		name:   "Snapshot cloned",
+		stderr: "cannot destroy snapshot tank/home@daily: snapshot is cloned\n",
+		want:   ErrHasClones,
+	},
+	{
+		name:   "Pool unavailable",
+		stderr: "cannot open 'tank': pool is unavailable\n",
+		want:   ErrPoolUnavailable,
+	},
+	{
+		name:   "Pool I/O suspended",
+		stderr: "cannot set property for 'tank': pool I/O is currently suspended\n",
+		want:   ErrPoolUnavailable,
+	},
+	{
+		name:   "Unknown",
+		stderr: "cannot create 'tank/h!me': invalid character '!' in name\n",
+	},
+}
+
+func TestCmdErrorKind(t *testing.T) {
+	for _, test := range cmdErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := cmdError("zfs", []string{"destroy", "tank/home"}, &ExitError{ExitCode: 1, Stderr: tc.stderr})
+
+			for _, kind := range allKindErrors {
+				if got := errors.Is(err, kind); got != (kind == tc.want) {
+					t.Errorf(
+						"cmdError()\nTest Case: %q\nFailure: errors.Is(err, %v) = %t\nstderr: %q",
+						tc.name, kind, got, tc.stderr)
+				}
+			}
+
+			var cmdErr *CommandError
+			if !errors.As(err, &cmdErr) || cmdErr.Name != "zfs" || cmdErr.ExitCode != 1 || cmdErr.Stderr != tc.stderr {
+				t.Errorf("cmdError()\nTest Case: %q\nFailure: want a *CommandError\nGot: %#v", tc.name, err)
+			}
+
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Errorf("cmdError()\nTest Case: %q\nFailure: want the wrapped *ExitError\nGot: %v", tc.name, err)
+			}
+		})
+	}
+}
+
+func TestCmdErrorWrapped(t *testing.T) {
+	t.Parallel()
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zpool list -H -p -o name,guid,size,allocated,free,fragmentation,health,altroot": {
+				ExitCode: 1,
+				Stderr:   "permission denied\n",
+			},
+		},
+	})
+
+	_, err := system.ListPools()
+	if !errors.Is(err, ErrPermission) {
+		t.Errorf("ListPools()\nTest Case: %q\nFailure: want ErrPermission\nGot: %v", "Permission denied", err)
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Name != "zpool" || cmdErr.Args[0] != "list" {
+		t.Errorf("ListPools()\nTest Case: %q\nFailure: want a *CommandError for zpool list\nGot: %v", "Permission denied", err)
+	}
+
+	pool := &Pool{Name: "tank", System: system}
+
+	_, err = pool.Status()
+	if !errors.As(err, &cmdErr) || cmdErr.ExitCode != -1 || cmdErr.Kind != nil {
+		t.Errorf("Status()\nTest Case: %q\nFailure: want a *CommandError without an exit status\nGot: %#v", "Not started", err)
+	}
+}
@@ -0,0 +1,410 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EventClassChecksum is the class of the events reported on checksum
+	// errors.
+	EventClassChecksum = "ereport.fs.zfs.checksum"
+	// EventClassIO is the class of the events reported on I/O errors.
+	EventClassIO = "ereport.fs.zfs.io"
+	// EventClassDelay is the class of the events reported on slow I/O.
+	EventClassDelay = "ereport.fs.zfs.delay"
+	// EventClassStateChange is the class of the events reported when the
+	// state of a vdev changes.
+	EventClassStateChange = "resource.fs.zfs.statechange"
+	// EventClassScrubFinish is the class of the events reported when a scrub
+	// finishes.
+	EventClassScrubFinish = "sysevent.fs.zfs.scrub_finish"
+	// EventClassResilverFinish is the class of the events reported when a
+	// resilver finishes.
+	EventClassResilverFinish = "sysevent.fs.zfs.resilver_finish"
+
+	eventTimeLayout = "Jan 02 2006 15:04:05.000000000"
+)
+
+var (
+	// Names of the vdev states (vdev_state_t) as reported by zpool status.
+	eventVdevStates = map[uint64]string{
+		0: "UNKNOWN",
+		1: "CLOSED",
+		2: "OFFLINE",
+		3: "REMOVED",
+		4: "UNAVAIL",
+		5: "FAULTED",
+		6: "DEGRADED",
+		7: "ONLINE",
+	}
+)
+
+// Event represents a single event generated by the zfs kernel module, as
+// reported by 'zpool events'.
+type Event struct {
+	// Time at which the event was generated.
+	Time time.Time
+	// Class of the event, e.g. "ereport.fs.zfs.checksum".
+	Class string
+	// Event ID, which is unique and increasing since the module was loaded.
+	EID uint64
+	// Name of the pool the event pertains to, if any.
+	Pool string
+	// GUID of the pool the event pertains to, if any.
+	PoolGUID uint64
+	// Path of the vdev the event pertains to, if any.
+	Vdev string
+	// GUID of the vdev the event pertains to, if any.
+	VdevGUID uint64
+	// All the fields of the event payload, with the string values unquoted
+	// and the numeric values as reported (usually in hex). The fields of
+	// embedded lists are prefixed by the name of the list and a dot, e.g.
+	// "detector.scheme".
+	Payload map[string]string
+	// Error encountered while watching the events, no more events follow
+	// an event with an error.
+	Err error
+}
+
+// EventHandler is a function invoked for each of the dispatched events.
+type EventHandler func(e *Event)
+
+// EventMux dispatches events to the handlers registered for their class.
+type EventMux struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+// String returns the string representation of the event.
+func (e *Event) String() string {
+	return fmt.Sprintf(
+		"{Event Class: %q, EID: %d, Time: %v, Pool: %q, Vdev: %q}",
+		e.Class,
+		e.EID,
+		e.Time,
+		e.Pool,
+		e.Vdev,
+	)
+}
+
+// Uint64 returns the value of the specified numeric payload field.
+func (e *Event) Uint64(field string) (uint64, error) {
+	val, ok := e.Payload[field]
+	if !ok {
+		return 0, fmt.Errorf("event %v does not include the field %q", e, field)
+	}
+
+	return parseEventUint64(val, field)
+}
+
+// VdevState returns the new state of the vdev (e.g. "DEGRADED") for the
+// state change events, or an empty string otherwise.
+func (e *Event) VdevState() string {
+	return e.vdevStateField("vdev_state")
+}
+
+// VdevLastState returns the previous state of the vdev (e.g. "ONLINE") for
+// the state change events, or an empty string otherwise.
+func (e *Event) VdevLastState() string {
+	return e.vdevStateField("vdev_laststate")
+}
+
+// vdevStateField returns the name of the vdev state in the field, which is
+// reported either as the numeric state or, by newer versions, as the quoted
+// name followed by the numeric state, e.g. "DEGRADED" (0x6).
+func (e *Event) vdevStateField(field string) string {
+	val, ok := e.Payload[field]
+	if !ok {
+		return ""
+	}
+
+	if strings.HasPrefix(val, "\"") {
+		if idx := strings.Index(val[1:], "\""); idx >= 0 {
+			return val[1 : idx+1]
+		}
+	}
+
+	state, err := parseEventUint64(val, field)
+	if err != nil {
+		return ""
+	}
+
+	if name, ok := eventVdevStates[state]; ok {
+		return name
+	}
+
+	return eventVdevStates[0]
+}
+
+// WatchEvents follows the events generated by the zfs kernel module and
+// streams them over the returned channel. The events already buffered by
+// the module are delivered first. The channel is closed once an error is
+// encountered or the context is cancelled.
+func (s *System) WatchEvents(ctx context.Context) (<-chan *Event, error) {
+	stream, err := s.cmd.zpool.events(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch events, reason: %w", err)
+	}
+
+	result := make(chan *Event)
+
+	go func() {
+		defer close(result)
+
+		send := func(e *Event) bool {
+			select {
+			case result <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		err := parseEvents(stream, send)
+		if closeErr := stream.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil && ctx.Err() == nil {
+			send(&Event{Err: fmt.Errorf("failed to watch events, reason: %w", err)})
+		}
+	}()
+
+	return result, nil
+}
+
+// NewEventMux returns a new event multiplexer without any handlers.
+func NewEventMux() *EventMux {
+	return &EventMux{handlers: make(map[string][]EventHandler)}
+}
+
+// Handle registers the handler for the events of the specified class. The
+// class can also be a prefix of the class components, e.g. "ereport.fs.zfs"
+// matches all the zfs error reports, while an empty class matches all the
+// events.
+func (m *EventMux) Handle(class string, handler EventHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers[class] = append(m.handlers[class], handler)
+}
+
+// Dispatch invokes the handlers registered for the class of the event, the
+// handlers of the more specific classes first.
+func (m *EventMux) Dispatch(e *Event) {
+	m.mu.RLock()
+
+	var classes []string
+
+	for class := range m.handlers {
+		if eventClassMatches(class, e.Class) {
+			classes = append(classes, class)
+		}
+	}
+
+	sort.Slice(classes, func(i, j int) bool {
+		if len(classes[i]) != len(classes[j]) {
+			return len(classes[i]) > len(classes[j])
+		}
+
+		return classes[i] < classes[j]
+	})
+
+	var handlers []EventHandler
+	for _, class := range classes {
+		handlers = append(handlers, m.handlers[class]...)
+	}
+
+	m.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+// Serve dispatches all the events received over the channel until it is
+// closed, and returns the error of the last event if any.
+func (m *EventMux) Serve(events <-chan *Event) error {
+	for e := range events {
+		if e.Err != nil {
+			return e.Err
+		}
+
+		m.Dispatch(e)
+	}
+
+	return nil
+}
+
+func eventClassMatches(pattern string, class string) bool {
+	return pattern == "" || pattern == class || strings.HasPrefix(class, pattern+".")
+}
+
+// parseEvents parses the output of 'zpool events -H -v', which consists of
+// a "<time>\t<class>" line per event followed by the indented payload
+// fields and a blank line, and invokes emit for each event until emit
+// returns false.
+func parseEvents(r io.Reader, emit func(e *Event) bool) error {
+	var current *Event
+
+	// Names of the embedded lists the current field is nested within.
+	var nesting []string
+
+	flush := func() bool {
+		if current == nil {
+			return true
+		}
+
+		e := current
+		current = nil
+		nesting = nil
+
+		return emit(e)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.TrimSpace(line) == "" {
+			if !flush() {
+				return nil
+			}
+
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			if !flush() {
+				return nil
+			}
+
+			e, err := parseEventHeader(line)
+			if err != nil {
+				return err
+			}
+
+			current = e
+
+			continue
+		}
+
+		if current == nil {
+			return fmt.Errorf("found event payload without an event, line: %q", line)
+		}
+
+		nesting = parseEventField(current, nesting, strings.TrimSpace(line))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read events, reason: %w", err)
+	}
+
+	flush()
+
+	return nil
+}
+
+func parseEventHeader(line string) (*Event, error) {
+	cols := strings.Split(line, "\t")
+	if len(cols) != 2 {
+		return nil, fmt.Errorf("expected 2 columns in event header, but found %d, line: %q", len(cols), line)
+	}
+
+	t, err := parseTimestamp(cols[0], eventTimeLayout, "event time")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{Time: t, Class: cols[1], Payload: make(map[string]string)}, nil
+}
+
+// parseEventField parses a single payload field of the event, and returns
+// the updated list of embedded lists the following fields are nested
+// within.
+func parseEventField(e *Event, nesting []string, field string) []string {
+	if strings.HasPrefix(field, "(start ") {
+		return append(nesting, strings.TrimSuffix(strings.TrimPrefix(field, "(start "), ")"))
+	}
+
+	if strings.HasPrefix(field, "(end ") {
+		if len(nesting) > 0 {
+			return nesting[:len(nesting)-1]
+		}
+
+		return nesting
+	}
+
+	idx := strings.Index(field, " = ")
+	if idx < 0 {
+		// Keys without a value are boolean flags.
+		e.Payload[eventFieldName(nesting, field)] = "true"
+		return nesting
+	}
+
+	key, val := field[:idx], field[idx+3:]
+
+	if val == "(embedded nvlist)" {
+		return append(nesting, key)
+	}
+
+	if strings.HasSuffix(val, "embedded nvlists)") {
+		// The individual lists follow, each within a start and end marker.
+		return nesting
+	}
+
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		val = val[1 : len(val)-1]
+	}
+
+	name := eventFieldName(nesting, key)
+	e.Payload[name] = val
+
+	if len(nesting) > 0 {
+		return nesting
+	}
+
+	switch key {
+	case "eid":
+		e.EID, _ = parseEventUint64(val, key)
+	case "pool":
+		e.Pool = val
+	case "pool_guid":
+		e.PoolGUID, _ = parseEventUint64(val, key)
+	case "vdev_path":
+		e.Vdev = val
+	case "vdev_guid":
+		e.VdevGUID, _ = parseEventUint64(val, key)
+	}
+
+	return nesting
+}
+
+func eventFieldName(nesting []string, key string) string {
+	if len(nesting) == 0 {
+		return key
+	}
+
+	return strings.Join(nesting, ".") + "." + key
+}
+
+// parseEventUint64 parses the numeric payload values, which are reported in
+// hex with a "0x" prefix.
+func parseEventUint64(str string, desc string) (uint64, error) {
+	res, err := strconv.ParseUint(str, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %q, unable to convert %q to uint64: %w", desc, str, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,196 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testEventsOut = "Oct 18 2026 10:00:00.123456789\tereport.fs.zfs.checksum\n" +
+		"        class = \"ereport.fs.zfs.checksum\"\n" +
+		"        ena = 0x1b1a2c3d4e00001\n" +
+		"        detector = (embedded nvlist)\n" +
+		"                version = 0x0\n" +
+		"                scheme = \"zfs\"\n" +
+		"                pool = 0x3e3f1a2b3c4d5e6f\n" +
+		"        (end detector)\n" +
+		"        pool = \"tank\"\n" +
+		"        pool_guid = 0x3e3f1a2b3c4d5e6f\n" +
+		"        vdev_guid = 0x1122334455667788\n" +
+		"        vdev_type = \"disk\"\n" +
+		"        vdev_path = \"/dev/sda1\"\n" +
+		"        bad_ranges = 0x0 0x200\n" +
+		"        time = 0x6712345 0x75bcd15\n" +
+		"        eid = 0x2a\n" +
+		"\n" +
+		"Oct 18 2026 10:00:05.000000001\tresource.fs.zfs.statechange\n" +
+		"        version = 0x0\n" +
+		"        class = \"resource.fs.zfs.statechange\"\n" +
+		"        pool = \"tank\"\n" +
+		"        pool_guid = 0x3e3f1a2b3c4d5e6f\n" +
+		"        vdev_guid = 0x1122334455667788\n" +
+		"        vdev_path = \"/dev/sda1\"\n" +
+		"        vdev_state = \"DEGRADED\" (0x6)\n" +
+		"        vdev_laststate = 0x7\n" +
+		"        children = (2 embedded nvlists)\n" +
+		"        (start children[0])\n" +
+		"                path = \"/dev/sdb1\"\n" +
+		"        (end children[0])\n" +
+		"        eid = 0x2b\n" +
+		"\n"
+)
+
+func TestParseEvents(t *testing.T) {
+	t.Parallel()
+
+	want := []*Event{
+		{
+			Time:     time.Date(2026, 10, 18, 10, 0, 0, 123456789, time.Local),
+			Class:    EventClassChecksum,
+			EID:      42,
+			Pool:     "tank",
+			PoolGUID: 0x3e3f1a2b3c4d5e6f,
+			Vdev:     "/dev/sda1",
+			VdevGUID: 0x1122334455667788,
+			Payload: map[string]string{
+				"class":            "ereport.fs.zfs.checksum",
+				"ena":              "0x1b1a2c3d4e00001",
+				"detector.version": "0x0",
+				"detector.scheme":  "zfs",
+				"detector.pool":    "0x3e3f1a2b3c4d5e6f",
+				"pool":             "tank",
+				"pool_guid":        "0x3e3f1a2b3c4d5e6f",
+				"vdev_guid":        "0x1122334455667788",
+				"vdev_type":        "disk",
+				"vdev_path":        "/dev/sda1",
+				"bad_ranges":       "0x0 0x200",
+				"time":             "0x6712345 0x75bcd15",
+				"eid":              "0x2a",
+			},
+		},
+		{
+			Time:     time.Date(2026, 10, 18, 10, 0, 5, 1, time.Local),
+			Class:    EventClassStateChange,
+			EID:      43,
+			Pool:     "tank",
+			PoolGUID: 0x3e3f1a2b3c4d5e6f,
+			Vdev:     "/dev/sda1",
+			VdevGUID: 0x1122334455667788,
+			Payload: map[string]string{
+				"version":          "0x0",
+				"class":            "resource.fs.zfs.statechange",
+				"pool":             "tank",
+				"pool_guid":        "0x3e3f1a2b3c4d5e6f",
+				"vdev_guid":        "0x1122334455667788",
+				"vdev_path":        "/dev/sda1",
+				"vdev_state":       "\"DEGRADED\" (0x6)",
+				"vdev_laststate":   "0x7",
+				"children[0].path": "/dev/sdb1",
+				"eid":              "0x2b",
+			},
+		},
+	}
+
+	var got []*Event
+
+	gotErr := parseEvents(strings.NewReader(testEventsOut), func(e *Event) bool {
+		got = append(got, e)
+		return true
+	})
+	if gotErr != nil {
+		t.Errorf(
+			"parseEvents()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"parseEvents()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestEventVdevState(t *testing.T) {
+	t.Parallel()
+
+	var got []*Event
+
+	_ = parseEvents(strings.NewReader(testEventsOut), func(e *Event) bool {
+		got = append(got, e)
+		return true
+	})
+
+	if len(got) != 2 {
+		t.Errorf(
+			"parseEvents()\nTest Case: %q\nFailure: expected 2 events\nReason: got %v",
+			t.Name(), got)
+		return
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "Checksum state", got: got[0].VdevState(), want: ""},
+		{name: "State with name", got: got[1].VdevState(), want: "DEGRADED"},
+		{name: "Numeric last state", got: got[1].VdevLastState(), want: "ONLINE"},
+	}
+
+	for _, tc := range tests {
+		if tc.got != tc.want {
+			t.Errorf(
+				"Event.VdevState()\nTest Case: %q\nFailure: got != want\nReason: got = %q, want = %q",
+				tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestEventMux(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+
+	record := func(name string) EventHandler {
+		return func(e *Event) {
+			got = append(got, name+":"+e.Class)
+		}
+	}
+
+	mux := NewEventMux()
+	mux.Handle("", record("all"))
+	mux.Handle("ereport.fs.zfs", record("ereport"))
+	mux.Handle(EventClassChecksum, record("checksum"))
+	mux.Handle("ereport.fs.zf", record("partial"))
+
+	events := make(chan *Event, 3)
+	events <- &Event{Class: EventClassChecksum}
+	events <- &Event{Class: EventClassStateChange}
+	events <- &Event{Class: EventClassIO}
+	close(events)
+
+	if gotErr := mux.Serve(events); gotErr != nil {
+		t.Errorf(
+			"EventMux.Serve()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	want := []string{
+		"checksum:ereport.fs.zfs.checksum",
+		"ereport:ereport.fs.zfs.checksum",
+		"all:ereport.fs.zfs.checksum",
+		"all:resource.fs.zfs.statechange",
+		"ereport:ereport.fs.zfs.io",
+		"all:ereport.fs.zfs.io",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"EventMux.Serve()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
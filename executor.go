@@ -0,0 +1,140 @@
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// Executor runs the zfs and zpool commands issued by a System. The default
+// executor runs the commands on the local system, while alternate executors
+// can simulate, record or replay the commands.
+type Executor interface {
+	// Run runs the command (i.e. "zfs" or "zpool") with the specified
+	// arguments to completion, supplying stdin (if non-nil) as the standard
+	// input, and returns the standard output. A command exiting with a
+	// non-zero status returns an *ExitError.
+	Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error)
+	// Stream starts the command with the specified arguments and returns its
+	// standard output as a stream, for commands that run until cancelled
+	// (e.g. 'zpool events -f'). Closing the stream waits for the command to
+	// exit and returns its error, if any.
+	Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error)
+}
+
+// ExitError represents a command that exited with a non-zero status.
+type ExitError struct {
+	// Exit status of the command.
+	ExitCode int
+	// Standard error output of the command.
+	Stderr string
+}
+
+// ExecExecutor is the Executor that runs the commands on the local system.
+type ExecExecutor struct {
+}
+
+// Error returns the string representation of the exit error.
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.ExitCode)
+}
+
+// Run runs the command on the local system.
+func (e *ExecExecutor) Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", execError(err, &stderr)
+	}
+
+	return string(out), nil
+}
+
+// Stream starts the command on the local system.
+func (e *ExecExecutor) Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &execStream{ReadCloser: stdout, cmd: cmd, stderr: &stderr}, nil
+}
+
+type execStream struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func (s *execStream) Close() error {
+	s.ReadCloser.Close()
+
+	if err := s.cmd.Wait(); err != nil {
+		return execError(err, s.stderr)
+	}
+
+	return nil
+}
+
+// execError converts the exit status of the command into an *ExitError.
+func execError(err error, stderr *bytes.Buffer) error {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return &ExitError{ExitCode: ee.ExitCode(), Stderr: stderr.String()}
+	}
+
+	return err
+}
+
+// runCmd runs the command using the executor, and wraps the error with the
+// command line and the standard error output.
+func runCmd(ctx context.Context, executor Executor, stdin io.Reader, name string, args ...string) (string, error) {
+	out, err := executor.Run(ctx, stdin, name, args...)
+	if err != nil {
+		return "", cmdError(name, args, err)
+	}
+
+	return out, nil
+}
+
+// streamCmd starts the command using the executor, and wraps the errors
+// with the command line and the standard error output.
+func streamCmd(ctx context.Context, executor Executor, name string, args ...string) (io.ReadCloser, error) {
+	stream, err := executor.Stream(ctx, name, args...)
+	if err != nil {
+		return nil, cmdError(name, args, err)
+	}
+
+	return &cmdStream{ReadCloser: stream, name: name, args: args}, nil
+}
+
+type cmdStream struct {
+	io.ReadCloser
+	name string
+	args []string
+}
+
+func (c *cmdStream) Close() error {
+	if err := c.ReadCloser.Close(); err != nil {
+		return cmdError(c.name, c.args, err)
+	}
+
+	return nil
+}
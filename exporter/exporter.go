@@ -0,0 +1,220 @@
+// Package exporter provides a Prometheus exporter for the pools, file
+// systems and snapshots of the zfs package.
+package exporter
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/tuxdude/zfs"
+)
+
+const (
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	poolHealthStates = []string{
+		"ONLINE",
+		"DEGRADED",
+		"FAULTED",
+		"OFFLINE",
+		"UNAVAIL",
+		"REMOVED",
+		"SUSPENDED",
+	}
+)
+
+// Exporter collects the metrics of all the pools within the system on
+// every scrape and serves them in the Prometheus text exposition format.
+type Exporter struct {
+	system *zfs.System
+	now    func() time.Time
+}
+
+// New returns a new exporter for the pools within the specified system.
+func New(system *zfs.System) *Exporter {
+	return &Exporter{
+		system: system,
+		now:    time.Now,
+	}
+}
+
+// ServeHTTP collects the metrics and writes them to the response.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+
+	if err := e.collect().write(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// collect collects the metrics of all the pools. Failures to collect parts
+// of the metrics are reported using the zfs_exporter_collect_errors metric
+// rather than failing the scrape.
+func (e *Exporter) collect() *metricSet {
+	m := newMetricSet()
+	errCount := 0
+
+	pools, err := e.system.ListPools()
+	if err != nil {
+		errCount++
+	}
+
+	for _, p := range pools {
+		errCount += e.collectPool(m, p)
+	}
+
+	m.gauge(
+		"zfs_exporter_collect_errors",
+		"Number of errors encountered while collecting the metrics.",
+		float64(errCount))
+
+	return m
+}
+
+func (e *Exporter) collectPool(m *metricSet, p *zfs.Pool) int {
+	errCount := 0
+	pl := label{"pool", p.Name}
+
+	m.gauge("zfs_pool_size_bytes", "Total size of the pool.", float64(p.Size), pl)
+	m.gauge("zfs_pool_allocated_bytes", "Number of bytes allocated in the pool.", float64(p.Allocated), pl)
+	m.gauge("zfs_pool_free_bytes", "Number of bytes free in the pool.", float64(p.Free), pl)
+	m.gauge(
+		"zfs_pool_fragmentation_percent", "Fragmentation percentage of the pool.",
+		float64(p.FragmentationPercent), pl)
+
+	for _, h := range poolHealthStates {
+		m.gauge(
+			"zfs_pool_health", "Health status of the pool, 1 for the current status and 0 otherwise.",
+			boolToFloat(p.HealthStatus == h), pl, label{"health", h})
+	}
+
+	if err := e.collectScrub(m, p); err != nil {
+		errCount++
+	}
+
+	fsList, err := p.FileSystems()
+	if err != nil {
+		return errCount + 1
+	}
+
+	// The space usage and the snapshots of all the file systems are listed
+	// once per pool rather than once per file system.
+	space, err := p.SpaceUsage()
+	if err != nil {
+		errCount++
+	}
+
+	var snapshots map[string]zfs.SnapshotList
+
+	snapList, err := p.Snapshots()
+	if err != nil {
+		errCount++
+	} else {
+		snapshots = make(map[string]zfs.SnapshotList)
+		for _, s := range snapList {
+			name := s.FileSystem.FullName()
+			snapshots[name] = append(snapshots[name], s)
+		}
+	}
+
+	for _, fs := range fsList {
+		e.collectFileSystem(m, fs, space, snapshots)
+	}
+
+	return errCount
+}
+
+func (e *Exporter) collectScrub(m *metricSet, p *zfs.Pool) error {
+	status, err := p.Status()
+	if err != nil {
+		return err
+	}
+
+	scan, err := status.ScanStatus()
+	if err != nil {
+		return err
+	}
+
+	pl := label{"pool", p.Name}
+
+	m.gauge(
+		"zfs_pool_scrub_in_progress", "Whether a scrub is in progress (including paused) on the pool.",
+		boolToFloat(scan.Function == "scrub" && scan.InProgress), pl)
+	m.gauge(
+		"zfs_pool_resilver_in_progress", "Whether a resilver is in progress on the pool.",
+		boolToFloat(scan.Function == "resilver" && scan.InProgress), pl)
+
+	if !scan.InProgress && !scan.Canceled && !scan.EndTime.IsZero() {
+		m.gauge(
+			"zfs_pool_scan_last_completed_timestamp_seconds",
+			"Unix timestamp of the completion of the last scrub or resilver.",
+			float64(scan.EndTime.Unix()), pl, label{"function", scan.Function})
+		m.gauge(
+			"zfs_pool_scan_last_errors", "Number of errors found by the last scrub or resilver.",
+			float64(scan.Errors), pl, label{"function", scan.Function})
+	}
+
+	return nil
+}
+
+// collectFileSystem collects the metrics of the file system from the space
+// usage and the snapshots listed for its pool, the nil maps indicate that
+// the listing failed and the corresponding metrics are skipped.
+func (e *Exporter) collectFileSystem(
+	m *metricSet,
+	fs *zfs.FileSystem,
+	spaceUsage map[string]*zfs.SpaceUsage,
+	snapshots map[string]zfs.SnapshotList,
+) {
+	labels := []label{{"pool", fs.Pool.Name}, {"dataset", fs.FullName()}}
+
+	if space, ok := spaceUsage[fs.FullName()]; ok {
+		spaceMetrics := []struct {
+			name  string
+			help  string
+			value uint64
+		}{
+			{"zfs_dataset_available_bytes", "Number of bytes available to the dataset and its children.", space.Available},
+			{"zfs_dataset_used_bytes", "Number of bytes consumed by the dataset and its descendants.", space.Used},
+			{"zfs_dataset_used_by_snapshots_bytes", "Number of bytes consumed by snapshots of the dataset.", space.UsedBySnapshots},
+			{"zfs_dataset_used_by_dataset_bytes", "Number of bytes consumed by the dataset itself.", space.UsedByDataset},
+			{"zfs_dataset_used_by_refreservation_bytes", "Number of bytes consumed by the refreservation of the dataset.", space.UsedByRefReservation},
+			{"zfs_dataset_used_by_children_bytes", "Number of bytes consumed by the children of the dataset.", space.UsedByChildren},
+			{"zfs_dataset_referenced_bytes", "Number of bytes accessible by the dataset.", space.Referenced},
+			{"zfs_dataset_logical_used_bytes", "Number of bytes consumed by the dataset and its descendants before compression.", space.LogicalUsed},
+		}
+
+		for _, s := range spaceMetrics {
+			m.gauge(s.name, s.help, float64(s.value), labels...)
+		}
+
+		m.gauge("zfs_dataset_compress_ratio", "Compression ratio achieved for the used space of the dataset.", space.CompressRatio, labels...)
+	}
+
+	if snapshots == nil {
+		return
+	}
+
+	fsSnapshots := snapshots[fs.FullName()]
+	m.gauge("zfs_dataset_snapshots", "Number of snapshots of the dataset.", float64(len(fsSnapshots)), labels...)
+
+	var newest time.Time
+	for _, s := range fsSnapshots {
+		if s.Creation.After(newest) {
+			newest = s.Creation
+		}
+	}
+
+	if !newest.IsZero() {
+		m.gauge(
+			"zfs_dataset_newest_snapshot_age_seconds", "Age of the newest snapshot of the dataset.",
+			e.now().Sub(newest).Seconds(), labels...)
+	}
+}
@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuxdude/zfs"
+	"github.com/tuxdude/zfs/zfstest"
+)
+
+func TestExporterServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	sim := zfstest.New()
+	sim.SetClock(func() time.Time { return now.Add(-time.Hour) })
+
+	system := sim.System()
+	layout := zfs.NewPoolLayout().Data(zfs.MirrorVdev("sda", "sdb"))
+
+	if _, err := system.CreatePool("tank", layout, nil, nil); err != nil {
+		t.Fatalf("CreatePool()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", t.Name(), err)
+	}
+
+	setup := []error{
+		sim.CreateFileSystem("tank/home", nil),
+		sim.CreateFileSystem("tank/data", nil),
+		sim.SetProp("tank/home", "used", "4096"),
+		sim.Snapshot("tank@daily", true),
+	}
+	for _, err := range setup {
+		if err != nil {
+			t.Fatalf("Sim\nTest Case: %q\nFailure: setup failed\nReason: %v", t.Name(), err)
+		}
+	}
+
+	e := New(system)
+	e.now = func() time.Time { return now }
+
+	before := len(sim.Commands())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	resp := rec.Result()
+	body, _ := io.ReadAll(resp.Body)
+	got := string(body)
+
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != contentType {
+		t.Errorf(
+			"Exporter.ServeHTTP()\nTest Case: %q\nFailure: unexpected response\nReason: status = %d, content type = %q",
+			t.Name(), resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	want := []string{
+		`zfs_pool_health{health="ONLINE",pool="tank"} 1`,
+		`zfs_pool_health{health="DEGRADED",pool="tank"} 0`,
+		`zfs_pool_scrub_in_progress{pool="tank"} 0`,
+		`zfs_dataset_used_bytes{dataset="tank/home",pool="tank"} 4096`,
+		`zfs_dataset_snapshots{dataset="tank",pool="tank"} 1`,
+		`zfs_dataset_snapshots{dataset="tank/data",pool="tank"} 1`,
+		`zfs_dataset_snapshots{dataset="tank/home",pool="tank"} 1`,
+		`zfs_dataset_newest_snapshot_age_seconds{dataset="tank/home",pool="tank"} 3600`,
+		`zfs_exporter_collect_errors 0`,
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w+"\n") {
+			t.Errorf(
+				"Exporter.ServeHTTP()\nTest Case: %q\nFailure: metric not found\nReason:\n\twant = %q\n\tgot  =\n%s",
+				t.Name(), w, got)
+		}
+	}
+
+	// The space usage and the snapshots are listed once for the pool, rather
+	// than once per file system.
+	lists := map[string]int{}
+	for _, c := range sim.Commands()[before:] {
+		for _, listType := range []string{"snapshot", "filesystem"} {
+			if strings.HasPrefix(c, "zfs list ") && strings.Contains(c, " -t "+listType+" ") {
+				lists[listType]++
+			}
+		}
+	}
+
+	// The file systems are listed for the pool, for its snapshots and for
+	// the space usage.
+	if lists["filesystem"] != 3 || lists["snapshot"] != 1 {
+		t.Errorf(
+			"Exporter.ServeHTTP()\nTest Case: %q\nFailure: unexpected number of listings\nReason: got %v, commands = %q",
+			t.Name(), lists, sim.Commands()[before:])
+	}
+}
@@ -0,0 +1,132 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	metricTypeGauge = "gauge"
+)
+
+// label represents a single label of a sample.
+type label struct {
+	name  string
+	value string
+}
+
+// sample represents a single sample of a metric family.
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// metricFamily represents a group of samples sharing the same metric name.
+type metricFamily struct {
+	name       string
+	help       string
+	metricType string
+	samples    []sample
+}
+
+// metricSet represents an ordered set of metric families.
+type metricSet struct {
+	families []*metricFamily
+	byName   map[string]*metricFamily
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{byName: make(map[string]*metricFamily)}
+}
+
+// gauge adds a sample to the gauge metric family with the specified name,
+// creating the family if it does not exist yet.
+func (m *metricSet) gauge(name string, help string, value float64, labels ...label) {
+	f, ok := m.byName[name]
+	if !ok {
+		f = &metricFamily{name: name, help: help, metricType: metricTypeGauge}
+		m.byName[name] = f
+		m.families = append(m.families, f)
+	}
+
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// write writes all the metric families in the Prometheus text exposition
+// format.
+func (m *metricSet) write(w io.Writer) error {
+	var b strings.Builder
+
+	for _, f := range m.families {
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.metricType)
+
+		for _, s := range f.samples {
+			b.WriteString(f.name)
+			writeLabels(&b, s.labels)
+			b.WriteString(" ")
+			b.WriteString(formatValue(s.value))
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeLabels(b *strings.Builder, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	sorted := append([]label{}, labels...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+
+	b.WriteString("{")
+
+	for i, l := range sorted {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		fmt.Fprintf(b, "%s=\"%s\"", l.name, escapeLabelValue(l.value))
+	}
+
+	b.WriteString("}")
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
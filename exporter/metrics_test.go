@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMetricSetWrite(t *testing.T) {
+	t.Parallel()
+
+	m := newMetricSet()
+	m.gauge("zfs_pool_size_bytes", "Total size of the pool.", 1024, label{"pool", "tank"})
+	m.gauge("zfs_dataset_compress_ratio", "Compression\nratio.", 1.5, label{"pool", "tank"}, label{"dataset", `tank/a"b\c`})
+	m.gauge("zfs_pool_size_bytes", "Total size of the pool.", 2048, label{"pool", "backup"})
+	m.gauge("zfs_exporter_collect_errors", "Number of errors.", math.NaN())
+
+	want := strings.Join([]string{
+		"# HELP zfs_pool_size_bytes Total size of the pool.",
+		"# TYPE zfs_pool_size_bytes gauge",
+		`zfs_pool_size_bytes{pool="tank"} 1024`,
+		`zfs_pool_size_bytes{pool="backup"} 2048`,
+		`# HELP zfs_dataset_compress_ratio Compression\nratio.`,
+		"# TYPE zfs_dataset_compress_ratio gauge",
+		`zfs_dataset_compress_ratio{dataset="tank/a\"b\\c",pool="tank"} 1.5`,
+		"# HELP zfs_exporter_collect_errors Number of errors.",
+		"# TYPE zfs_exporter_collect_errors gauge",
+		"zfs_exporter_collect_errors NaN",
+		"",
+	}, "\n")
+
+	var got strings.Builder
+	if err := m.write(&got); err != nil {
+		t.Errorf(
+			"metricSet.write()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), err)
+		return
+	}
+
+	if got.String() != want {
+		t.Errorf(
+			"metricSet.write()\nTest Case: %q\nFailure: want and got differ\nReason:\n\tgot  = %q\n\twant = %q",
+			t.Name(), got.String(), want)
+	}
+}
@@ -0,0 +1,236 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// FeatureDisabled indicates that the feature is not enabled on the pool.
+	FeatureDisabled FeatureState = "disabled"
+	// FeatureEnabled indicates that the feature is enabled on the pool, but
+	// not yet in use, so the pool can still be imported by systems without
+	// the feature.
+	FeatureEnabled FeatureState = "enabled"
+	// FeatureActive indicates that the feature is in use by the pool.
+	FeatureActive FeatureState = "active"
+
+	// CompatibilityOff disables the feature set restrictions of the pool.
+	CompatibilityOff = "off"
+	// CompatibilityLegacy disables all the features of the pool.
+	CompatibilityLegacy = "legacy"
+
+	featurePropPrefix = "feature@"
+)
+
+var (
+	getAllPoolPropsOutputCols = []string{
+		"property",
+		"value",
+	}
+
+	upgradeFeatureRegex = regexp.MustCompile(`^(\S+)\s*(\(read-only compatible\))?\s*$`)
+)
+
+// FeatureState represents the state of a pool feature.
+type FeatureState string
+
+// Feature represents a feature flag of a pool.
+type Feature struct {
+	// Name of the feature without the "feature@" prefix, e.g.
+	// "async_destroy".
+	Name string
+	// State of the feature on the pool.
+	State FeatureState
+	// Description of the feature, empty if not reported by the installed
+	// version of zfs.
+	Description string
+	// True if the pool can be imported in read-only mode by systems that
+	// do not support the feature even when it is active.
+	ReadOnlyCompatible bool
+}
+
+// FeatureList represents a list of Feature objects.
+type FeatureList []*Feature
+
+// String returns the string representation of the feature.
+func (f *Feature) String() string {
+	return fmt.Sprintf("{Feature Name: %q, State: %q}", f.Name, f.State)
+}
+
+// Lookup returns the feature with the specified name, or nil if the
+// feature is not found.
+func (l FeatureList) Lookup(name string) *Feature {
+	name = strings.TrimPrefix(name, featurePropPrefix)
+	for _, f := range l {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// MissingFrom returns the names of the features that are active in the
+// list, but are neither enabled nor active in the other list, e.g. the
+// features of the pool receiving a raw send stream.
+func (l FeatureList) MissingFrom(other FeatureList) []string {
+	var result []string
+
+	for _, f := range l {
+		if f.State != FeatureActive {
+			continue
+		}
+
+		o := other.Lookup(f.Name)
+		if o == nil || o.State == FeatureDisabled {
+			result = append(result, f.Name)
+		}
+	}
+
+	return result
+}
+
+// Features returns all the features supported by the installed version of
+// zfs along with their state on the pool.
+func (p *Pool) Features() (FeatureList, error) {
+	rows, err := p.cmd().zpool.get(p.Name, []string{"all"}, getAllPoolPropsOutputCols, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get features of pool %q, reason: %w", p, err)
+	}
+
+	result, err := parseFeatureStates(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse features of pool %q, reason: %w", p, err)
+	}
+
+	out, err := p.cmd().zpool.upgradeList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supported features, reason: %w", err)
+	}
+
+	for _, desc := range parseFeatureDescriptions(out) {
+		if f := result.Lookup(desc.Name); f != nil {
+			f.Description = desc.Description
+			f.ReadOnlyCompatible = desc.ReadOnlyCompatible
+		}
+	}
+
+	return result, nil
+}
+
+// EnableFeature enables the feature (e.g. "async_destroy" or
+// "feature@async_destroy") on the pool, features cannot be disabled once
+// enabled.
+func (p *Pool) EnableFeature(name string) error {
+	prop := featurePropPrefix + strings.TrimPrefix(name, featurePropPrefix)
+
+	_, err := p.cmd().zpool.set(p.Name, prop, string(FeatureEnabled))
+	if err != nil {
+		return fmt.Errorf("failed to enable feature %q of pool %q, reason: %w", name, p, err)
+	}
+
+	return nil
+}
+
+// Upgrade enables all the features supported by the installed version of
+// zfs on the pool, limited to the feature sets of the compatibility
+// property of the pool.
+func (p *Pool) Upgrade() error {
+	_, err := p.cmd().zpool.upgrade(p.Name)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// Compatibility returns the feature sets (e.g. "openzfs-2.1-linux" or
+// "legacy") that the features of the pool are restricted to, empty if
+// there are no restrictions.
+func (p *Pool) Compatibility() ([]string, error) {
+	val, err := p.GetProp("compatibility")
+	if err != nil {
+		return nil, err
+	}
+
+	if val == CompatibilityOff || val == "-" || val == "" {
+		return nil, nil
+	}
+
+	return strings.Split(val, ","), nil
+}
+
+// SetCompatibility restricts the features of the pool to the union of the
+// specified feature sets, the restrictions are removed if none are
+// specified.
+func (p *Pool) SetCompatibility(featureSets ...string) error {
+	val := CompatibilityOff
+	if len(featureSets) > 0 {
+		val = strings.Join(featureSets, ",")
+	}
+
+	_, err := p.cmd().zpool.set(p.Name, "compatibility", val)
+	if err != nil {
+		return fmt.Errorf("failed to set compatibility of pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// parseFeatureStates parses the "feature@" properties from the output of
+// 'zpool get all' with the property and value columns.
+func parseFeatureStates(rows [][]string) (FeatureList, error) {
+	var result FeatureList
+
+	for _, cols := range rows {
+		if len(cols) != 2 {
+			return nil, fmt.Errorf(
+				"expected 2 columns per line in pool properties, but found %d, line: %q",
+				len(cols), strings.Join(cols, "\t"))
+		}
+
+		if !strings.HasPrefix(cols[0], featurePropPrefix) {
+			continue
+		}
+
+		state := FeatureState(cols[1])
+		if state != FeatureDisabled && state != FeatureEnabled && state != FeatureActive {
+			return nil, fmt.Errorf("parsing \"feature state\", invalid state %q of %q", cols[1], cols[0])
+		}
+
+		result = append(result, &Feature{Name: strings.TrimPrefix(cols[0], featurePropPrefix), State: state})
+	}
+
+	return result, nil
+}
+
+// parseFeatureDescriptions parses the supported features listed by 'zpool
+// upgrade -v', ignoring the legacy versions.
+func parseFeatureDescriptions(out string) FeatureList {
+	var result FeatureList
+
+	inFeatures := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "FEAT "):
+			inFeatures = true
+		case !inFeatures || strings.HasPrefix(line, "---"):
+		case strings.TrimSpace(line) == "":
+			inFeatures = false
+		case line[0] == ' ' || line[0] == '\t':
+			if len(result) > 0 {
+				f := result[len(result)-1]
+				f.Description = strings.TrimSpace(f.Description + " " + strings.TrimSpace(line))
+			}
+		default:
+			if m := upgradeFeatureRegex.FindStringSubmatch(line); m != nil {
+				result = append(result, &Feature{Name: m[1], ReadOnlyCompatible: m[2] != ""})
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,161 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testZpoolGetAll = "size\t1992864825344\n" +
+		"capacity\t25\n" +
+		"compatibility\topenzfs-2.1-linux\n" +
+		"feature@async_destroy\tenabled\n" +
+		"feature@empty_bpobj\tactive\n" +
+		"feature@lz4_compress\tactive\n" +
+		"feature@draid\tdisabled\n"
+	testZpoolUpgradeList = `This system supports ZFS pool feature flags.
+
+The following features are supported:
+
+FEAT DESCRIPTION
+-------------------------------------------------------------
+async_destroy                         (read-only compatible)
+     Destroy filesystems asynchronously.
+empty_bpobj                           (read-only compatible)
+     Snapshots use less space.
+lz4_compress
+     LZ4 compression algorithm support.
+draid
+     Support for distributed spare RAID
+
+The following legacy versions are also supported:
+
+VER  DESCRIPTION
+---  --------------------------------------------------------
+ 1   Initial ZFS version
+ 2   Ditto blocks (replicated metadata)
+`
+)
+
+func TestPoolFeatures(t *testing.T) {
+	t.Parallel()
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zpool get -H -o property,value all tank":  {Stdout: testZpoolGetAll},
+			"zpool upgrade -v":                         {Stdout: testZpoolUpgradeList},
+			"zpool get -H -o value compatibility tank": {Stdout: "openzfs-2.1-linux\n"},
+		},
+		DisableJSON: true,
+	})
+	pool := &Pool{Name: "tank", System: system}
+
+	got, err := pool.Features()
+	if err != nil {
+		t.Fatalf("Features()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Features", err)
+	}
+
+	want := FeatureList{
+		{
+			Name:               "async_destroy",
+			State:              FeatureEnabled,
+			Description:        "Destroy filesystems asynchronously.",
+			ReadOnlyCompatible: true,
+		},
+		{
+			Name:               "empty_bpobj",
+			State:              FeatureActive,
+			Description:        "Snapshots use less space.",
+			ReadOnlyCompatible: true,
+		},
+		{
+			Name:        "lz4_compress",
+			State:       FeatureActive,
+			Description: "LZ4 compression algorithm support.",
+		},
+		{
+			Name:        "draid",
+			State:       FeatureDisabled,
+			Description: "Support for distributed spare RAID",
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Features()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "Features", diff)
+	}
+
+	compat, err := pool.Compatibility()
+	if err != nil {
+		t.Fatalf("Compatibility()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Compatibility", err)
+	}
+
+	if diff := cmp.Diff([]string{"openzfs-2.1-linux"}, compat); diff != "" {
+		t.Errorf("Compatibility()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "Compatibility", diff)
+	}
+}
+
+func TestParseFeatureStatesErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, out := range []string{"feature@async_destroy\tunknown\n", "feature@async_destroy enabled\n"} {
+		if _, err := parseFeatureStates(splitRows(out, 2)); err == nil {
+			t.Errorf("parseFeatureStates()\nTest Case: %q\nFailure: gotErr == nil", out)
+		}
+	}
+}
+
+var featuresMissingFromTests = []struct {
+	name  string
+	local FeatureList
+	other FeatureList
+	want  []string
+}{
+	{
+		name: "All supported",
+		local: FeatureList{
+			{Name: "encryption", State: FeatureActive},
+			{Name: "draid", State: FeatureEnabled},
+		},
+		other: FeatureList{
+			{Name: "encryption", State: FeatureEnabled},
+		},
+	},
+	{
+		name: "Disabled on the receiver",
+		local: FeatureList{
+			{Name: "encryption", State: FeatureActive},
+			{Name: "large_blocks", State: FeatureActive},
+		},
+		other: FeatureList{
+			{Name: "encryption", State: FeatureActive},
+			{Name: "large_blocks", State: FeatureDisabled},
+		},
+		want: []string{"large_blocks"},
+	},
+	{
+		name: "Unknown to the receiver",
+		local: FeatureList{
+			{Name: "encryption", State: FeatureActive},
+			{Name: "block_cloning", State: FeatureActive},
+		},
+		other: FeatureList{
+			{Name: "encryption", State: FeatureEnabled},
+		},
+		want: []string{"block_cloning"},
+	},
+}
+
+func TestFeaturesMissingFrom(t *testing.T) {
+	for _, test := range featuresMissingFromTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.local.MissingFrom(tc.other)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("MissingFrom()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", tc.name, diff)
+			}
+		})
+	}
+}
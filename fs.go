@@ -70,6 +70,11 @@ func (f *FileSystem) GetProp(prop string) (string, error) {
 	return getPropForFsOrSnap(f.Pool, f.FullName(), prop)
 }
 
+// SetProp sets the specified property's value for the file system.
+func (f *FileSystem) SetProp(prop string, value string) error {
+	return setPropsForFsOrSnap(f.Pool, f.FullName(), map[string]string{prop: value})
+}
+
 func (f *FileSystem) cmd() *cmd {
 	return f.Pool.cmd()
 }
@@ -83,8 +88,8 @@ func listFileSystems(pool *Pool) (FileSystemList, error) {
 
 	var result FileSystemList
 
-	for _, line := range splitOnNewLine(out) {
-		fs, err := parseFileSystemInfo(pool, line)
+	for _, row := range out {
+		fs, err := parseFileSystemInfo(pool, row)
 		if err != nil {
 			return nil, err
 		}
@@ -99,10 +104,11 @@ func listFileSystems(pool *Pool) (FileSystemList, error) {
 	return result, nil
 }
 
-func parseFileSystemInfo(pool *Pool, line string) (*FileSystem, error) {
-	cols := strings.Split(line, "\t")
+func parseFileSystemInfo(pool *Pool, cols []string) (*FileSystem, error) {
 	if len(cols) != 3 {
-		return nil, fmt.Errorf("expected 3 columns per line in file system info, but found %d, line: %q", len(cols), line)
+		return nil, fmt.Errorf(
+			"expected 3 columns per line in file system info, but found %d, line: %q",
+			len(cols), strings.Join(cols, "\t"))
 	}
 
 	name := cols[0]
@@ -133,10 +139,81 @@ func getPropForFsOrSnap(pool *Pool, fsOrSnap string, prop string) (string, error
 			"failed to get property %q of filesystem/snapshot %q, reason: %w", prop, fsOrSnap, err)
 	}
 
-	val, err := strFromOnlyLine(out)
+	val, err := valueFromOnlyRow(out)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse property value %q, reason: %w", out, err)
 	}
 
 	return val, nil
 }
+
+// getUint64PropForFsOrSnap returns the exact numeric value of the specified
+// property. Unlike getPropForFsOrSnap, the value is not formatted for human
+// consumption.
+func getUint64PropForFsOrSnap(pool *Pool, fsOrSnap string, listType zfsListType, prop string) (uint64, error) {
+	out, err := pool.cmd().zfs.list(fsOrSnap, false, listType, []string{prop})
+	if err != nil {
+		return 0, fmt.Errorf(
+			"failed to list property %q of filesystem/snapshot %q, reason: %w", prop, fsOrSnap, err)
+	}
+
+	val, err := valueFromOnlyRow(out)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse property value %q, reason: %w", out, err)
+	}
+
+	return parseUint64(val, fmt.Sprintf("property %s", prop))
+}
+
+func setPropsForFsOrSnap(pool *Pool, fsOrSnap string, props map[string]string) error {
+	_, err := pool.cmd().zfs.set(fsOrSnap, props)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to set properties %v of filesystem/snapshot %q, reason: %w", props, fsOrSnap, err)
+	}
+
+	return nil
+}
+
+// Origin returns the snapshot this file system was cloned from, or nil if
+// the file system is not a clone.
+func (f *FileSystem) Origin() (*Snapshot, error) {
+	origin, err := f.GetProp("origin")
+	if err != nil {
+		return nil, err
+	}
+
+	if origin == "-" || origin == "" {
+		return nil, nil
+	}
+
+	return snapshotByFullName(f.Pool, origin)
+}
+
+// Promote promotes this clone file system so that it no longer depends on
+// its origin snapshot, reversing the parent-child dependency.
+func (f *FileSystem) Promote() error {
+	_, err := f.cmd().zfs.promote(f.FullName())
+	if err != nil {
+		return fmt.Errorf("failed to promote file system %q, reason: %w", f, err)
+	}
+
+	return nil
+}
+
+// fileSystemByFullName looks up the file system with the specified full
+// name (i.e. prefixed by the pool name) within the pool.
+func fileSystemByFullName(pool *Pool, fullName string) (*FileSystem, error) {
+	out, err := pool.cmd().zfs.list(
+		fullName, false, zfsListFilesystems, listFileSystemsOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file system %q, reason: %w", fullName, err)
+	}
+
+	row, err := onlyRow(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file system info %q, reason: %w", out, err)
+	}
+
+	return parseFileSystemInfo(pool, row)
+}
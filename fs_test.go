@@ -0,0 +1,106 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var originTests = []struct {
+	name    string
+	stub    stubExecutor
+	want    *Snapshot
+	wantErr bool
+}{
+	{
+		name: "Not a clone",
+		stub: stubExecutor{"zfs get -H -o value origin tank/clone": {Stdout: "-\n"}},
+	},
+	{
+		name: "Clone",
+		stub: stubExecutor{
+			"zfs get -H -o value origin tank/clone":                        {Stdout: "tank/data@daily\n"},
+			"zfs list -H -p -t filesystem -o name,guid,creation tank/data": {Stdout: "tank/data\t1000\t1690000000\n"},
+			"zfs list -H -p -t snapshot -o name,guid,creation tank/data@daily": {
+				Stdout: "tank/data@daily\t2000\t1700000000\n",
+			},
+		},
+		want: &Snapshot{
+			Name:       "daily",
+			FileSystem: &FileSystem{Name: "data", GUID: 1000, Creation: time.Unix(1690000000, 0)},
+			GUID:       2000,
+			Creation:   time.Unix(1700000000, 0),
+		},
+	},
+	{
+		name:    "Origin snapshot missing",
+		stub:    stubExecutor{"zfs get -H -o value origin tank/clone": {Stdout: "tank/data@daily\n"}},
+		wantErr: true,
+	},
+}
+
+func TestFileSystemOrigin(t *testing.T) {
+	for _, test := range originTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, _ := newRecordedFileSystem("clone", tc.stub)
+
+			got, gotErr := fs.Origin()
+			if gotErr != nil != tc.wantErr {
+				t.Errorf(
+					"FileSystem.Origin()\nTest Case: %q\nFailure: unexpected error\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmpIgnorePool); diff != "" {
+				t.Errorf(
+					"FileSystem.Origin()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var promoteTests = []struct {
+	name    string
+	stub    stubExecutor
+	wantErr bool
+}{
+	{
+		name: "Promoted",
+	},
+	{
+		name: "Not a clone",
+		stub: stubExecutor{
+			"zfs promote tank/clone": {ExitCode: 1, Stderr: "cannot promote 'tank/clone': not a cloned filesystem"},
+		},
+		wantErr: true,
+	},
+}
+
+func TestFileSystemPromote(t *testing.T) {
+	for _, test := range promoteTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, rec := newRecordedFileSystem("clone", tc.stub)
+
+			if gotErr := fs.Promote(); gotErr != nil != tc.wantErr {
+				t.Errorf(
+					"FileSystem.Promote()\nTest Case: %q\nFailure: unexpected error\nReason: %v",
+					tc.name, gotErr)
+			}
+
+			if diff := cmp.Diff([]string{"zfs promote tank/clone"}, rec.commands); diff != "" {
+				t.Errorf(
+					"FileSystem.Promote()\nTest Case: %q\nFailure: want and got commands differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthPoolAdded indicates that a new pool was found.
+	HealthPoolAdded HealthChangeType = "pool_added"
+	// HealthPoolRemoved indicates that a pool is no longer found, e.g. since
+	// it was exported or destroyed.
+	HealthPoolRemoved HealthChangeType = "pool_removed"
+	// HealthPoolStateChanged indicates that the state of a pool changed,
+	// e.g. from ONLINE to DEGRADED.
+	HealthPoolStateChanged HealthChangeType = "pool_state_changed"
+	// HealthVdevStateChanged indicates that the state of a vdev changed,
+	// e.g. from ONLINE to FAULTED.
+	HealthVdevStateChanged HealthChangeType = "vdev_state_changed"
+	// HealthVdevErrorsIncreased indicates that any of the read, write or
+	// checksum error counters of a vdev increased.
+	HealthVdevErrorsIncreased HealthChangeType = "vdev_errors_increased"
+)
+
+// HealthChangeType represents the type of a health change.
+type HealthChangeType string
+
+// VdevErrors represents the error counters of a vdev.
+type VdevErrors struct {
+	Read     uint64
+	Write    uint64
+	Checksum uint64
+}
+
+// HealthChange represents a single change in the health of a pool or one
+// of its vdevs between two consecutive samples.
+type HealthChange struct {
+	// Type of the change.
+	Type HealthChangeType
+	// Time at which the change was detected.
+	Time time.Time
+	// Name of the pool.
+	Pool string
+	// Name of the vdev, empty for the changes pertaining to the pool.
+	Vdev string
+	// Previous state, empty if the pool was added.
+	OldState string
+	// Current state, empty if the pool was removed.
+	NewState string
+	// Previous error counters of the vdev.
+	OldErrors VdevErrors
+	// Current error counters of the vdev.
+	NewErrors VdevErrors
+}
+
+// HealthHandler is a function invoked for each health change.
+type HealthHandler func(c *HealthChange)
+
+// HealthErrorHandler is a function invoked for each failed poll of Run.
+type HealthErrorHandler func(err error)
+
+// HealthMonitor periodically samples the status of all the pools and
+// reports the changes in the health of the pools and their vdevs.
+type HealthMonitor struct {
+	system   *System
+	interval time.Duration
+
+	mu            sync.Mutex
+	handlers      []*healthHandler
+	errorHandlers []*healthHandler
+	nextID        uint64
+	prev          map[string]*PoolStatus
+	now           func() time.Time
+}
+
+// healthHandler is a registered change or error handler, the id allows
+// removing the handlers registered by Changes.
+type healthHandler struct {
+	id       uint64
+	onChange HealthHandler
+	onError  HealthErrorHandler
+}
+
+// String returns the string representation of the health change.
+func (c *HealthChange) String() string {
+	return fmt.Sprintf(
+		"{HealthChange Type: %q, Pool: %q, Vdev: %q, OldState: %q, NewState: %q}",
+		c.Type,
+		c.Pool,
+		c.Vdev,
+		c.OldState,
+		c.NewState,
+	)
+}
+
+// NewHealthMonitor returns a new health monitor that samples the status of
+// all the pools of the system at the specified interval.
+func NewHealthMonitor(system *System, interval time.Duration) *HealthMonitor {
+	return &HealthMonitor{
+		system:   system,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// OnChange registers the handler to be invoked for each health change.
+func (m *HealthMonitor) OnChange(handler HealthHandler) {
+	m.addHandler(&healthHandler{onChange: handler})
+}
+
+// OnError registers the handler to be invoked for each failed poll of Run.
+func (m *HealthMonitor) OnError(handler HealthErrorHandler) {
+	m.addHandler(&healthHandler{onError: handler})
+}
+
+// addHandler registers the handler and returns its id.
+func (m *HealthMonitor) addHandler(h *healthHandler) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	h.id = m.nextID
+
+	if h.onChange != nil {
+		m.handlers = append(m.handlers, h)
+	} else {
+		m.errorHandlers = append(m.errorHandlers, h)
+	}
+
+	return h.id
+}
+
+// removeHandlers unregisters the change and error handlers with the
+// specified ids.
+func (m *HealthMonitor) removeHandlers(ids ...uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	remove := func(handlers []*healthHandler) []*healthHandler {
+		var result []*healthHandler
+
+	outer:
+		for _, h := range handlers {
+			for _, id := range ids {
+				if h.id == id {
+					continue outer
+				}
+			}
+
+			result = append(result, h)
+		}
+
+		return result
+	}
+
+	m.handlers = remove(m.handlers)
+	m.errorHandlers = remove(m.errorHandlers)
+}
+
+// Poll samples the status of all the pools, invokes the handlers for each
+// change since the previous sample and returns the changes. The first
+// sample only establishes the baseline and reports no changes.
+func (m *HealthMonitor) Poll() ([]*HealthChange, error) {
+	pools, err := m.system.ListPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll pool health, reason: %w", err)
+	}
+
+	cur := make(map[string]*PoolStatus, len(pools))
+
+	for _, p := range pools {
+		status, err := p.Status()
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll pool health, reason: %w", err)
+		}
+
+		cur[p.Name] = status
+	}
+
+	m.mu.Lock()
+
+	var changes []*HealthChange
+	if m.prev != nil {
+		changes = diffHealth(m.prev, cur, m.now())
+	}
+
+	m.prev = cur
+	handlers := append([]*healthHandler{}, m.handlers...)
+
+	m.mu.Unlock()
+
+	for _, c := range changes {
+		for _, h := range handlers {
+			h.onChange(c)
+		}
+	}
+
+	return changes, nil
+}
+
+// Run polls the health of the pools at the configured interval until the
+// context is cancelled, and returns the error of the context. A failed
+// poll, e.g. due to a transient command failure, is reported to the error
+// handlers and the polling continues at the next interval.
+func (m *HealthMonitor) Run(ctx context.Context) error {
+	if m.interval <= 0 {
+		return fmt.Errorf("invalid health monitor interval %v, must be positive", m.interval)
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Poll(); err != nil {
+			m.mu.Lock()
+			handlers := append([]*healthHandler{}, m.errorHandlers...)
+			m.mu.Unlock()
+
+			for _, h := range handlers {
+				h.onError(err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Changes runs the monitor (see Run) and streams the health changes and
+// the poll errors over the returned channels, both of which are closed
+// once Run returns. The error returned by Run is sent over the error
+// channel last. Both the channels must be received from, since the
+// polling blocks until the change or the error is received.
+func (m *HealthMonitor) Changes(ctx context.Context) (<-chan *HealthChange, <-chan error) {
+	result := make(chan *HealthChange)
+	errs := make(chan error, 1)
+	stop := make(chan struct{})
+
+	// The handlers may still be running in a concurrent Poll after they
+	// are removed, the channels are only closed once none of them can send.
+	var mu sync.Mutex
+	closed := false
+
+	changeID := m.addHandler(&healthHandler{onChange: func(c *HealthChange) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if closed {
+			return
+		}
+
+		select {
+		case result <- c:
+		case <-ctx.Done():
+		case <-stop:
+		}
+	}})
+	errorID := m.addHandler(&healthHandler{onError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if closed {
+			return
+		}
+
+		select {
+		case errs <- err:
+		case <-ctx.Done():
+		case <-stop:
+		}
+	}})
+
+	go func() {
+		err := m.Run(ctx)
+		m.removeHandlers(changeID, errorID)
+		close(stop)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		closed = true
+		close(result)
+
+		select {
+		case errs <- err:
+		default:
+			// A poll error is still buffered, replace it since the final
+			// error must be delivered.
+			<-errs
+			errs <- err
+		}
+
+		close(errs)
+	}()
+
+	return result, errs
+}
+
+// diffHealth returns the changes between the previous and the current
+// status of the pools, in the order of the current pools followed by the
+// removed pools.
+func diffHealth(prev map[string]*PoolStatus, cur map[string]*PoolStatus, now time.Time) []*HealthChange {
+	var result []*HealthChange
+
+	for _, name := range sortedStatusKeys(cur) {
+		c := cur[name]
+
+		p, ok := prev[name]
+		if !ok {
+			result = append(result, &HealthChange{
+				Type: HealthPoolAdded, Time: now, Pool: name, NewState: c.State,
+			})
+
+			continue
+		}
+
+		if p.State != c.State {
+			result = append(result, &HealthChange{
+				Type: HealthPoolStateChanged, Time: now, Pool: name, OldState: p.State, NewState: c.State,
+			})
+		}
+
+		result = append(result, diffVdevHealth(name, p.Vdevs, c.Vdevs, now)...)
+	}
+
+	for _, name := range sortedStatusKeys(prev) {
+		if _, ok := cur[name]; !ok {
+			result = append(result, &HealthChange{
+				Type: HealthPoolRemoved, Time: now, Pool: name, OldState: prev[name].State,
+			})
+		}
+	}
+
+	return result
+}
+
+// diffVdevHealth returns the state and error counter changes of the vdevs
+// present in both the previous and the current vdev trees. The root vdev
+// is skipped since its state is the state of the pool.
+func diffVdevHealth(pool string, prev *VdevTree, cur *VdevTree, now time.Time) []*HealthChange {
+	if prev == nil || cur == nil {
+		return nil
+	}
+
+	prevVdevs := make(map[string]*Vdev)
+	for _, v := range prev.All() {
+		prevVdevs[v.Name] = v
+	}
+
+	var result []*HealthChange
+
+	for _, v := range cur.All() {
+		p, ok := prevVdevs[v.Name]
+		if !ok || v == cur.Root {
+			continue
+		}
+
+		if p.State != v.State {
+			result = append(result, &HealthChange{
+				Type: HealthVdevStateChanged, Time: now, Pool: pool, Vdev: v.Name,
+				OldState: p.State, NewState: v.State,
+				OldErrors: vdevErrors(p), NewErrors: vdevErrors(v),
+			})
+		}
+
+		if v.ReadErrors > p.ReadErrors || v.WriteErrors > p.WriteErrors || v.ChecksumErrors > p.ChecksumErrors {
+			result = append(result, &HealthChange{
+				Type: HealthVdevErrorsIncreased, Time: now, Pool: pool, Vdev: v.Name,
+				OldState: p.State, NewState: v.State,
+				OldErrors: vdevErrors(p), NewErrors: vdevErrors(v),
+			})
+		}
+	}
+
+	return result
+}
+
+func vdevErrors(v *Vdev) VdevErrors {
+	return VdevErrors{Read: v.ReadErrors, Write: v.WriteErrors, Checksum: v.ChecksumErrors}
+}
+
+func sortedStatusKeys(m map[string]*PoolStatus) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,225 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testHealthStatus(pool string, poolState string, sdaState string, sdaCksum uint64) string {
+	return fmt.Sprintf(`  pool: %[1]s
+ state: %[2]s
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	%[1]s        %[2]s     0     0     0
+	  mirror-0  %[2]s     0     0     0
+	    sda     %[3]s       0     0 %[4]d
+	    sdb     ONLINE       0     0     0
+
+errors: No known data errors
+`, pool, poolState, sdaState, sdaCksum)
+}
+
+func TestHealthMonitorPoll(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	stub := stubExecutor{
+		testZpoolListCmd:       {Stdout: testPoolInfo("tank", nil)},
+		"zpool status -p tank": {Stdout: testHealthStatus("tank", "ONLINE", "ONLINE", 0)},
+	}
+	system := newStubSystem(stub)
+
+	monitor := NewHealthMonitor(system, time.Minute)
+	monitor.now = func() time.Time { return now }
+
+	var handled []*HealthChange
+
+	monitor.OnChange(func(c *HealthChange) {
+		handled = append(handled, c)
+	})
+
+	tests := []struct {
+		name   string
+		update func()
+		want   []*HealthChange
+	}{
+		{
+			name:   "Baseline",
+			update: func() {},
+		},
+		{
+			name:   "No changes",
+			update: func() {},
+		},
+		{
+			name: "Checksum errors",
+			update: func() {
+				stub["zpool status -p tank"].Stdout = testHealthStatus("tank", "ONLINE", "ONLINE", 3)
+			},
+			want: []*HealthChange{
+				{
+					Type:      HealthVdevErrorsIncreased,
+					Time:      now,
+					Pool:      "tank",
+					Vdev:      "sda",
+					OldState:  "ONLINE",
+					NewState:  "ONLINE",
+					NewErrors: VdevErrors{Checksum: 3},
+				},
+			},
+		},
+		{
+			name: "Degraded",
+			update: func() {
+				stub["zpool status -p tank"].Stdout = testHealthStatus("tank", "DEGRADED", "FAULTED", 3)
+			},
+			want: []*HealthChange{
+				{Type: HealthPoolStateChanged, Time: now, Pool: "tank", OldState: "ONLINE", NewState: "DEGRADED"},
+				{
+					Type:     HealthVdevStateChanged,
+					Time:     now,
+					Pool:     "tank",
+					Vdev:     "mirror-0",
+					OldState: "ONLINE",
+					NewState: "DEGRADED",
+				},
+				{
+					Type:      HealthVdevStateChanged,
+					Time:      now,
+					Pool:      "tank",
+					Vdev:      "sda",
+					OldState:  "ONLINE",
+					NewState:  "FAULTED",
+					OldErrors: VdevErrors{Checksum: 3},
+					NewErrors: VdevErrors{Checksum: 3},
+				},
+			},
+		},
+		{
+			name: "Errors cleared",
+			update: func() {
+				stub["zpool status -p tank"].Stdout = testHealthStatus("tank", "DEGRADED", "FAULTED", 0)
+			},
+		},
+		{
+			name: "Pool added and removed",
+			update: func() {
+				stub[testZpoolListCmd].Stdout = testPoolInfo("backup", nil)
+				stub["zpool status -p backup"] = &RecordedCommand{
+					Stdout: testHealthStatus("backup", "ONLINE", "ONLINE", 0),
+				}
+			},
+			want: []*HealthChange{
+				{Type: HealthPoolAdded, Time: now, Pool: "backup", NewState: "ONLINE"},
+				{Type: HealthPoolRemoved, Time: now, Pool: "tank", OldState: "DEGRADED"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc.update()
+		handled = nil
+
+		got, gotErr := monitor.Poll()
+		if gotErr != nil {
+			t.Errorf(
+				"HealthMonitor.Poll()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+				tc.name, gotErr)
+			return
+		}
+
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf(
+				"HealthMonitor.Poll()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+				tc.name, diff)
+		}
+
+		if diff := cmp.Diff(tc.want, handled); diff != "" {
+			t.Errorf(
+				"HealthMonitor.OnChange()\nTest Case: %q\nFailure: want and handled differ\nReason:\n%s",
+				tc.name, diff)
+		}
+	}
+}
+
+func TestHealthMonitorChanges(t *testing.T) {
+	t.Parallel()
+
+	stub := stubExecutor{
+		testZpoolListCmd:       {Stdout: testPoolInfo("tank", nil)},
+		"zpool status -p tank": {Stdout: testHealthStatus("tank", "ONLINE", "ONLINE", 0)},
+	}
+	system := newStubSystem(stub)
+
+	monitor := NewHealthMonitor(system, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changes, errs := monitor.Changes(ctx)
+	cancel()
+
+	for range changes {
+	}
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf(
+			"HealthMonitor.Changes()\nTest Case: %q\nFailure: unexpected error\nReason: got %v, want %v",
+			"Cancelled", err, context.Canceled)
+	}
+
+	// The handlers of Changes are removed, polling must neither send over
+	// the closed channel nor invoke them.
+	stub["zpool status -p tank"].Stdout = testHealthStatus("tank", "DEGRADED", "FAULTED", 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := monitor.Poll(); err != nil {
+			t.Fatalf(
+				"HealthMonitor.Poll()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+				"Poll after cancel", err)
+		}
+	}
+
+	if n := len(monitor.handlers) + len(monitor.errorHandlers); n != 0 {
+		t.Errorf(
+			"HealthMonitor.Changes()\nTest Case: %q\nFailure: %d handlers still registered",
+			"Poll after cancel", n)
+	}
+}
+
+func TestHealthMonitorRunPollErrors(t *testing.T) {
+	t.Parallel()
+
+	stub := stubExecutor{
+		testZpoolListCmd: {ExitCode: 1, Stderr: "transient failure"},
+	}
+	system := newStubSystem(stub)
+
+	monitor := NewHealthMonitor(system, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	failures := 0
+
+	monitor.OnError(func(err error) {
+		failures++
+		if failures == 3 {
+			cancel()
+		}
+	})
+
+	if err := monitor.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf(
+			"HealthMonitor.Run()\nTest Case: %q\nFailure: unexpected error\nReason: got %v, want %v",
+			"Poll errors", err, context.Canceled)
+	}
+
+	if failures != 3 {
+		t.Errorf(
+			"HealthMonitor.Run()\nTest Case: %q\nFailure: got %d failed polls, want 3",
+			"Poll errors", failures)
+	}
+}
@@ -1,26 +1,24 @@
 package zfs
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
-func newFakeSystem(pools fakeZpools) *System {
-	return NewSystem(&SystemConfig{
-		alternateCmd: newFakeZpoolCmd(pools),
-	})
-}
+const (
+	testZpoolListCmd = "zpool list -H -p -o name,guid,size,allocated,free,fragmentation,health,altroot"
+)
 
-func getFakeZpoolCmd(system *System) *fakeZpoolCmd {
-	result, ok := system.cmd.zpool.(*fakeZpoolCmd)
-	if !ok {
-		panic("supplied system argument includes a cmd.zpool field that is not a *fakeZpoolCmd")
-	}
+type propMap map[string]string
 
-	return result
+func newStubSystem(stub stubExecutor) *System {
+	return NewSystem(&SystemConfig{Executor: stub, DisableJSON: true})
 }
 
 func updateExpectedPoolsWithSystem(pools PoolList, system *System) {
@@ -37,30 +35,44 @@ func poolListsEqual(actual PoolList, expected PoolList) error {
 	return nil
 }
 
-func fakeZpoolWithPropertyOverride(override propMap) *fakeZpool {
-	result := &fakeZpool{
-		props: propMap{
-			"guid":          "1234567890123459",
-			"size":          "23000000",
-			"allocated":     "19000000",
-			"free":          "4000000",
-			"fragmentation": "4",
-			"health":        "ONLINE",
-			"altroot":       "/some-alt-root",
-		},
+func testPoolProps(override propMap) propMap {
+	result := propMap{
+		"guid":          "1234567890123459",
+		"size":          "23000000",
+		"allocated":     "19000000",
+		"free":          "4000000",
+		"fragmentation": "4",
+		"health":        "ONLINE",
+		"altroot":       "/some-alt-root",
 	}
 
 	for k, v := range override {
-		result.props[k] = v
+		result[k] = v
 	}
+
 	return result
 }
 
+// testPoolInfo returns the line of 'zpool list' output for the pool with
+// the default properties except for the overrides.
+func testPoolInfo(poolName string, override propMap) string {
+	props := testPoolProps(override)
+
+	cols := []string{poolName}
+	for _, col := range listPoolsOutputCols[1:] {
+		cols = append(cols, props[col])
+	}
+
+	return strings.Join(cols, "\t") + "\n"
+}
+
 func newPoolForTesting(t *testing.T, poolName string, override propMap) *Pool {
-	system := newFakeSystem(
-		fakeZpools{
-			poolName: fakeZpoolWithPropertyOverride(override),
-		})
+	stub := stubExecutor{testZpoolListCmd: {Stdout: testPoolInfo(poolName, override)}}
+	for prop, val := range testPoolProps(override) {
+		stub["zpool get -H -o value "+prop+" "+poolName] = &RecordedCommand{Stdout: val + "\n"}
+	}
+
+	system := newStubSystem(stub)
 
 	pools, gotErr := system.ListPools()
 	if nil != gotErr {
@@ -77,3 +89,57 @@ func newPoolForTesting(t *testing.T, poolName string, override propMap) *Pool {
 
 	return pools[0]
 }
+
+// commandRecorder records the command lines and the standard input of the
+// commands run, serving the responses from the stub and an empty output
+// for the commands missing from it.
+type commandRecorder struct {
+	stub     stubExecutor
+	commands []string
+	stdin    []string
+}
+
+func (r *commandRecorder) Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error) {
+	cmdLine := name + " " + strings.Join(args, " ")
+	r.commands = append(r.commands, cmdLine)
+
+	if stdin != nil {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", err
+		}
+
+		r.stdin = append(r.stdin, string(b))
+	}
+
+	if c, ok := r.stub[cmdLine]; ok {
+		return c.Stdout, c.err()
+	}
+
+	return "", nil
+}
+
+func (r *commandRecorder) Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	out, err := r.Run(ctx, nil, name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(strings.NewReader(out)), nil
+}
+
+// newRecordedFileSystem returns the file system with the specified name
+// within the pool "tank" (the root file system if the name is empty), whose
+// commands are recorded.
+func newRecordedFileSystem(name string, stub stubExecutor) (*FileSystem, *commandRecorder) {
+	pool, rec := newRecordedPool(stub)
+
+	return &FileSystem{Name: name, IsRoot: name == "", Pool: pool}, rec
+}
+
+// newRecordedPool returns the pool "tank" whose commands are recorded.
+func newRecordedPool(stub stubExecutor) (*Pool, *commandRecorder) {
+	rec := &commandRecorder{stub: stub}
+
+	return &Pool{Name: "tank", System: NewSystem(&SystemConfig{Executor: rec, DisableJSON: true})}, rec
+}
@@ -0,0 +1,225 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	historyTimestampLayout = "2006-01-02.15:04:05"
+)
+
+var (
+	historyRecordRegex   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}\.\d{2}:\d{2}:\d{2}) (.*)$`)
+	historyLongRegex     = regexp.MustCompile(`(?s)^(.*?)\s*\[(?:user (\d+) (?:\((.*?)\) )?)?(?:on ([^:\]]*)(?::([^\]]*))?)?\]$`)
+	historyInternalRegex = regexp.MustCompile(`^\[(?:internal (\S+) )?txg:(\d+)\]\s*(.*)$`)
+	historyDatasetRegex  = regexp.MustCompile(`^\S+ (\S+) \(\d+\)`)
+)
+
+// HistoryEntry represents an entry of the command history of a pool as
+// reported by 'zpool history'.
+type HistoryEntry struct {
+	// Time the entry was logged.
+	Time time.Time
+	// Command line of the user command, e.g. "zfs destroy tank/home@daily",
+	// or the description of the internal event, e.g.
+	// "destroy tank/home@daily (259)".
+	Command string
+	// True if the entry is an internal event logged by zfs, false if the
+	// entry is a user command.
+	Internal bool
+	// Transaction group of the internal event, 0 for user commands.
+	TXG uint64
+	// Dataset (or pool) operated on, empty if unknown.
+	Dataset string
+	// User ID of the user that ran the command, -1 if unknown.
+	UID int
+	// Name of the user that ran the command, empty if unknown.
+	User string
+	// Hostname of the system that ran the command, empty if unknown.
+	Hostname string
+	// Zone of the system that ran the command (e.g. "linux"), empty if
+	// unknown.
+	Zone string
+}
+
+// HistoryEntryList represents a list of HistoryEntry objects.
+type HistoryEntryList []*HistoryEntry
+
+// HistoryOptions represents the options for filtering the command history
+// of a pool.
+type HistoryOptions struct {
+	// Include the internal events logged by zfs (e.g. every snapshot
+	// destroyed by a recursive destroy) along with the user commands.
+	Internal bool
+	// Only include the entries logged at or after the time, if non-zero.
+	Since time.Time
+	// Only include the entries logged before the time, if non-zero.
+	Until time.Time
+	// Only include the entries operating on the dataset or its descendants
+	// (including snapshots and bookmarks), if non-empty.
+	Dataset string
+}
+
+// String returns the string representation of the history entry.
+func (e *HistoryEntry) String() string {
+	return fmt.Sprintf("{HistoryEntry Time: %q, Command: %q}", e.Time.Format(time.RFC3339), e.Command)
+}
+
+// History returns the command history of the pool in the order logged,
+// filtered by the options.
+func (p *Pool) History(opts *HistoryOptions) (HistoryEntryList, error) {
+	if opts == nil {
+		opts = &HistoryOptions{}
+	}
+
+	out, err := p.cmd().zpool.history(p.Name, opts.Internal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history of pool %q, reason: %w", p, err)
+	}
+
+	entries, err := parseHistory(p.Name, out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse history of pool %q, reason: %w", p, err)
+	}
+
+	var result HistoryEntryList
+
+	for _, e := range entries {
+		if opts.matches(e) {
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
+
+// matches returns true if the history entry is not filtered out by the
+// options.
+func (o *HistoryOptions) matches(e *HistoryEntry) bool {
+	if e.Internal && !o.Internal {
+		return false
+	}
+
+	if !o.Since.IsZero() && e.Time.Before(o.Since) {
+		return false
+	}
+
+	if !o.Until.IsZero() && !e.Time.Before(o.Until) {
+		return false
+	}
+
+	return o.Dataset == "" || isDatasetOrDescendant(e.Dataset, o.Dataset)
+}
+
+// isDatasetOrDescendant returns true if name is the dataset, or one of its
+// descendant file systems, snapshots or bookmarks.
+func isDatasetOrDescendant(name string, dataset string) bool {
+	if name == dataset {
+		return true
+	}
+
+	for _, sep := range []string{"/", "@", "#"} {
+		if strings.HasPrefix(name, dataset+sep) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseHistory parses the output of 'zpool history -l' (optionally along
+// with -i) for the pool, where the lines that do not begin with a
+// timestamp (e.g. the arguments of the logged ioctls) belong to the
+// previous entry.
+func parseHistory(pool string, out string) (HistoryEntryList, error) {
+	var records []string
+
+	for _, line := range splitOnNewLine(out) {
+		switch {
+		case strings.HasPrefix(line, "History for "):
+		case historyRecordRegex.MatchString(line):
+			records = append(records, line)
+		case strings.TrimSpace(line) == "":
+		case len(records) == 0:
+			return nil, fmt.Errorf("parsing \"history\", unexpected line %q", line)
+		default:
+			records[len(records)-1] += "\n" + line
+		}
+	}
+
+	result := make(HistoryEntryList, 0, len(records))
+
+	for _, r := range records {
+		entry, err := parseHistoryEntry(pool, r)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+func parseHistoryEntry(pool string, record string) (*HistoryEntry, error) {
+	m := historyRecordRegex.FindStringSubmatch(strings.SplitN(record, "\n", 2)[0])
+
+	ts, err := time.ParseInLocation(historyTimestampLayout, m[1], time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("parsing \"history timestamp\", invalid timestamp %q, reason: %w", m[1], err)
+	}
+
+	entry := &HistoryEntry{Time: ts, UID: -1}
+	body := strings.TrimPrefix(record, m[1]+" ")
+
+	if l := historyLongRegex.FindStringSubmatch(body); l != nil {
+		body = l[1]
+
+		if l[2] != "" {
+			entry.UID, err = strconv.Atoi(l[2])
+			if err != nil {
+				return nil, fmt.Errorf("parsing \"history user\", invalid uid %q, reason: %w", l[2], err)
+			}
+		}
+
+		entry.User, entry.Hostname, entry.Zone = l[3], l[4], l[5]
+	}
+
+	// Only the first line describes the entry, the remaining lines are the
+	// arguments of the logged ioctl.
+	body = strings.TrimSpace(strings.SplitN(body, "\n", 2)[0])
+
+	switch {
+	case historyInternalRegex.MatchString(body):
+		i := historyInternalRegex.FindStringSubmatch(body)
+
+		entry.Internal = true
+		entry.TXG, err = parseUint64(i[2], "history txg")
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Command = strings.TrimSpace(i[1] + " " + i[3])
+		if d := historyDatasetRegex.FindStringSubmatch(entry.Command); d != nil {
+			entry.Dataset = d[1]
+		}
+	case strings.HasPrefix(body, "ioctl "):
+		entry.Internal = true
+		entry.Command = body
+	default:
+		entry.Command = body
+
+		for _, arg := range strings.Fields(body) {
+			if isDatasetOrDescendant(arg, pool) {
+				entry.Dataset = arg
+				break
+			}
+		}
+	}
+
+	return entry, nil
+}
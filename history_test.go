@@ -0,0 +1,208 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testZpoolHistory = `History for 'tank':
+2026-10-11.08:08:01 [txg:5] create pool version 5000; software version zfs-2.2.6-1; uts host 6.8.0-45-generic #45-Ubuntu SMP x86_64 [on host]
+2026-10-11.08:08:01 zpool create tank mirror sda sdb [user 0 (root) on host:linux]
+2026-10-11.08:10:12 [txg:40] set tank/home (54) compression=15 [user 0 (root) on host]
+2026-10-11.08:10:12 zfs set compression=lz4 tank/home [user 0 (root) on host:linux]
+2026-10-12.00:00:03 ioctl snapshot
+    input:
+        snaps:
+            tank/home@daily
+        props:
+ [user 0 (root) on host]
+2026-10-12.00:00:03 zfs snapshot tank/home@daily [user 1000 (alice) on host:linux]
+2026-10-13.17:42:19 [txg:1201] destroy tank/home@daily (259)  [on host]
+2026-10-13.17:42:19 zfs destroy tank/home@daily [user 1001 (bob) on other:linux]
+2026-10-14.09:00:00 [internal snapshot txg:1302] dataset = 301 [user 0 on host]
+2026-10-14.09:30:00 zfs create tank/homework [user 0 (root) on host:linux]
+`
+)
+
+func testHistoryTime(t *testing.T, ts string) time.Time {
+	t.Helper()
+
+	result, err := time.ParseInLocation(historyTimestampLayout, ts, time.Local)
+	if err != nil {
+		t.Fatalf("time.ParseInLocation()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", ts, err)
+	}
+
+	return result
+}
+
+func TestParseHistory(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseHistory("tank", testZpoolHistory)
+	if err != nil {
+		t.Fatalf("parseHistory()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "History", err)
+	}
+
+	want := HistoryEntryList{
+		{
+			Time:     testHistoryTime(t, "2026-10-11.08:08:01"),
+			Command:  "create pool version 5000; software version zfs-2.2.6-1; uts host 6.8.0-45-generic #45-Ubuntu SMP x86_64",
+			Internal: true,
+			TXG:      5,
+			UID:      -1,
+			Hostname: "host",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-11.08:08:01"),
+			Command:  "zpool create tank mirror sda sdb",
+			Dataset:  "tank",
+			User:     "root",
+			Hostname: "host",
+			Zone:     "linux",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-11.08:10:12"),
+			Command:  "set tank/home (54) compression=15",
+			Internal: true,
+			TXG:      40,
+			Dataset:  "tank/home",
+			User:     "root",
+			Hostname: "host",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-11.08:10:12"),
+			Command:  "zfs set compression=lz4 tank/home",
+			Dataset:  "tank/home",
+			User:     "root",
+			Hostname: "host",
+			Zone:     "linux",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-12.00:00:03"),
+			Command:  "ioctl snapshot",
+			Internal: true,
+			User:     "root",
+			Hostname: "host",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-12.00:00:03"),
+			Command:  "zfs snapshot tank/home@daily",
+			Dataset:  "tank/home@daily",
+			UID:      1000,
+			User:     "alice",
+			Hostname: "host",
+			Zone:     "linux",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-13.17:42:19"),
+			Command:  "destroy tank/home@daily (259)",
+			Internal: true,
+			TXG:      1201,
+			Dataset:  "tank/home@daily",
+			UID:      -1,
+			Hostname: "host",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-13.17:42:19"),
+			Command:  "zfs destroy tank/home@daily",
+			Dataset:  "tank/home@daily",
+			UID:      1001,
+			User:     "bob",
+			Hostname: "other",
+			Zone:     "linux",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-14.09:00:00"),
+			Command:  "snapshot dataset = 301",
+			Internal: true,
+			TXG:      1302,
+			Hostname: "host",
+		},
+		{
+			Time:     testHistoryTime(t, "2026-10-14.09:30:00"),
+			Command:  "zfs create tank/homework",
+			Dataset:  "tank/homework",
+			User:     "root",
+			Hostname: "host",
+			Zone:     "linux",
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseHistory()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "History", diff)
+	}
+}
+
+var poolHistoryTests = []struct {
+	name string
+	opts *HistoryOptions
+	want []string
+}{
+	{
+		name: "User commands",
+		want: []string{
+			"zpool create tank mirror sda sdb",
+			"zfs set compression=lz4 tank/home",
+			"zfs snapshot tank/home@daily",
+			"zfs destroy tank/home@daily",
+			"zfs create tank/homework",
+		},
+	},
+	{
+		name: "Time range",
+		opts: &HistoryOptions{
+			Since: time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local),
+			Until: time.Date(2026, 10, 14, 9, 30, 0, 0, time.Local),
+		},
+		want: []string{
+			"zfs snapshot tank/home@daily",
+			"zfs destroy tank/home@daily",
+		},
+	},
+	{
+		name: "Dataset including internal events",
+		opts: &HistoryOptions{Internal: true, Dataset: "tank/home"},
+		want: []string{
+			"set tank/home (54) compression=15",
+			"zfs set compression=lz4 tank/home",
+			"zfs snapshot tank/home@daily",
+			"destroy tank/home@daily (259)",
+			"zfs destroy tank/home@daily",
+		},
+	},
+}
+
+func TestPoolHistory(t *testing.T) {
+	for _, test := range poolHistoryTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			system := NewSystem(&SystemConfig{
+				Executor: stubExecutor{
+					"zpool history -l tank":  {Stdout: testZpoolHistory},
+					"zpool history -il tank": {Stdout: testZpoolHistory},
+				},
+				DisableJSON: true,
+			})
+			pool := &Pool{Name: "tank", System: system}
+
+			entries, err := pool.History(tc.opts)
+			if err != nil {
+				t.Fatalf("History()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", tc.name, err)
+			}
+
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Command)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("History()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", tc.name, diff)
+			}
+		})
+	}
+}
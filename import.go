@@ -0,0 +1,151 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ImportablePool represents a pool that is available to be imported.
+type ImportablePool struct {
+	// Name of the pool.
+	Name string
+	// GUID of the pool.
+	GUID uint64
+	// State of the pool, e.g. "ONLINE" or "DEGRADED".
+	State string
+	// Detailed status of the pool, empty if there are no issues.
+	Status string
+	// Action recommended to import the pool.
+	Action string
+	// Vdev layout of the pool.
+	Vdevs *VdevTree
+}
+
+// ImportablePoolList represents a list of ImportablePool objects.
+type ImportablePoolList []*ImportablePool
+
+// ImportPoolOptions represents the options for importing a pool.
+type ImportPoolOptions struct {
+	// Directories to search for devices or files backing the vdevs, the
+	// system default is used if empty.
+	SearchDirs []string
+	// Alternate root for the pool.
+	AltRoot string
+	// Import the pool in read-only mode.
+	ReadOnly bool
+	// Import the pool even if it appears to be in use by another system.
+	Force bool
+	// Do not mount the file systems within the pool.
+	NoMount bool
+	// Rewind the pool to its checkpoint, discarding all the changes made
+	// after the checkpoint was created along with the checkpoint.
+	RewindToCheckpoint bool
+	// New name for the pool, the pool retains its name if empty.
+	NewName string
+	// Properties to set on the pool.
+	Props map[string]string
+}
+
+// String returns the string representation of the importable pool.
+func (i *ImportablePool) String() string {
+	return fmt.Sprintf("{ImportablePool Name: %q, GUID: %d, State: %q}", i.Name, i.GUID, i.State)
+}
+
+// ImportablePools returns the list of pools available to be imported,
+// searching for the devices within the specified directories (or the system
+// default if none are specified). An empty list is returned if there are no
+// pools available to import.
+func (s *System) ImportablePools(searchDirs ...string) (ImportablePoolList, error) {
+	out, err := s.cmd.zpool.importList(searchDirs)
+	if err != nil {
+		// zpool exits with an error when there are no pools to import.
+		var ce *CommandError
+		if errors.As(err, &ce) && strings.Contains(ce.Stderr, "no pools available to import") {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list importable pools, reason: %w", err)
+	}
+
+	return parseImportablePools(out)
+}
+
+// ImportPool imports the pool with the specified name or GUID, and returns
+// the imported pool.
+func (s *System) ImportPool(nameOrGUID string, opts *ImportPoolOptions) (*Pool, error) {
+	if opts == nil {
+		opts = &ImportPoolOptions{}
+	}
+
+	_, err := s.cmd.zpool.importPool(nameOrGUID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import pool %q, reason: %w", nameOrGUID, err)
+	}
+
+	name := nameOrGUID
+	if opts.NewName != "" {
+		name = opts.NewName
+	}
+
+	return findPool(s, name)
+}
+
+// Export exports the pool, forcefully unmounting all the file systems even
+// if they are busy when force is true.
+func (p *Pool) Export(force bool) error {
+	_, err := p.cmd().zpool.export(p.Name, force)
+	if err != nil {
+		return fmt.Errorf("failed to export pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// findPool returns the pool with the specified name or GUID.
+func findPool(system *System, nameOrGUID string) (*Pool, error) {
+	pools, err := system.ListPools()
+	if err != nil {
+		return nil, err
+	}
+
+	guid, guidErr := strconv.ParseUint(nameOrGUID, 10, 64)
+
+	for _, p := range pools {
+		if p.Name == nameOrGUID || (guidErr == nil && p.GUID == guid) {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pool %q not found", nameOrGUID)
+}
+
+func parseImportablePools(out string) (ImportablePoolList, error) {
+	var result ImportablePoolList
+
+	for _, block := range parseStatusBlocks(out) {
+		name := block.value("pool")
+
+		guid, err := parseUint64(block.value("id"), "importable pool id")
+		if err != nil {
+			return nil, err
+		}
+
+		vdevs, err := parseVdevTree(block["config"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config of importable pool %q, reason: %w", name, err)
+		}
+
+		result = append(result, &ImportablePool{
+			Name:   name,
+			GUID:   guid,
+			State:  block.value("state"),
+			Status: block.value("status"),
+			Action: block.value("action"),
+			Vdevs:  vdevs,
+		})
+	}
+
+	return result, nil
+}
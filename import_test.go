@@ -0,0 +1,151 @@
+package zfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testZpoolImportOutput = `   pool: tank
+     id: 15370473916395285325
+  state: ONLINE
+ status: Some supported features are not enabled on the pool.
+	(Note that they may be intentionally disabled if the
+	'compatibility' property is set.)
+ action: The pool can be imported using its name or numeric identifier, though
+	some features will not be available without an explicit 'zpool upgrade'.
+ config:
+
+	tank                 ONLINE
+	  mirror-0           ONLINE
+	    /tmp/vdevs/d1    ONLINE
+	    /tmp/vdevs/d2    ONLINE
+	logs
+	  /tmp/vdevs/log1    ONLINE
+	cache
+	  /tmp/vdevs/cache1
+	spares
+	  /tmp/vdevs/spare1
+
+   pool: backup
+     id: 4185036219138294719
+  state: DEGRADED
+ status: One or more devices are missing from the system.
+ action: The pool can be imported despite missing or damaged devices.  The
+	fault tolerance of the pool may be compromised if imported.
+   see: https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-2Q
+ config:
+
+	backup               DEGRADED
+	  raidz1-0           DEGRADED
+	    /tmp/vdevs/r1    ONLINE
+	    /tmp/vdevs/r2    ONLINE
+	    /tmp/vdevs/r3    UNAVAIL  cannot open
+`
+
+func TestParseImportablePools(t *testing.T) {
+	t.Parallel()
+
+	want := ImportablePoolList{
+		&ImportablePool{
+			Name:  "tank",
+			GUID:  15370473916395285325,
+			State: "ONLINE",
+			Status: "Some supported features are not enabled on the pool. " +
+				"(Note that they may be intentionally disabled if the " +
+				"'compatibility' property is set.)",
+			Action: "The pool can be imported using its name or numeric identifier, though " +
+				"some features will not be available without an explicit 'zpool upgrade'.",
+			Vdevs: &VdevTree{
+				Root: &Vdev{
+					Name:  "tank",
+					State: "ONLINE",
+					Children: VdevList{
+						&Vdev{
+							Name:  "mirror-0",
+							State: "ONLINE",
+							Children: VdevList{
+								&Vdev{Name: "/tmp/vdevs/d1", State: "ONLINE"},
+								&Vdev{Name: "/tmp/vdevs/d2", State: "ONLINE"},
+							},
+						},
+					},
+				},
+				Logs:   VdevList{&Vdev{Name: "/tmp/vdevs/log1", State: "ONLINE"}},
+				Cache:  VdevList{&Vdev{Name: "/tmp/vdevs/cache1"}},
+				Spares: VdevList{&Vdev{Name: "/tmp/vdevs/spare1"}},
+			},
+		},
+		&ImportablePool{
+			Name:   "backup",
+			GUID:   4185036219138294719,
+			State:  "DEGRADED",
+			Status: "One or more devices are missing from the system.",
+			Action: "The pool can be imported despite missing or damaged devices.  The " +
+				"fault tolerance of the pool may be compromised if imported.",
+			Vdevs: &VdevTree{
+				Root: &Vdev{
+					Name:  "backup",
+					State: "DEGRADED",
+					Children: VdevList{
+						&Vdev{
+							Name:  "raidz1-0",
+							State: "DEGRADED",
+							Children: VdevList{
+								&Vdev{Name: "/tmp/vdevs/r1", State: "ONLINE"},
+								&Vdev{Name: "/tmp/vdevs/r2", State: "ONLINE"},
+								&Vdev{Name: "/tmp/vdevs/r3", State: "UNAVAIL", Message: "cannot open"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got, gotErr := parseImportablePools(testZpoolImportOutput)
+	if nil != gotErr {
+		t.Errorf(
+			"parseImportablePools()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"parseImportablePools()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestImportablePoolsNone(t *testing.T) {
+	t.Parallel()
+
+	system := newStubSystem(stubExecutor{
+		"zpool import":                      {ExitCode: 1, Stderr: "no pools available to import\n"},
+		"zpool import -d /dev/disk/by-id":   {ExitCode: 1, Stderr: "no pools available to import\n"},
+		"zpool import -d /dev/disk/by-uuid": {ExitCode: 1, Stderr: "Permission denied the ZFS utilities must be run as root.\n"},
+	})
+
+	tests := []struct {
+		name       string
+		searchDirs []string
+		wantErr    bool
+	}{
+		{name: "No pools"},
+		{name: "No pools in search dir", searchDirs: []string{"/dev/disk/by-id"}},
+		{name: "Not root", searchDirs: []string{"/dev/disk/by-uuid"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := system.ImportablePools(test.searchDirs...)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("ImportablePools()\nTest Case: %q\nFailure: gotErr != wantErr\nReason: %v", test.name, err)
+			continue
+		}
+
+		if len(got) != 0 {
+			t.Errorf("ImportablePools()\nTest Case: %q\nFailure: got %v, want none", test.name, got)
+		}
+	}
+}
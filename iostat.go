@@ -0,0 +1,494 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	iostatBaseCols = 7
+)
+
+// IOStatsOptions represents the additional statistics to collect in each
+// I/O statistics sample.
+type IOStatsOptions struct {
+	// Collect the average latencies.
+	Latency bool
+	// Collect the queue depths.
+	Queue bool
+	// Collect the latency histograms, cannot be combined with Latency or
+	// Queue. Only the histograms are populated when enabled.
+	Histogram bool
+}
+
+// IOWaitStats represents a value (average latency in nanoseconds, or number
+// of requests within a histogram bucket) for each of the I/O wait
+// categories.
+type IOWaitStats struct {
+	// Total I/O time (queueing and disk I/O) for reads.
+	TotalRead uint64
+	// Total I/O time (queueing and disk I/O) for writes.
+	TotalWrite uint64
+	// Disk I/O time for reads.
+	DiskRead uint64
+	// Disk I/O time for writes.
+	DiskWrite uint64
+	// Time spent in the synchronous read queue.
+	SyncQueueRead uint64
+	// Time spent in the synchronous write queue.
+	SyncQueueWrite uint64
+	// Time spent in the asynchronous read queue.
+	AsyncQueueRead uint64
+	// Time spent in the asynchronous write queue.
+	AsyncQueueWrite uint64
+	// Time spent in the scrub queue.
+	Scrub uint64
+	// Time spent in the trim queue.
+	Trim uint64
+	// Time spent in the rebuild queue, only reported by newer versions.
+	Rebuild uint64
+}
+
+// IOQueueDepth represents the number of pending and active requests in an
+// I/O queue.
+type IOQueueDepth struct {
+	Pending uint64
+	Active  uint64
+}
+
+// IOQueueStats represents the depth of each of the I/O queues.
+type IOQueueStats struct {
+	SyncRead   IOQueueDepth
+	SyncWrite  IOQueueDepth
+	AsyncRead  IOQueueDepth
+	AsyncWrite IOQueueDepth
+	Scrub      IOQueueDepth
+	Trim       IOQueueDepth
+	// Only reported by newer versions.
+	Rebuild IOQueueDepth
+}
+
+// IOHistogramBucket represents a single bucket of the latency histograms.
+type IOHistogramBucket struct {
+	// Upper bound of the latency for the bucket.
+	Latency time.Duration
+	// Number of requests within the bucket for each wait category.
+	Counts IOWaitStats
+}
+
+// VdevIOStats represents the I/O statistics of the pool or a single vdev
+// within the pool over a sampling interval.
+type VdevIOStats struct {
+	// Name of the pool or the vdev.
+	Name string
+	// Number of bytes allocated.
+	Allocated uint64
+	// Number of bytes free.
+	Free uint64
+	// Number of read operations per second.
+	ReadOps uint64
+	// Number of write operations per second.
+	WriteOps uint64
+	// Number of bytes read per second.
+	ReadBytes uint64
+	// Number of bytes written per second.
+	WriteBytes uint64
+	// Average latencies in nanoseconds, only set if requested.
+	Latency *IOWaitStats
+	// Queue depths, only set if requested.
+	Queue *IOQueueStats
+	// Latency histograms, only set if requested.
+	Histogram []*IOHistogramBucket
+}
+
+// IOStatsSample represents a single sample of the I/O statistics of a pool.
+type IOStatsSample struct {
+	// Time at which the sample was collected.
+	Time time.Time
+	// Statistics of the pool (first) followed by each of its vdevs.
+	Vdevs []*VdevIOStats
+	// Error encountered while collecting the sample, no more samples follow
+	// a sample with an error.
+	Err error
+}
+
+// String returns the string representation of the vdev I/O statistics.
+func (v *VdevIOStats) String() string {
+	return fmt.Sprintf(
+		"{VdevIOStats Name: %q, ReadOps: %d, WriteOps: %d, ReadBytes: %d, WriteBytes: %d}",
+		v.Name,
+		v.ReadOps,
+		v.WriteOps,
+		v.ReadBytes,
+		v.WriteBytes,
+	)
+}
+
+// IOStats collects count samples of the I/O statistics of the pool and its
+// vdevs, each spanning the specified interval, and streams them over the
+// returned channel. A count of zero collects samples until the context is
+// cancelled. All the samples are reported by a single 'zpool iostat'
+// command. The channel is closed once all the samples have been delivered,
+// an error is encountered or the context is cancelled.
+func (p *Pool) IOStats(
+	ctx context.Context, interval time.Duration, count int, opts *IOStatsOptions,
+) (<-chan *IOStatsSample, error) {
+	if opts == nil {
+		opts = &IOStatsOptions{}
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid I/O stats interval %v, must be positive", interval)
+	}
+
+	if count < 0 {
+		return nil, fmt.Errorf("invalid I/O stats count %d, must not be negative", count)
+	}
+
+	if opts.Histogram && (opts.Latency || opts.Queue) {
+		return nil, fmt.Errorf("I/O stats histogram cannot be combined with latency or queue stats")
+	}
+
+	// The command is stopped once the samples are no longer received.
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	stream, err := p.cmd().zpool.iostat(
+		streamCtx, p.Name, interval, count, opts.Latency, opts.Queue, opts.Histogram)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to get I/O stats of pool %q, reason: %w", p, err)
+	}
+
+	result := make(chan *IOStatsSample)
+
+	go func() {
+		defer close(result)
+
+		send := func(sample *IOStatsSample) bool {
+			select {
+			case result <- sample:
+				return sample.Err == nil
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		stopped := false
+		err := splitIOStatsSamples(stream, p.Name, interval/2, func(t time.Time, out string) bool {
+			sample := &IOStatsSample{Time: t}
+			if opts.Histogram {
+				sample.Vdevs, sample.Err = parseIOStatsHistograms(out)
+			} else {
+				sample.Vdevs, sample.Err = parseIOStats(out, opts.Latency, opts.Queue)
+			}
+
+			stopped = !send(sample)
+
+			return !stopped
+		})
+
+		cancel()
+
+		if closeErr := stream.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil && !stopped && ctx.Err() == nil {
+			send(&IOStatsSample{Time: time.Now(), Err: fmt.Errorf("failed to get I/O stats of pool %q, reason: %w", p, err)})
+		}
+	}()
+
+	return result, nil
+}
+
+// splitIOStatsSamples splits the output of 'zpool iostat' with an interval
+// into the samples, each of which starts with the statistics of the pool,
+// and emits each sample along with the time it was reported. Since the
+// samples are not delimited otherwise, a sample is complete once the next
+// sample starts or the output ends, or no more output follows within the
+// quiet period as zpool reports each sample at once.
+func splitIOStatsSamples(r io.Reader, pool string, quiet time.Duration, emit func(t time.Time, out string) bool) error {
+	batches := make(chan []string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+
+	defer close(done)
+
+	// Read the lines in batches of the output available at once.
+	go func() {
+		defer close(batches)
+
+		br := bufio.NewReader(r)
+
+		var batch []string
+
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				batch = append(batch, strings.TrimSuffix(line, "\n"))
+			}
+
+			if err != nil || br.Buffered() == 0 {
+				select {
+				case batches <- batch:
+				case <-done:
+					return
+				}
+
+				batch = nil
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+
+				return
+			}
+		}
+	}()
+
+	var current strings.Builder
+
+	var start time.Time
+
+	flush := func() bool {
+		if current.Len() == 0 {
+			return true
+		}
+
+		out := current.String()
+		current.Reset()
+
+		return emit(start, out)
+	}
+
+	var timer *time.Timer
+
+	var quietC <-chan time.Time
+
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case batch, ok := <-batches:
+			if !ok {
+				flush()
+
+				select {
+				case err := <-readErr:
+					return err
+				default:
+					return nil
+				}
+			}
+
+			for _, line := range batch {
+				if strings.SplitN(line, "\t", 2)[0] == pool {
+					if !flush() {
+						return nil
+					}
+
+					start = time.Now()
+				}
+
+				current.WriteString(line + "\n")
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+
+			timer = time.NewTimer(quiet)
+			quietC = timer.C
+		case <-quietC:
+			quietC = nil
+
+			if !flush() {
+				return nil
+			}
+		}
+	}
+}
+
+// parseIOStats parses the output of 'zpool iostat -H -p -v' with the
+// optional latency and queue columns.
+func parseIOStats(out string, latency bool, queue bool) ([]*VdevIOStats, error) {
+	var result []*VdevIOStats
+
+	for _, line := range splitOnNewLine(out) {
+		cols := strings.Split(line, "\t")
+		if len(cols) == 1 || allDashes(cols[1:]) {
+			// Skip the headers of the allocation classes.
+			continue
+		}
+
+		latencyCols, queueCols, err := iostatExtraCols(len(cols)-iostatBaseCols, latency, queue)
+		if err != nil {
+			return nil, fmt.Errorf("%w, line: %q", err, line)
+		}
+
+		vals, err := parseUint64Cols(cols[1:], "iostat")
+		if err != nil {
+			return nil, err
+		}
+
+		v := &VdevIOStats{
+			Name:       cols[0],
+			Allocated:  vals[0],
+			Free:       vals[1],
+			ReadOps:    vals[2],
+			WriteOps:   vals[3],
+			ReadBytes:  vals[4],
+			WriteBytes: vals[5],
+		}
+
+		rest := vals[iostatBaseCols-1:]
+		if latency {
+			v.Latency = newIOWaitStats(rest[:latencyCols])
+			rest = rest[latencyCols:]
+		}
+
+		if queue {
+			v.Queue = newIOQueueStats(rest[:queueCols])
+		}
+
+		result = append(result, v)
+	}
+
+	return result, nil
+}
+
+// parseIOStatsHistograms parses the output of 'zpool iostat -H -p -v -w',
+// which includes a line with the name of the pool or vdev followed by one
+// line per histogram bucket.
+func parseIOStatsHistograms(out string) ([]*VdevIOStats, error) {
+	var result []*VdevIOStats
+
+	var current *VdevIOStats
+
+	for _, line := range splitOnNewLine(out) {
+		cols := strings.Split(line, "\t")
+		if len(cols) == 1 {
+			if cols[0] != "" {
+				current = &VdevIOStats{Name: cols[0]}
+				result = append(result, current)
+			}
+
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("found iostat histogram bucket without a vdev, line: %q", line)
+		}
+
+		if n := len(cols) - 1; n != 10 && n != 11 {
+			return nil, fmt.Errorf("expected 10 or 11 columns per line in iostat histogram, but found %d, line: %q", n, line)
+		}
+
+		vals, err := parseUint64Cols(cols, "iostat histogram")
+		if err != nil {
+			return nil, err
+		}
+
+		current.Histogram = append(current.Histogram, &IOHistogramBucket{
+			Latency: time.Duration(vals[0]),
+			Counts:  *newIOWaitStats(vals[1:]),
+		})
+	}
+
+	return result, nil
+}
+
+// iostatExtraCols returns the number of latency and queue columns given the
+// number of columns in addition to the base columns, since newer versions
+// additionally report the rebuild latency and queue.
+func iostatExtraCols(extra int, latency bool, queue bool) (int, int, error) {
+	switch {
+	case latency && queue && extra == 22:
+		return 10, 12, nil
+	case latency && queue && extra == 25:
+		return 11, 14, nil
+	case latency && !queue && (extra == 10 || extra == 11):
+		return extra, 0, nil
+	case !latency && queue && (extra == 12 || extra == 14):
+		return 0, extra, nil
+	case !latency && !queue && extra == 0:
+		return 0, 0, nil
+	}
+
+	return 0, 0, fmt.Errorf("unexpected %d columns per line in iostat (latency: %t, queue: %t)", extra+iostatBaseCols, latency, queue)
+}
+
+func newIOWaitStats(vals []uint64) *IOWaitStats {
+	result := &IOWaitStats{
+		TotalRead:       vals[0],
+		TotalWrite:      vals[1],
+		DiskRead:        vals[2],
+		DiskWrite:       vals[3],
+		SyncQueueRead:   vals[4],
+		SyncQueueWrite:  vals[5],
+		AsyncQueueRead:  vals[6],
+		AsyncQueueWrite: vals[7],
+		Scrub:           vals[8],
+		Trim:            vals[9],
+	}
+	if len(vals) > 10 {
+		result.Rebuild = vals[10]
+	}
+
+	return result
+}
+
+func newIOQueueStats(vals []uint64) *IOQueueStats {
+	depth := func(i int) IOQueueDepth {
+		return IOQueueDepth{Pending: vals[2*i], Active: vals[2*i+1]}
+	}
+
+	result := &IOQueueStats{
+		SyncRead:   depth(0),
+		SyncWrite:  depth(1),
+		AsyncRead:  depth(2),
+		AsyncWrite: depth(3),
+		Scrub:      depth(4),
+		Trim:       depth(5),
+	}
+	if len(vals) > 12 {
+		result.Rebuild = depth(6)
+	}
+
+	return result
+}
+
+func parseUint64Cols(cols []string, desc string) ([]uint64, error) {
+	result := make([]uint64, len(cols))
+
+	for i, c := range cols {
+		val, err := parseUint64OrNone(c, fmt.Sprintf("%s column %d", desc, i))
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = val
+	}
+
+	return result, nil
+}
+
+func allDashes(cols []string) bool {
+	for _, c := range cols {
+		if c != "-" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,302 @@
+package zfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseIOStatsTests = []struct {
+	name    string
+	out     string
+	latency bool
+	queue   bool
+	want    []*VdevIOStats
+}{
+	{
+		name: "Basic",
+		out: "tank\t1000\t9000\t10\t20\t4096\t8192\n" +
+			"mirror-0\t1000\t9000\t10\t20\t4096\t8192\n" +
+			"sda\t-\t-\t5\t10\t2048\t4096\n" +
+			"logs\t-\t-\t-\t-\t-\t-\n" +
+			"sdc\t0\t100\t0\t3\t0\t512\n",
+		want: []*VdevIOStats{
+			{Name: "tank", Allocated: 1000, Free: 9000, ReadOps: 10, WriteOps: 20, ReadBytes: 4096, WriteBytes: 8192},
+			{Name: "mirror-0", Allocated: 1000, Free: 9000, ReadOps: 10, WriteOps: 20, ReadBytes: 4096, WriteBytes: 8192},
+			{Name: "sda", ReadOps: 5, WriteOps: 10, ReadBytes: 2048, WriteBytes: 4096},
+			{Name: "sdc", Free: 100, WriteOps: 3, WriteBytes: 512},
+		},
+	},
+	{
+		name:    "Latency and queue with rebuild",
+		latency: true,
+		queue:   true,
+		out: "tank\t1\t2\t3\t4\t5\t6" +
+			"\t10\t11\t12\t13\t14\t15\t16\t17\t18\t19\t20" +
+			"\t30\t31\t32\t33\t34\t35\t36\t37\t38\t39\t40\t41\t42\t43\n",
+		want: []*VdevIOStats{
+			{
+				Name:       "tank",
+				Allocated:  1,
+				Free:       2,
+				ReadOps:    3,
+				WriteOps:   4,
+				ReadBytes:  5,
+				WriteBytes: 6,
+				Latency: &IOWaitStats{
+					TotalRead:       10,
+					TotalWrite:      11,
+					DiskRead:        12,
+					DiskWrite:       13,
+					SyncQueueRead:   14,
+					SyncQueueWrite:  15,
+					AsyncQueueRead:  16,
+					AsyncQueueWrite: 17,
+					Scrub:           18,
+					Trim:            19,
+					Rebuild:         20,
+				},
+				Queue: &IOQueueStats{
+					SyncRead:   IOQueueDepth{Pending: 30, Active: 31},
+					SyncWrite:  IOQueueDepth{Pending: 32, Active: 33},
+					AsyncRead:  IOQueueDepth{Pending: 34, Active: 35},
+					AsyncWrite: IOQueueDepth{Pending: 36, Active: 37},
+					Scrub:      IOQueueDepth{Pending: 38, Active: 39},
+					Trim:       IOQueueDepth{Pending: 40, Active: 41},
+					Rebuild:    IOQueueDepth{Pending: 42, Active: 43},
+				},
+			},
+		},
+	},
+	{
+		name:    "Latency without rebuild",
+		latency: true,
+		out:     "sda\t1\t2\t3\t4\t5\t6\t10\t11\t12\t13\t-\t-\t16\t17\t18\t19\n",
+		want: []*VdevIOStats{
+			{
+				Name:       "sda",
+				Allocated:  1,
+				Free:       2,
+				ReadOps:    3,
+				WriteOps:   4,
+				ReadBytes:  5,
+				WriteBytes: 6,
+				Latency: &IOWaitStats{
+					TotalRead:       10,
+					TotalWrite:      11,
+					DiskRead:        12,
+					DiskWrite:       13,
+					AsyncQueueRead:  16,
+					AsyncQueueWrite: 17,
+					Scrub:           18,
+					Trim:            19,
+				},
+			},
+		},
+	},
+}
+
+func TestParseIOStats(t *testing.T) {
+	for _, test := range parseIOStatsTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := parseIOStats(tc.out, tc.latency, tc.queue)
+			if nil != gotErr {
+				t.Errorf(
+					"parseIOStats()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"parseIOStats()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestParseIOStatsColumnMismatch(t *testing.T) {
+	t.Parallel()
+
+	_, gotErr := parseIOStats("tank\t1\t2\t3\t4\t5\t6\t7\n", true, false)
+	if gotErr == nil {
+		t.Errorf(
+			"parseIOStats()\nTest Case: %q\nFailure: gotErr == nil\nReason: expected column count mismatch",
+			t.Name())
+	}
+}
+
+func TestParseIOStatsHistograms(t *testing.T) {
+	t.Parallel()
+
+	out := "tank\n" +
+		"1\t0\t0\t0\t0\t0\t0\t0\t0\t0\t0\n" +
+		"2\t1\t2\t3\t4\t5\t6\t7\t8\t9\t10\n" +
+		"sda\n" +
+		"1\t5\t0\t0\t0\t0\t0\t0\t0\t0\t0\n"
+	want := []*VdevIOStats{
+		{
+			Name: "tank",
+			Histogram: []*IOHistogramBucket{
+				{Latency: time.Nanosecond},
+				{
+					Latency: 2 * time.Nanosecond,
+					Counts: IOWaitStats{
+						TotalRead:       1,
+						TotalWrite:      2,
+						DiskRead:        3,
+						DiskWrite:       4,
+						SyncQueueRead:   5,
+						SyncQueueWrite:  6,
+						AsyncQueueRead:  7,
+						AsyncQueueWrite: 8,
+						Scrub:           9,
+						Trim:            10,
+					},
+				},
+			},
+		},
+		{
+			Name: "sda",
+			Histogram: []*IOHistogramBucket{
+				{Latency: time.Nanosecond, Counts: IOWaitStats{TotalRead: 5}},
+			},
+		},
+	}
+
+	got, gotErr := parseIOStatsHistograms(out)
+	if nil != gotErr {
+		t.Errorf(
+			"parseIOStatsHistograms()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"parseIOStatsHistograms()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+var ioStatsStreamTests = []struct {
+	name      string
+	count     int
+	opts      *IOStatsOptions
+	cmd       string
+	out       string
+	wantNames [][]string
+}{
+	{
+		name:  "Three samples",
+		count: 3,
+		cmd:   "zpool iostat -H -p -v -y tank 1 3",
+		out: "tank\t1000\t9000\t10\t20\t4096\t8192\n" +
+			"sda\t-\t-\t5\t10\t2048\t4096\n" +
+			"tank\t1000\t9000\t11\t21\t4096\t8192\n" +
+			"sda\t-\t-\t6\t11\t2048\t4096\n" +
+			"tank\t1000\t9000\t12\t22\t4096\t8192\n" +
+			"sda\t-\t-\t7\t12\t2048\t4096\n",
+		wantNames: [][]string{{"tank", "sda"}, {"tank", "sda"}, {"tank", "sda"}},
+	},
+	{
+		name:  "Histograms with blank lines",
+		count: 2,
+		opts:  &IOStatsOptions{Histogram: true},
+		cmd:   "zpool iostat -H -p -v -y -w tank 1 2",
+		out: "tank\n" +
+			"1024\t1\t2\t3\t4\t5\t6\t7\t8\t9\t10\n" +
+			"\n" +
+			"sda\n" +
+			"1024\t1\t2\t3\t4\t5\t6\t7\t8\t9\t10\n" +
+			"\n" +
+			"tank\n" +
+			"1024\t1\t2\t3\t4\t5\t6\t7\t8\t9\t10\n" +
+			"\n" +
+			"sda\n" +
+			"1024\t1\t2\t3\t4\t5\t6\t7\t8\t9\t10\n",
+		wantNames: [][]string{{"tank", "sda"}, {"tank", "sda"}},
+	},
+}
+
+func TestIOStatsStream(t *testing.T) {
+	for _, test := range ioStatsStreamTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool, rec := newRecordedPool(stubExecutor{tc.cmd: {Stdout: tc.out}})
+
+			samples, gotErr := pool.IOStats(context.Background(), time.Second, tc.count, tc.opts)
+			if gotErr != nil {
+				t.Errorf(
+					"Pool.IOStats()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			var gotNames [][]string
+
+			for sample := range samples {
+				if sample.Err != nil {
+					t.Errorf(
+						"Pool.IOStats()\nTest Case: %q\nFailure: sample.Err != nil\nReason: %v",
+						tc.name, sample.Err)
+					continue
+				}
+
+				var names []string
+				for _, v := range sample.Vdevs {
+					names = append(names, v.Name)
+				}
+
+				gotNames = append(gotNames, names)
+			}
+
+			if diff := cmp.Diff(tc.wantNames, gotNames); diff != "" {
+				t.Errorf(
+					"Pool.IOStats()\nTest Case: %q\nFailure: want and got samples differ\nReason:\n%s",
+					tc.name, diff)
+			}
+
+			if diff := cmp.Diff([]string{tc.cmd}, rec.commands); diff != "" {
+				t.Errorf(
+					"Pool.IOStats()\nTest Case: %q\nFailure: want and got commands differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestIOStatsStreamParseError(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := newRecordedPool(stubExecutor{
+		"zpool iostat -H -p -v -y tank 1": {
+			Stdout: "tank\t1\t2\t3\t4\t5\t6\n" +
+				"tank\tinvalid\t2\t3\t4\t5\t6\n" +
+				"tank\t1\t2\t3\t4\t5\t6\n",
+		},
+	})
+
+	samples, gotErr := pool.IOStats(context.Background(), time.Second, 0, nil)
+	if gotErr != nil {
+		t.Fatalf("Pool.IOStats()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", t.Name(), gotErr)
+	}
+
+	var gotErrs []bool
+	for sample := range samples {
+		gotErrs = append(gotErrs, sample.Err != nil)
+	}
+
+	// No more samples follow the sample with the error.
+	if diff := cmp.Diff([]bool{false, true}, gotErrs); diff != "" {
+		t.Errorf("Pool.IOStats()\nTest Case: %q\nFailure: want and got samples differ\nReason:\n%s", t.Name(), diff)
+	}
+}
@@ -0,0 +1,602 @@
+package zfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jsonDatasetsKey = "datasets"
+	jsonPoolsKey    = "pools"
+)
+
+var (
+	// Names of the vdev classes in the JSON output of 'zpool status'.
+	jsonVdevClasses = []string{
+		vdevClassSpecial,
+		vdevClassDedup,
+		vdevClassLogs,
+		"l2cache",
+		vdevClassSpares,
+	}
+)
+
+// jsonOutput detects whether the zfs and zpool commands support the JSON
+// output added in OpenZFS 2.3 using the detected versions, the detection
+// runs once on first use.
+type jsonOutput struct {
+	versions  *versionDetector
+	disabled  bool
+	once      sync.Once
+	supported bool
+}
+
+// enabled returns true if the commands should use the JSON output.
+func (j *jsonOutput) enabled() bool {
+	if j.disabled {
+		return false
+	}
+
+	j.once.Do(func() {
+		version, err := j.versions.get()
+		j.supported = err == nil && version.Supports(CapabilityJSON)
+	})
+
+	return j.supported
+}
+
+// jsonObject represents a JSON object that retains the order of its keys,
+// since zfs reports the datasets and vdevs in a meaningful order.
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes the JSON object.
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected a JSON object, but found %v", tok)
+	}
+
+	o.values = make(map[string]json.RawMessage)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, _ := tok.(string)
+
+		var val json.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+
+		o.keys = append(o.keys, key)
+		o.values[key] = val
+	}
+
+	_, err = dec.Token()
+
+	return err
+}
+
+// object decodes the value of the key as a JSON object, and returns an
+// empty object if the key is missing.
+func (o *jsonObject) object(key string) (*jsonObject, error) {
+	result := &jsonObject{}
+
+	raw, ok := o.values[key]
+	if !ok {
+		return result, nil
+	}
+
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, fmt.Errorf("failed to parse %q, reason: %w", key, err)
+	}
+
+	return result, nil
+}
+
+// str returns the value of the key as a string, numbers are returned as
+// reported and an empty string is returned if the key is missing or the
+// value is not a string or a number.
+func (o *jsonObject) str(key string) string {
+	var v jsonValue
+	if raw, ok := o.values[key]; ok && json.Unmarshal(raw, &v) == nil {
+		return string(v)
+	}
+
+	return ""
+}
+
+// jsonValue represents a property value, which is reported as a string
+// unless '--json-int' is specified.
+type jsonValue string
+
+// UnmarshalJSON decodes a string or a number.
+func (v *jsonValue) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*v = jsonValue(str)
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return fmt.Errorf("expected a string or a number, but found %s", data)
+	}
+
+	*v = jsonValue(num)
+
+	return nil
+}
+
+// jsonProperty represents a property along with its source.
+type jsonProperty struct {
+	Value  jsonValue `json:"value"`
+	Source struct {
+		Type string `json:"type"`
+		Data string `json:"data"`
+	} `json:"source"`
+}
+
+// source returns the source of the property as reported by the "source"
+// column of 'zfs get' and 'zpool get'.
+func (p *jsonProperty) source() string {
+	switch p.Source.Type {
+	case "", "NONE":
+		return "-"
+	case "INHERITED":
+		return "inherited from " + p.Source.Data
+	}
+
+	return strings.ToLower(p.Source.Type)
+}
+
+// jsonEntities returns the datasets or pools (as specified by key) in the
+// JSON output of 'zfs list', 'zfs get', 'zpool list' or 'zpool get'.
+func jsonEntities(out string, key string) (*jsonObject, error) {
+	var root jsonObject
+	if err := json.Unmarshal([]byte(out), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON output, reason: %w", err)
+	}
+
+	return root.object(key)
+}
+
+// jsonColumn returns the value of the column for the dataset or pool, the
+// column is looked up within the properties followed by the other fields.
+func jsonColumn(entity *jsonObject, col string) (string, error) {
+	props, err := entity.object("properties")
+	if err != nil {
+		return "", err
+	}
+
+	if raw, ok := props.values[col]; ok {
+		var prop jsonProperty
+		if err := json.Unmarshal(raw, &prop); err != nil {
+			return "", fmt.Errorf("failed to parse property %q, reason: %w", col, err)
+		}
+
+		return string(prop.Value), nil
+	}
+
+	if _, ok := entity.values[col]; ok {
+		return entity.str(col), nil
+	}
+
+	return "", fmt.Errorf("column %q not found in JSON output", col)
+}
+
+// jsonListRows returns the rows of the JSON output of 'zfs list' or 'zpool
+// list', each with the values of the specified columns of a dataset or
+// pool.
+func jsonListRows(out string, key string, cols []string) ([][]string, error) {
+	entities, err := jsonEntities(out, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var result [][]string
+
+	for _, name := range entities.keys {
+		var entity jsonObject
+		if err := json.Unmarshal(entities.values[name], &entity); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON output of %q, reason: %w", name, err)
+		}
+
+		row := make([]string, len(cols))
+
+		for i, c := range cols {
+			val, err := jsonColumn(&entity, c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse JSON output of %q, reason: %w", name, err)
+			}
+
+			row[i] = val
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// jsonGetRows returns the rows of the JSON output of 'zfs get' or 'zpool
+// get', one per property, each with the values of the specified columns
+// (i.e. "name", "property", "value" or "source"). All the properties are
+// included in the output order if props is just "all".
+func jsonGetRows(out string, key string, props []string, cols []string) ([][]string, error) {
+	entities, err := jsonEntities(out, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var result [][]string
+
+	for _, name := range entities.keys {
+		var entity jsonObject
+		if err := json.Unmarshal(entities.values[name], &entity); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON output of %q, reason: %w", name, err)
+		}
+
+		entityProps, err := entity.object("properties")
+		if err != nil {
+			return nil, err
+		}
+
+		entityPropNames := props
+		if len(props) == 1 && props[0] == "all" {
+			entityPropNames = entityProps.keys
+		}
+
+		for _, p := range entityPropNames {
+			raw, ok := entityProps.values[p]
+			if !ok {
+				return nil, fmt.Errorf("property %q of %q not found in JSON output", p, name)
+			}
+
+			var prop jsonProperty
+			if err := json.Unmarshal(raw, &prop); err != nil {
+				return nil, fmt.Errorf("failed to parse property %q of %q, reason: %w", p, name, err)
+			}
+
+			row := make([]string, len(cols))
+
+			for i, c := range cols {
+				switch c {
+				case "name":
+					row[i] = name
+				case "property":
+					row[i] = p
+				case "value":
+					row[i] = string(prop.Value)
+				case "source":
+					row[i] = prop.source()
+				default:
+					return nil, fmt.Errorf("unsupported column %q for JSON output", c)
+				}
+			}
+
+			result = append(result, row)
+		}
+	}
+
+	return result, nil
+}
+
+// isJSON returns true if the output is JSON rather than text.
+func isJSON(out string) bool {
+	return strings.HasPrefix(strings.TrimSpace(out), "{")
+}
+
+// parsePoolStatusJSON parses the output of 'zpool status -j -p'.
+func parsePoolStatusJSON(pool string, out string) (*PoolStatus, error) {
+	pools, err := jsonEntities(out, jsonPoolsKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse status of pool %q, reason: %w", pool, err)
+	}
+
+	if len(pools.keys) != 1 {
+		return nil, fmt.Errorf("expected status of exactly one pool, but found %d, output: %q", len(pools.keys), out)
+	}
+
+	var p jsonObject
+	if err := json.Unmarshal(pools.values[pools.keys[0]], &p); err != nil {
+		return nil, fmt.Errorf("failed to parse status of pool %q, reason: %w", pool, err)
+	}
+
+	name := p.str("name")
+	if name != pool {
+		return nil, fmt.Errorf("expected status of pool %q, but found %q", pool, name)
+	}
+
+	vdevs, err := parseVdevTreeJSON(&p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config of pool %q, reason: %w", name, err)
+	}
+
+	var scanStats jsonScanStats
+	if raw, ok := p.values["scan_stats"]; ok {
+		if err := json.Unmarshal(raw, &scanStats); err != nil {
+			return nil, fmt.Errorf("failed to parse scan status of pool %q, reason: %w", name, err)
+		}
+	}
+
+	scan, err := scanStats.scanStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scan status of pool %q, reason: %w", name, err)
+	}
+
+	checkpoint, err := p.object("checkpoint_stats")
+	if err != nil {
+		return nil, err
+	}
+
+	dataErrors := "No known data errors"
+	if count := p.str("error_count"); count != "" && count != "0" {
+		dataErrors = fmt.Sprintf("%s data errors, use '-v' for a list", count)
+	}
+
+	return &PoolStatus{
+		Name:       name,
+		State:      p.str("state"),
+		Status:     p.str("status"),
+		Action:     p.str("action"),
+		Scan:       scanStats.summary(scan),
+		Checkpoint: jsonCheckpointStatus(checkpoint),
+		Errors:     dataErrors,
+		Vdevs:      vdevs,
+		scan:       scan,
+	}, nil
+}
+
+// parseVdevTreeJSON parses the vdevs in the JSON output of 'zpool status'.
+func parseVdevTreeJSON(pool *jsonObject) (*VdevTree, error) {
+	roots, err := pool.object("vdevs")
+	if err != nil {
+		return nil, err
+	}
+
+	rootList, err := parseVdevListJSON(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rootList) != 1 {
+		return nil, fmt.Errorf("expected exactly one root vdev, but found %d", len(rootList))
+	}
+
+	result := &VdevTree{Root: rootList[0]}
+
+	for _, class := range jsonVdevClasses {
+		obj, err := pool.object(class)
+		if err != nil {
+			return nil, err
+		}
+
+		list, err := parseVdevListJSON(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		switch class {
+		case vdevClassSpecial:
+			result.Special = list
+		case vdevClassDedup:
+			result.Dedup = list
+		case vdevClassLogs:
+			result.Logs = list
+		case vdevClassSpares:
+			result.Spares = list
+		default:
+			result.Cache = list
+		}
+	}
+
+	return result, nil
+}
+
+func parseVdevListJSON(obj *jsonObject) (VdevList, error) {
+	var result VdevList
+
+	for _, name := range obj.keys {
+		var v jsonObject
+		if err := json.Unmarshal(obj.values[name], &v); err != nil {
+			return nil, fmt.Errorf("failed to parse vdev %q, reason: %w", name, err)
+		}
+
+		vdev, err := parseVdevJSON(name, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, vdev)
+	}
+
+	return result, nil
+}
+
+func parseVdevJSON(name string, v *jsonObject) (*Vdev, error) {
+	result := &Vdev{Name: name, State: v.str("state")}
+
+	counters := []struct {
+		key string
+		val *uint64
+	}{
+		{"read_errors", &result.ReadErrors},
+		{"write_errors", &result.WriteErrors},
+		{"checksum_errors", &result.ChecksumErrors},
+	}
+
+	for _, c := range counters {
+		str := v.str(c.key)
+		if str == "" {
+			continue
+		}
+
+		val, err := parseUint64(str, "vdev "+c.key)
+		if err != nil {
+			return nil, err
+		}
+
+		*c.val = val
+	}
+
+	children, err := v.object("vdevs")
+	if err != nil {
+		return nil, err
+	}
+
+	result.Children, err = parseVdevListJSON(children)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// jsonScanStats represents the "scan_stats" in the JSON output of 'zpool
+// status -j -p', where the times are reported in seconds since the epoch.
+type jsonScanStats struct {
+	Function   string    `json:"function"`
+	State      string    `json:"state"`
+	StartTime  jsonValue `json:"start_time"`
+	EndTime    jsonValue `json:"end_time"`
+	Errors     jsonValue `json:"errors"`
+	ScrubPause jsonValue `json:"scrub_pause"`
+	// Number of bytes repaired, which zfs reports as "processed" (the same
+	// counter reported as repaired by the text output).
+	Repaired jsonValue `json:"processed"`
+}
+
+// scanStatus returns the status of the scan.
+func (s *jsonScanStats) scanStatus() (*ScanStatus, error) {
+	function := strings.ToLower(s.Function)
+	if function == "" || function == "none" {
+		return &ScanStatus{}, nil
+	}
+
+	switch s.State {
+	case "SCANNING":
+		start, err := parseJSONTimestamp(s.StartTime, "scan status start time")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanStatus{Function: function, InProgress: true, Paused: s.paused(), StartTime: start}, nil
+	case "CANCELED":
+		end, err := parseJSONTimestamp(s.EndTime, "scan status end time")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanStatus{Function: function, Canceled: true, EndTime: end}, nil
+	case "FINISHED":
+		end, err := parseJSONTimestamp(s.EndTime, "scan status end time")
+		if err != nil {
+			return nil, err
+		}
+
+		errs, err := parseUint64OrNone(string(s.Errors), "scan status errors")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanStatus{Function: function, Errors: errs, EndTime: end}, nil
+	}
+
+	return nil, fmt.Errorf("parsing \"scan status\", unrecognized scan state: %q", s.State)
+}
+
+// paused returns true if the scrub is paused, the pause time is zero
+// otherwise.
+func (s *jsonScanStats) paused() bool {
+	return s.ScrubPause != "" && s.ScrubPause != "0" && s.ScrubPause != "-"
+}
+
+// summary returns the scan status in the format of the text output of
+// 'zpool status', e.g. "scrub in progress since Sun Oct 18 00:24:01 2026".
+func (s *jsonScanStats) summary(scan *ScanStatus) string {
+	switch {
+	case scan.Function == "":
+		return "none requested"
+	case scan.Paused:
+		pause, _ := parseJSONTimestamp(s.ScrubPause, "scan status pause time")
+
+		return fmt.Sprintf(
+			"scrub paused since %s scrub started on %s",
+			pause.Format(scanTimestampLayout), scan.StartTime.Format(scanTimestampLayout))
+	case scan.InProgress:
+		return fmt.Sprintf("%s in progress since %s", scan.Function, scan.StartTime.Format(scanTimestampLayout))
+	case scan.Canceled:
+		return fmt.Sprintf("%s canceled on %s", scan.Function, scan.EndTime.Format(scanTimestampLayout))
+	}
+
+	start, _ := parseJSONTimestamp(s.StartTime, "scan status start time")
+	duration := scanDuration(start, scan.EndTime)
+
+	action := "scrub repaired"
+	if scan.Function == "resilver" {
+		action = "resilvered"
+	}
+
+	return fmt.Sprintf(
+		"%s %sB in %s with %d errors on %s",
+		action, s.Repaired, duration, scan.Errors, scan.EndTime.Format(scanTimestampLayout))
+}
+
+// jsonCheckpointStatus returns the checkpoint status in the format of the
+// text output of 'zpool status', i.e. the format parsed by
+// parseCheckpointStatus.
+func jsonCheckpointStatus(checkpoint *jsonObject) string {
+	switch checkpoint.str("state") {
+	case "EXISTS":
+		created := checkpoint.str("start_time")
+		if secs, err := strconv.ParseInt(created, 10, 64); err == nil {
+			created = time.Unix(secs, 0).Format(scanTimestampLayout)
+		}
+
+		return fmt.Sprintf("created %s, consumes %s", created, checkpoint.str("space"))
+	case "DISCARDING":
+		return "discarding"
+	}
+
+	return ""
+}
+
+// scanDuration returns the duration of the scan in the "hh:mm:ss" format.
+func scanDuration(start time.Time, end time.Time) string {
+	if start.IsZero() || end.Before(start) {
+		return "00:00:00"
+	}
+
+	secs := int64(end.Sub(start).Seconds())
+
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+// parseJSONTimestamp parses the time in seconds since the epoch reported by
+// the JSON output with the '-p' option.
+func parseJSONTimestamp(v jsonValue, desc string) (time.Time, error) {
+	secs, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing %q, unable to convert %q to timestamp: %w", desc, v, err)
+	}
+
+	return time.Unix(secs, 0), nil
+}
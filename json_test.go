@@ -0,0 +1,541 @@
+package zfs
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const (
+	testJSONZfsList = `{
+  "output_version": {"command": "zfs list", "vers_major": 0, "vers_minor": 1},
+  "datasets": {
+    "tank": {
+      "name": "tank",
+      "type": "FILESYSTEM",
+      "pool": "tank",
+      "createtxg": "1",
+      "properties": {
+        "guid": {"value": "6321581830434387226", "source": {"type": "NONE", "data": "-"}},
+        "creation": {"value": "1697357281", "source": {"type": "NONE", "data": "-"}}
+      }
+    },
+    "tank/home": {
+      "name": "tank/home",
+      "type": "FILESYSTEM",
+      "pool": "tank",
+      "createtxg": "12",
+      "properties": {
+        "guid": {"value": "2406237466416624013", "source": {"type": "NONE", "data": "-"}},
+        "creation": {"value": "1697357390", "source": {"type": "NONE", "data": "-"}}
+      }
+    },
+    "tank/apps": {
+      "name": "tank/apps",
+      "type": "FILESYSTEM",
+      "pool": "tank",
+      "createtxg": "20",
+      "properties": {
+        "guid": {"value": 15632940478624371130, "source": {"type": "NONE", "data": "-"}},
+        "creation": {"value": 1697357412, "source": {"type": "NONE", "data": "-"}}
+      }
+    }
+  }
+}`
+	testJSONZpoolList = `{
+  "output_version": {"command": "zpool list", "vers_major": 0, "vers_minor": 1},
+  "pools": {
+    "tank": {
+      "name": "tank",
+      "type": "POOL",
+      "state": "ONLINE",
+      "pool_guid": "11806393938123466592",
+      "txg": "2731812",
+      "spa_version": "5000",
+      "zpl_version": "5",
+      "properties": {
+        "guid": {"value": "11806393938123466592", "source": {"type": "NONE", "data": "-"}},
+        "size": {"value": "1992864825344", "source": {"type": "NONE", "data": "-"}},
+        "allocated": {"value": "502813016064", "source": {"type": "NONE", "data": "-"}},
+        "free": {"value": "1490051809280", "source": {"type": "NONE", "data": "-"}},
+        "fragmentation": {"value": "3", "source": {"type": "NONE", "data": "-"}},
+        "health": {"value": "ONLINE", "source": {"type": "NONE", "data": "-"}},
+        "altroot": {"value": "-", "source": {"type": "DEFAULT", "data": "-"}}
+      }
+    }
+  }
+}`
+	testJSONZfsGet = `{
+  "output_version": {"command": "zfs get", "vers_major": 0, "vers_minor": 1},
+  "datasets": {
+    "tank/home": {
+      "name": "tank/home",
+      "type": "FILESYSTEM",
+      "pool": "tank",
+      "createtxg": "12",
+      "properties": {
+        "compression": {"value": "lz4", "source": {"type": "INHERITED", "data": "tank"}},
+        "atime": {"value": "off", "source": {"type": "LOCAL", "data": "-"}},
+        "quota": {"value": "none", "source": {"type": "DEFAULT", "data": "-"}}
+      }
+    }
+  }
+}`
+	testJSONZpoolStatus = `{
+  "output_version": {"command": "zpool status", "vers_major": 0, "vers_minor": 1},
+  "pools": {
+    "tank": {
+      "name": "tank",
+      "state": "DEGRADED",
+      "pool_guid": "11806393938123466592",
+      "txg": "2731812",
+      "spa_version": "5000",
+      "zpl_version": "5",
+      "status": "One or more devices are faulted in response to persistent errors.",
+      "action": "Replace the faulted device, or use 'zpool clear' to mark the device repaired.",
+      "msgid": "ZFS-8000-K4",
+      "moreinfo": "https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-K4",
+      "scan_stats": {
+        "function": "SCRUB",
+        "state": "FINISHED",
+        "start_time": "1791678241",
+        "end_time": "1791684184",
+        "to_examine": "502813016064",
+        "examined": "502813016064",
+        "skipped": "0",
+        "processed": "0",
+        "errors": "0",
+        "bytes_per_scan": "0",
+        "pass_start": "1791678241",
+        "scrub_pause": "0",
+        "scrub_spent_paused": "0",
+        "issued_bytes_per_scan": "0",
+        "issued": "502813016064"
+      },
+      "vdevs": {
+        "tank": {
+          "name": "tank",
+          "vdev_type": "root",
+          "guid": "11806393938123466592",
+          "class": "normal",
+          "state": "DEGRADED",
+          "alloc_space": "502813016064",
+          "total_space": "1992864825344",
+          "def_space": "1992864825344",
+          "read_errors": "0",
+          "write_errors": "0",
+          "checksum_errors": "0",
+          "vdevs": {
+            "mirror-0": {
+              "name": "mirror-0",
+              "vdev_type": "mirror",
+              "guid": "1539867305342498218",
+              "class": "normal",
+              "state": "DEGRADED",
+              "read_errors": "0",
+              "write_errors": "0",
+              "checksum_errors": "0",
+              "vdevs": {
+                "sdb": {
+                  "name": "sdb",
+                  "vdev_type": "disk",
+                  "guid": "7425389233816712981",
+                  "path": "/dev/sdb1",
+                  "class": "normal",
+                  "state": "FAULTED",
+                  "read_errors": "3",
+                  "write_errors": "0",
+                  "checksum_errors": "12"
+                },
+                "sda": {
+                  "name": "sda",
+                  "vdev_type": "disk",
+                  "guid": "12733389512783513017",
+                  "path": "/dev/sda1",
+                  "class": "normal",
+                  "state": "ONLINE",
+                  "read_errors": "0",
+                  "write_errors": "0",
+                  "checksum_errors": "0"
+                }
+              }
+            }
+          }
+        }
+      },
+      "logs": {
+        "nvme0n1": {
+          "name": "nvme0n1",
+          "vdev_type": "disk",
+          "guid": "3390587611232418211",
+          "class": "logs",
+          "state": "ONLINE",
+          "read_errors": "0",
+          "write_errors": "0",
+          "checksum_errors": "0"
+        }
+      },
+      "l2cache": {
+        "nvme1n1": {
+          "name": "nvme1n1",
+          "vdev_type": "disk",
+          "guid": "9820431776312355113",
+          "class": "l2cache",
+          "state": "ONLINE",
+          "read_errors": "0",
+          "write_errors": "0",
+          "checksum_errors": "0"
+        }
+      },
+      "spares": {
+        "sdc": {
+          "name": "sdc",
+          "vdev_type": "disk",
+          "guid": "5108239987721231233",
+          "class": "spare",
+          "state": "AVAIL"
+        }
+      },
+      "error_count": "0"
+    }
+  }
+}`
+)
+
+func TestJSONListRows(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		out  string
+		key  string
+		cols []string
+		want [][]string
+	}{
+		{
+			name: "zfs list",
+			out:  testJSONZfsList,
+			key:  jsonDatasetsKey,
+			cols: listFileSystemsOutputCols,
+			want: [][]string{
+				{"tank", "6321581830434387226", "1697357281"},
+				{"tank/home", "2406237466416624013", "1697357390"},
+				{"tank/apps", "15632940478624371130", "1697357412"},
+			},
+		},
+		{
+			name: "zpool list",
+			out:  testJSONZpoolList,
+			key:  jsonPoolsKey,
+			cols: listPoolsOutputCols,
+			want: [][]string{
+				{"tank", "11806393938123466592", "1992864825344", "502813016064", "1490051809280", "3", "ONLINE", "-"},
+			},
+		},
+		{
+			name: "Top-level fields",
+			out:  testJSONZpoolList,
+			key:  jsonPoolsKey,
+			cols: []string{"name", "pool_guid", "txg"},
+			want: [][]string{{"tank", "11806393938123466592", "2731812"}},
+		},
+		{
+			name: "Empty",
+			out:  `{"output_version": {"command": "zfs list", "vers_major": 0, "vers_minor": 1}, "datasets": {}}`,
+			key:  jsonDatasetsKey,
+			cols: listSnapshotsOutputCols,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := jsonListRows(test.out, test.key, test.cols)
+		if err != nil {
+			t.Errorf("jsonListRows()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", test.name, err)
+			continue
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("jsonListRows()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestJSONListRowsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		out  string
+		cols []string
+	}{
+		{
+			name: "Invalid JSON",
+			out:  `{"datasets": `,
+			cols: []string{"name"},
+		},
+		{
+			name: "Missing column",
+			out:  testJSONZfsList,
+			cols: []string{"name", "used"},
+		},
+	}
+
+	for _, test := range tests {
+		if got, err := jsonListRows(test.out, jsonDatasetsKey, test.cols); err == nil {
+			t.Errorf("jsonListRows()\nTest Case: %q\nFailure: gotErr == nil\nGot: %q", test.name, got)
+		}
+	}
+}
+
+func TestJSONGetRows(t *testing.T) {
+	t.Parallel()
+
+	got, err := jsonGetRows(
+		testJSONZfsGet, jsonDatasetsKey, []string{"compression", "atime", "quota"},
+		[]string{"name", "property", "value", "source"})
+	if err != nil {
+		t.Fatalf("jsonGetRows()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "zfs get", err)
+	}
+
+	want := [][]string{
+		{"tank/home", "compression", "lz4", "inherited from tank"},
+		{"tank/home", "atime", "off", "local"},
+		{"tank/home", "quota", "none", "default"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("jsonGetRows()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "zfs get", diff)
+	}
+
+	got, err = jsonGetRows(testJSONZfsGet, jsonDatasetsKey, []string{"all"}, []string{"property", "value"})
+	if err != nil {
+		t.Fatalf("jsonGetRows()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "All properties", err)
+	}
+
+	want = [][]string{{"compression", "lz4"}, {"atime", "off"}, {"quota", "none"}}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("jsonGetRows()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "All properties", diff)
+	}
+
+	if _, err := jsonGetRows(testJSONZfsGet, jsonDatasetsKey, []string{"dedup"}, getFsOrSnapPropOutputCols); err == nil {
+		t.Errorf("jsonGetRows()\nTest Case: %q\nFailure: gotErr == nil", "Missing property")
+	}
+}
+
+func TestParsePoolStatusJSON(t *testing.T) {
+	t.Parallel()
+
+	got, err := parsePoolStatus("tank", testJSONZpoolStatus)
+	if err != nil {
+		t.Fatalf("parsePoolStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "JSON", err)
+	}
+
+	want := &PoolStatus{
+		Name:   "tank",
+		State:  "DEGRADED",
+		Status: "One or more devices are faulted in response to persistent errors.",
+		Action: "Replace the faulted device, or use 'zpool clear' to mark the device repaired.",
+		Scan:   "scrub repaired 0B in 01:39:03 with 0 errors on " + time.Unix(1791684184, 0).Format(scanTimestampLayout),
+		Errors: "No known data errors",
+		Vdevs: &VdevTree{
+			Root: &Vdev{
+				Name:  "tank",
+				State: "DEGRADED",
+				Children: VdevList{
+					{
+						Name:  "mirror-0",
+						State: "DEGRADED",
+						Children: VdevList{
+							{Name: "sdb", State: "FAULTED", ReadErrors: 3, ChecksumErrors: 12},
+							{Name: "sda", State: "ONLINE"},
+						},
+					},
+				},
+			},
+			Logs:   VdevList{{Name: "nvme0n1", State: "ONLINE"}},
+			Cache:  VdevList{{Name: "nvme1n1", State: "ONLINE"}},
+			Spares: VdevList{{Name: "sdc", State: "AVAIL"}},
+		},
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(PoolStatus{})); diff != "" {
+		t.Errorf("parsePoolStatus()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "JSON", diff)
+	}
+
+	scan, err := got.ScanStatus()
+	if err != nil {
+		t.Fatalf("ScanStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "JSON", err)
+	}
+
+	wantScan := &ScanStatus{
+		Function: "scrub",
+		EndTime:  time.Unix(1791684184, 0),
+	}
+
+	if diff := cmp.Diff(wantScan, scan); diff != "" {
+		t.Errorf("ScanStatus()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "JSON", diff)
+	}
+
+	if _, err := parsePoolStatus("backup", testJSONZpoolStatus); err == nil {
+		t.Errorf("parsePoolStatus()\nTest Case: %q\nFailure: gotErr == nil", "Mismatched pool")
+	}
+}
+
+func TestJSONScanStats(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1792283041, 0)
+	end := time.Unix(1792283083, 0)
+
+	tests := []struct {
+		name     string
+		scan     string
+		want     *ScanStatus
+		wantText string
+	}{
+		{
+			name:     "None",
+			scan:     `{"function": "NONE", "state": "NONE", "start_time": "0", "end_time": "0"}`,
+			want:     &ScanStatus{},
+			wantText: "none requested",
+		},
+		{
+			name: "Scrub in progress",
+			scan: `{"function": "SCRUB", "state": "SCANNING", "start_time": "1792283041", "end_time": "0", ` +
+				`"processed": "0", "errors": "0", "scrub_pause": "0"}`,
+			want:     &ScanStatus{Function: "scrub", InProgress: true, StartTime: start},
+			wantText: "scrub in progress since " + start.Format(scanTimestampLayout),
+		},
+		{
+			name: "Scrub paused",
+			scan: `{"function": "SCRUB", "state": "SCANNING", "start_time": "1792283041", "end_time": "0", ` +
+				`"processed": "0", "errors": "0", "scrub_pause": "1792283083"}`,
+			want: &ScanStatus{Function: "scrub", InProgress: true, Paused: true, StartTime: start},
+			wantText: "scrub paused since " + end.Format(scanTimestampLayout) +
+				" scrub started on " + start.Format(scanTimestampLayout),
+		},
+		{
+			name: "Resilver finished",
+			scan: `{"function": "RESILVER", "state": "FINISHED", "start_time": "1792283041", "end_time": "1792283083", ` +
+				`"processed": "1048576", "errors": "0", "scrub_pause": "0"}`,
+			want:     &ScanStatus{Function: "resilver", EndTime: end},
+			wantText: "resilvered 1048576B in 00:00:42 with 0 errors on " + end.Format(scanTimestampLayout),
+		},
+		{
+			name: "Scrub canceled",
+			scan: `{"function": "SCRUB", "state": "CANCELED", "start_time": "1792283041", "end_time": "1792283083", ` +
+				`"processed": "0", "errors": "0", "scrub_pause": "0"}`,
+			want:     &ScanStatus{Function: "scrub", Canceled: true, EndTime: end},
+			wantText: "scrub canceled on " + end.Format(scanTimestampLayout),
+		},
+	}
+
+	for _, test := range tests {
+		var stats jsonScanStats
+		if err := json.Unmarshal([]byte(test.scan), &stats); err != nil {
+			t.Fatalf("json.Unmarshal()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", test.name, err)
+		}
+
+		got, err := stats.scanStatus()
+		if err != nil {
+			t.Errorf("scanStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", test.name, err)
+			continue
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("scanStatus()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", test.name, diff)
+		}
+
+		text := stats.summary(got)
+		if text != test.wantText {
+			t.Errorf("summary()\nTest Case: %q\nGot: %q\nWant: %q", test.name, text, test.wantText)
+		}
+
+		if _, err := parseScanStatus(text); err != nil {
+			t.Errorf("parseScanStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", test.name, err)
+		}
+	}
+}
+
+func TestJSONScanStatsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		scan string
+	}{
+		{
+			name: "Formatted start time",
+			scan: `{"function": "SCRUB", "state": "SCANNING", "start_time": "Sun Oct 18 00:24:01 2026", "scrub_pause": "0"}`,
+		},
+		{
+			name: "Invalid errors",
+			scan: `{"function": "SCRUB", "state": "FINISHED", "start_time": "1792283041", "end_time": "1792283083", "errors": "x"}`,
+		},
+		{
+			name: "Unrecognized state",
+			scan: `{"function": "SCRUB", "state": "PAUSED", "start_time": "1792283041"}`,
+		},
+	}
+
+	for _, test := range tests {
+		var stats jsonScanStats
+		if err := json.Unmarshal([]byte(test.scan), &stats); err != nil {
+			t.Fatalf("json.Unmarshal()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", test.name, err)
+		}
+
+		if got, err := stats.scanStatus(); err == nil {
+			t.Errorf("scanStatus()\nTest Case: %q\nFailure: gotErr == nil\nGot: %v", test.name, got)
+		}
+	}
+}
+
+func TestSystemJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	stub := stubExecutor{
+		"zfs version": {Stdout: "zfs-2.3.0-1\nzfs-kmod-2.3.0-1\n"},
+		"zpool list -j -p -o name,guid,size,allocated,free,fragmentation,health,altroot": {Stdout: testJSONZpoolList},
+		"zpool list -H -p -o name,guid,size,allocated,free,fragmentation,health,altroot": {
+			Stdout: "tank\t11806393938123466592\t1992864825344\t502813016064\t1490051809280\t3\tONLINE\t-\n",
+		},
+		"zpool status -j -p tank": {Stdout: testJSONZpoolStatus},
+	}
+
+	want := PoolList{
+		&Pool{
+			Name:                 "tank",
+			GUID:                 11806393938123466592,
+			Size:                 1992864825344,
+			Allocated:            502813016064,
+			Free:                 1490051809280,
+			FragmentationPercent: 3,
+			HealthStatus:         "ONLINE",
+			AltRoot:              "-",
+		},
+	}
+
+	for _, disable := range []bool{false, true} {
+		system := NewSystem(&SystemConfig{Executor: stub, DisableJSON: disable})
+		updateExpectedPoolsWithSystem(want, system)
+
+		got, err := system.ListPools()
+		if err != nil {
+			t.Fatalf("ListPools()\nTest Case: DisableJSON: %t\nFailure: gotErr != nil\nReason: %v", disable, err)
+		}
+
+		if matchErr := poolListsEqual(want, got); matchErr != nil {
+			t.Errorf("ListPools()\nTest Case: DisableJSON: %t\nFailure: want and got differ\nReason: %s", disable, matchErr)
+		}
+
+		_, err = got[0].Status()
+		if gotErr := err != nil; gotErr != disable {
+			// The text status is not part of the stub, only the JSON one is.
+			t.Errorf("Status()\nTest Case: DisableJSON: %t\nFailure: unexpected error %v", disable, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package kstat
+
+// ARCStats represents the statistics of the Adaptive Replacement Cache (ARC)
+// and the L2ARC.
+type ARCStats struct {
+	Hits                   uint64 `kstat:"hits"`
+	Misses                 uint64 `kstat:"misses"`
+	DemandDataHits         uint64 `kstat:"demand_data_hits"`
+	DemandDataMisses       uint64 `kstat:"demand_data_misses"`
+	DemandMetadataHits     uint64 `kstat:"demand_metadata_hits"`
+	DemandMetadataMisses   uint64 `kstat:"demand_metadata_misses"`
+	PrefetchDataHits       uint64 `kstat:"prefetch_data_hits"`
+	PrefetchDataMisses     uint64 `kstat:"prefetch_data_misses"`
+	PrefetchMetadataHits   uint64 `kstat:"prefetch_metadata_hits"`
+	PrefetchMetadataMisses uint64 `kstat:"prefetch_metadata_misses"`
+	MRUHits                uint64 `kstat:"mru_hits"`
+	MRUGhostHits           uint64 `kstat:"mru_ghost_hits"`
+	MFUHits                uint64 `kstat:"mfu_hits"`
+	MFUGhostHits           uint64 `kstat:"mfu_ghost_hits"`
+	Deleted                uint64 `kstat:"deleted"`
+	EvictSkip              uint64 `kstat:"evict_skip"`
+	// Current size of the ARC in bytes.
+	Size uint64 `kstat:"size"`
+	// Target size of the ARC in bytes.
+	TargetSize uint64 `kstat:"c"`
+	// Minimum target size of the ARC in bytes.
+	TargetSizeMin uint64 `kstat:"c_min"`
+	// Maximum target size of the ARC in bytes.
+	TargetSizeMax       uint64 `kstat:"c_max"`
+	DataSize            uint64 `kstat:"data_size"`
+	MetadataSize        uint64 `kstat:"metadata_size"`
+	DnodeSize           uint64 `kstat:"dnode_size"`
+	DbufSize            uint64 `kstat:"dbuf_size"`
+	HeaderSize          uint64 `kstat:"hdr_size"`
+	L2Hits              uint64 `kstat:"l2_hits"`
+	L2Misses            uint64 `kstat:"l2_misses"`
+	L2Size              uint64 `kstat:"l2_size"`
+	L2AllocatedSize     uint64 `kstat:"l2_asize"`
+	L2HeaderSize        uint64 `kstat:"l2_hdr_size"`
+	MemoryThrottleCount uint64 `kstat:"memory_throttle_count"`
+	MemoryAllBytes      uint64 `kstat:"memory_all_bytes"`
+	MemoryFreeBytes     uint64 `kstat:"memory_free_bytes"`
+	// Could be negative when the system is low on memory.
+	MemoryAvailableBytes int64  `kstat:"memory_available_bytes"`
+	ARCNoGrow            uint64 `kstat:"arc_no_grow"`
+	ARCNeedFree          uint64 `kstat:"arc_need_free"`
+	// Raw values of all the statistics, including the ones not listed above.
+	Raw NamedStats
+}
+
+// DbufStats represents the statistics of the DMU buffer (dbuf) cache.
+type DbufStats struct {
+	CacheCount                uint64 `kstat:"cache_count"`
+	CacheSizeBytes            uint64 `kstat:"cache_size_bytes"`
+	CacheSizeBytesMax         uint64 `kstat:"cache_size_bytes_max"`
+	CacheTargetBytes          uint64 `kstat:"cache_target_bytes"`
+	CacheLowWaterBytes        uint64 `kstat:"cache_lowater_bytes"`
+	CacheHighWaterBytes       uint64 `kstat:"cache_hiwater_bytes"`
+	CacheTotalEvicts          uint64 `kstat:"cache_total_evicts"`
+	HashHits                  uint64 `kstat:"hash_hits"`
+	HashMisses                uint64 `kstat:"hash_misses"`
+	HashCollisions            uint64 `kstat:"hash_collisions"`
+	HashElements              uint64 `kstat:"hash_elements"`
+	HashElementsMax           uint64 `kstat:"hash_elements_max"`
+	HashChains                uint64 `kstat:"hash_chains"`
+	HashChainMax              uint64 `kstat:"hash_chain_max"`
+	HashInsertRace            uint64 `kstat:"hash_insert_race"`
+	MetadataCacheCount        uint64 `kstat:"metadata_cache_count"`
+	MetadataCacheSizeBytes    uint64 `kstat:"metadata_cache_size_bytes"`
+	MetadataCacheSizeBytesMax uint64 `kstat:"metadata_cache_size_bytes_max"`
+	MetadataCacheOverflow     uint64 `kstat:"metadata_cache_overflow"`
+	// Raw values of all the statistics, including the ones not listed above.
+	Raw NamedStats
+}
+
+// ZILStats represents the statistics of the ZFS Intent Log (ZIL).
+type ZILStats struct {
+	CommitCount            uint64 `kstat:"zil_commit_count"`
+	CommitWriterCount      uint64 `kstat:"zil_commit_writer_count"`
+	ITXCount               uint64 `kstat:"zil_itx_count"`
+	ITXIndirectCount       uint64 `kstat:"zil_itx_indirect_count"`
+	ITXIndirectBytes       uint64 `kstat:"zil_itx_indirect_bytes"`
+	ITXCopiedCount         uint64 `kstat:"zil_itx_copied_count"`
+	ITXCopiedBytes         uint64 `kstat:"zil_itx_copied_bytes"`
+	ITXNeedCopyCount       uint64 `kstat:"zil_itx_needcopy_count"`
+	ITXNeedCopyBytes       uint64 `kstat:"zil_itx_needcopy_bytes"`
+	ITXMetaslabNormalCount uint64 `kstat:"zil_itx_metaslab_normal_count"`
+	ITXMetaslabNormalBytes uint64 `kstat:"zil_itx_metaslab_normal_bytes"`
+	ITXMetaslabSlogCount   uint64 `kstat:"zil_itx_metaslab_slog_count"`
+	ITXMetaslabSlogBytes   uint64 `kstat:"zil_itx_metaslab_slog_bytes"`
+	// Raw values of all the statistics, including the ones not listed above.
+	Raw NamedStats
+}
+
+// ARCStats returns the ARC statistics.
+func (r *Reader) ARCStats() (*ARCStats, error) {
+	result := &ARCStats{}
+
+	raw, err := r.readNamedInto("arcstats", result)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Raw = raw
+
+	return result, nil
+}
+
+// DbufStats returns the dbuf cache statistics.
+func (r *Reader) DbufStats() (*DbufStats, error) {
+	result := &DbufStats{}
+
+	raw, err := r.readNamedInto("dbufstats", result)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Raw = raw
+
+	return result, nil
+}
+
+// ZILStats returns the ZIL statistics.
+func (r *Reader) ZILStats() (*ZILStats, error) {
+	result := &ZILStats{}
+
+	raw, err := r.readNamedInto("zil", result)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Raw = raw
+
+	return result, nil
+}
+
+func (r *Reader) readNamedInto(path string, dst interface{}) (NamedStats, error) {
+	raw, err := r.Named(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fill(path, dst, raw); err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
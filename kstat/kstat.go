@@ -0,0 +1,192 @@
+// Package kstat provides the functions for reading the zfs kernel
+// statistics exported by the SPL under /proc/spl/kstat/zfs.
+package kstat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultRoot is the directory the zfs kstats are exported under.
+	DefaultRoot = "/proc/spl/kstat/zfs"
+
+	kstatTag = "kstat"
+)
+
+// Reader reads the zfs kstats under a root directory.
+type Reader struct {
+	root string
+}
+
+// NamedStats represents the raw values of a kstat of the named type, keyed
+// by the name of each statistic.
+type NamedStats map[string]string
+
+// NewReader returns a new reader for the kstats under the specified root
+// directory, or under DefaultRoot if root is empty.
+func NewReader(root string) *Reader {
+	if root == "" {
+		root = DefaultRoot
+	}
+
+	return &Reader{root: root}
+}
+
+// Named returns the raw values of the named kstat at the specified path
+// relative to the root directory, e.g. "arcstats" or "tank/objset-0x36".
+func (r *Reader) Named(path string) (NamedStats, error) {
+	lines, err := r.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNamed(path, lines)
+}
+
+// Pools returns the names of the pools with kstats under the root directory.
+func (r *Reader) Pools() ([]string, error) {
+	entries, err := os.ReadDir(r.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kstat directory %q, reason: %w", r.root, err)
+	}
+
+	var result []string
+
+	for _, e := range entries {
+		if e.IsDir() {
+			result = append(result, e.Name())
+		}
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+func (r *Reader) readLines(path string) ([]string, error) {
+	fullPath := filepath.Join(r.root, path)
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kstat %q, reason: %w", fullPath, err)
+	}
+
+	s := strings.TrimRight(string(data), "\n")
+	if s == "" {
+		return nil, nil
+	}
+
+	return strings.Split(s, "\n"), nil
+}
+
+// parseNamed parses a kstat of the named type, which includes a kstat
+// header line, followed by a "name type data" column header line, followed
+// by one line per statistic.
+func parseNamed(path string, lines []string) (NamedStats, error) {
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("expected at least 2 header lines in named kstat %q, but found %d", path, len(lines))
+	}
+
+	if cols := strings.Fields(lines[1]); len(cols) != 3 || cols[0] != "name" {
+		return nil, fmt.Errorf("invalid column header in named kstat %q, line: %q", path, lines[1])
+	}
+
+	result := make(NamedStats)
+
+	for _, line := range lines[2:] {
+		cols := strings.Fields(line)
+		if len(cols) < 2 {
+			return nil, fmt.Errorf("expected at least 2 columns per line in named kstat %q, but found %d, line: %q", path, len(cols), line)
+		}
+
+		// String values could be empty or contain spaces.
+		value := ""
+		if len(cols) > 2 {
+			value = strings.Join(cols[2:], " ")
+		}
+
+		result[cols[0]] = value
+	}
+
+	return result, nil
+}
+
+// parseTable parses a kstat with a kstat header line, followed by a
+// column header line, followed by one line per row.
+func parseTable(path string, lines []string) ([]map[string]string, error) {
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("expected at least 2 header lines in kstat %q, but found %d", path, len(lines))
+	}
+
+	header := strings.Fields(lines[1])
+
+	var result []map[string]string
+
+	for _, line := range lines[2:] {
+		cols := strings.Fields(line)
+		if len(cols) != len(header) {
+			return nil, fmt.Errorf("expected %d columns per line in kstat %q, but found %d, line: %q", len(header), path, len(cols), line)
+		}
+
+		row := make(map[string]string, len(cols))
+		for i, c := range cols {
+			row[header[i]] = c
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// fill sets the fields of the struct pointed to by dst from the values,
+// using the "kstat" tag of each field as the key. Values missing from the
+// kstat (e.g. statistics not exported by the running zfs version) leave the
+// field untouched.
+func fill(path string, dst interface{}, values map[string]string) error {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get(kstatTag)
+		if key == "" {
+			continue
+		}
+
+		raw, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		f := v.Field(i)
+
+		switch f.Kind() {
+		case reflect.Uint64:
+			val, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parsing %q in kstat %q, unable to convert %q to uint64: %w", key, path, raw, err)
+			}
+
+			f.SetUint(val)
+		case reflect.Int64:
+			val, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parsing %q in kstat %q, unable to convert %q to int64: %w", key, path, raw, err)
+			}
+
+			f.SetInt(val)
+		case reflect.String:
+			f.SetString(raw)
+		default:
+			return fmt.Errorf("unsupported kstat field type %v for %q in kstat %q", f.Kind(), key, path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package kstat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const (
+	testRoot = "testdata"
+)
+
+func TestARCStats(t *testing.T) {
+	t.Parallel()
+
+	want := &ARCStats{
+		Hits:                 123456,
+		Misses:               1234,
+		DemandDataHits:       100000,
+		DemandDataMisses:     1000,
+		MRUHits:              50000,
+		MFUHits:              73456,
+		Size:                 4294967296,
+		TargetSize:           4294967296,
+		TargetSizeMin:        1073741824,
+		TargetSizeMax:        8589934592,
+		L2Hits:               42,
+		MemoryAvailableBytes: -1048576,
+	}
+
+	got, gotErr := NewReader(testRoot).ARCStats()
+	if nil != gotErr {
+		t.Errorf(
+			"ARCStats()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ARCStats{}, "Raw")); diff != "" {
+		t.Errorf(
+			"ARCStats()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+
+	if got.Raw["some_future_stat"] != "7" {
+		t.Errorf(
+			"ARCStats()\nTest Case: %q\nFailure: raw stat missing\nReason: raw = %v",
+			t.Name(), got.Raw)
+	}
+}
+
+func TestDbufStats(t *testing.T) {
+	t.Parallel()
+
+	want := &DbufStats{
+		CacheCount:        812,
+		CacheSizeBytes:    33554432,
+		CacheSizeBytesMax: 67108864,
+		HashHits:          98765,
+		HashMisses:        4321,
+	}
+
+	got, gotErr := NewReader(testRoot).DbufStats()
+	if nil != gotErr {
+		t.Errorf(
+			"DbufStats()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(DbufStats{}, "Raw")); diff != "" {
+		t.Errorf(
+			"DbufStats()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestZILStats(t *testing.T) {
+	t.Parallel()
+
+	want := &ZILStats{
+		CommitCount:          15,
+		CommitWriterCount:    12,
+		ITXCount:             300,
+		ITXMetaslabSlogBytes: 65536,
+	}
+
+	got, gotErr := NewReader(testRoot).ZILStats()
+	if nil != gotErr {
+		t.Errorf(
+			"ZILStats()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ZILStats{}, "Raw")); diff != "" {
+		t.Errorf(
+			"ZILStats()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestPools(t *testing.T) {
+	t.Parallel()
+
+	got, gotErr := NewReader(testRoot).Pools()
+	if nil != gotErr {
+		t.Errorf(
+			"Pools()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff([]string{"tank"}, got); diff != "" {
+		t.Errorf(
+			"Pools()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestPoolIO(t *testing.T) {
+	t.Parallel()
+
+	want := &PoolIOStats{
+		BytesRead:    1048576,
+		BytesWritten: 2097152,
+		Reads:        16,
+		Writes:       32,
+		WaitTime:     100,
+		WaitLenTime:  200,
+		WaitUpdate:   300,
+		RunTime:      400,
+		RunLenTime:   500,
+		RunUpdate:    600,
+		RunCount:     1,
+	}
+
+	got, gotErr := NewReader(testRoot).PoolIO("tank")
+	if nil != gotErr {
+		t.Errorf(
+			"PoolIO()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"PoolIO()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestTXGs(t *testing.T) {
+	t.Parallel()
+
+	want := []*TXG{
+		{
+			TXG:          100,
+			Birth:        2281000000,
+			State:        "C",
+			DirtyBytes:   1024,
+			BytesWritten: 8192,
+			Writes:       4,
+			OpenTime:     5000000000,
+			QuiesceTime:  1000,
+			WaitTime:     2000,
+			SyncTime:     3000000,
+		},
+		{
+			TXG:         101,
+			Birth:       2286000000,
+			State:       "S",
+			DirtyBytes:  2048,
+			BytesRead:   512,
+			Reads:       1,
+			OpenTime:    5000000000,
+			QuiesceTime: 1500,
+			WaitTime:    2500,
+		},
+		{
+			TXG:   102,
+			Birth: 2291000000,
+			State: "O",
+		},
+	}
+
+	got, gotErr := NewReader(testRoot).TXGs("tank")
+	if nil != gotErr {
+		t.Errorf(
+			"TXGs()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"TXGs()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestObjsets(t *testing.T) {
+	t.Parallel()
+
+	want := []*ObjsetStats{
+		{
+			ID:           "objset-0x10",
+			DatasetName:  "tank",
+			Writes:       1,
+			BytesWritten: 512,
+		},
+		{
+			ID:           "objset-0x36",
+			DatasetName:  "tank/home",
+			Writes:       10,
+			BytesWritten: 40960,
+			Reads:        5,
+			BytesRead:    20480,
+			Unlinks:      2,
+			Unlinked:     2,
+		},
+	}
+
+	got, gotErr := NewReader(testRoot).Objsets("tank")
+	if nil != gotErr {
+		t.Errorf(
+			"Objsets()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"Objsets()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+func TestMissingKstat(t *testing.T) {
+	t.Parallel()
+
+	if _, gotErr := NewReader(testRoot).PoolIO("missing"); gotErr == nil {
+		t.Errorf(
+			"PoolIO()\nTest Case: %q\nFailure: gotErr == nil\nReason: expected error for missing pool",
+			t.Name())
+	}
+}
+
+func TestFillUnsupportedFieldType(t *testing.T) {
+	t.Parallel()
+
+	dst := &struct {
+		Ratio float64 `kstat:"ratio"`
+	}{}
+
+	gotErr := fill("arcstats", dst, map[string]string{"ratio": "1.5"})
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "unsupported kstat field type float64") {
+		t.Errorf(
+			"fill()\nTest Case: %q\nFailure: unexpected error\nReason: gotErr = %v",
+			t.Name(), gotErr)
+	}
+}
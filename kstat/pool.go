@@ -0,0 +1,179 @@
+package kstat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PoolIOStats represents the cumulative I/O statistics of a pool.
+type PoolIOStats struct {
+	// Number of bytes read.
+	BytesRead uint64 `kstat:"nread"`
+	// Number of bytes written.
+	BytesWritten uint64 `kstat:"nwritten"`
+	// Number of read operations.
+	Reads uint64 `kstat:"reads"`
+	// Number of write operations.
+	Writes uint64 `kstat:"writes"`
+	// Cumulative wait (pre-service) time in nanoseconds.
+	WaitTime uint64 `kstat:"wtime"`
+	// Cumulative wait length*time product.
+	WaitLenTime uint64 `kstat:"wlentime"`
+	// Last time the wait queue was updated.
+	WaitUpdate uint64 `kstat:"wupdate"`
+	// Cumulative run (service) time in nanoseconds.
+	RunTime uint64 `kstat:"rtime"`
+	// Cumulative run length*time product.
+	RunLenTime uint64 `kstat:"rlentime"`
+	// Last time the run queue was updated.
+	RunUpdate uint64 `kstat:"rupdate"`
+	// Number of elements in the wait queue.
+	WaitCount uint64 `kstat:"wcnt"`
+	// Number of elements in the run queue.
+	RunCount uint64 `kstat:"rcnt"`
+}
+
+// TXG represents the statistics of a single transaction group of a pool.
+type TXG struct {
+	// Transaction group number.
+	TXG uint64 `kstat:"txg"`
+	// Birth time of the transaction group in nanoseconds.
+	Birth uint64 `kstat:"birth"`
+	// State of the transaction group, i.e. "O" (open), "Q" (quiescing),
+	// "W" (waiting for sync), "S" (syncing) or "C" (committed).
+	State string `kstat:"state"`
+	// Number of dirty bytes.
+	DirtyBytes uint64 `kstat:"ndirty"`
+	// Number of bytes read.
+	BytesRead uint64 `kstat:"nread"`
+	// Number of bytes written.
+	BytesWritten uint64 `kstat:"nwritten"`
+	// Number of read operations.
+	Reads uint64 `kstat:"reads"`
+	// Number of write operations.
+	Writes uint64 `kstat:"writes"`
+	// Time spent in the open state in nanoseconds.
+	OpenTime uint64 `kstat:"otime"`
+	// Time spent in the quiescing state in nanoseconds.
+	QuiesceTime uint64 `kstat:"qtime"`
+	// Time spent waiting for sync in nanoseconds.
+	WaitTime uint64 `kstat:"wtime"`
+	// Time spent syncing in nanoseconds.
+	SyncTime uint64 `kstat:"stime"`
+}
+
+// ObjsetStats represents the I/O statistics of a single dataset.
+type ObjsetStats struct {
+	// Name of the kstat, e.g. "objset-0x36".
+	ID string
+	// Full name of the dataset.
+	DatasetName string `kstat:"dataset_name"`
+	// Number of write operations.
+	Writes uint64 `kstat:"writes"`
+	// Number of bytes written.
+	BytesWritten uint64 `kstat:"nwritten"`
+	// Number of read operations.
+	Reads uint64 `kstat:"reads"`
+	// Number of bytes read.
+	BytesRead uint64 `kstat:"nread"`
+	// Number of files queued for unlinking.
+	Unlinks uint64 `kstat:"nunlinks"`
+	// Number of files unlinked.
+	Unlinked uint64 `kstat:"nunlinked"`
+}
+
+// PoolIO returns the cumulative I/O statistics of the pool.
+func (r *Reader) PoolIO(pool string) (*PoolIOStats, error) {
+	path := filepath.Join(pool, "io")
+
+	lines, err := r.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := parseTable(path, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("expected exactly 1 row in kstat %q, but found %d", path, len(rows))
+	}
+
+	result := &PoolIOStats{}
+	if err := fill(path, result, rows[0]); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TXGs returns the statistics of the recent transaction groups of the pool,
+// in the order of the transaction group number.
+func (r *Reader) TXGs(pool string) ([]*TXG, error) {
+	path := filepath.Join(pool, "txgs")
+
+	lines, err := r.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := parseTable(path, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*TXG
+
+	for _, row := range rows {
+		txg := &TXG{}
+		if err := fill(path, txg, row); err != nil {
+			return nil, err
+		}
+
+		result = append(result, txg)
+	}
+
+	return result, nil
+}
+
+// Objsets returns the I/O statistics of all the datasets within the pool.
+func (r *Reader) Objsets(pool string) ([]*ObjsetStats, error) {
+	dir := filepath.Join(r.root, pool)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kstat directory %q, reason: %w", dir, err)
+	}
+
+	var result []*ObjsetStats
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "objset-") {
+			continue
+		}
+
+		path := filepath.Join(pool, e.Name())
+
+		raw, err := r.Named(path)
+		if err != nil {
+			return nil, err
+		}
+
+		o := &ObjsetStats{ID: e.Name()}
+		if err := fill(path, o, raw); err != nil {
+			return nil, err
+		}
+
+		result = append(result, o)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DatasetName < result[j].DatasetName
+	})
+
+	return result, nil
+}
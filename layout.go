@@ -0,0 +1,280 @@
+package zfs
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	vdevTypeDisk   = ""
+	vdevTypeMirror = "mirror"
+	vdevTypeRaidZ  = "raidz"
+	vdevTypeDRaid  = "draid"
+)
+
+// VdevSpec represents the specification of a single top-level vdev used in
+// a pool layout.
+type VdevSpec struct {
+	// Type of the vdev as accepted by 'zpool create', e.g. "mirror",
+	// "raidz2" or "draid1:4d:6c:1s", empty for a single device.
+	Type string
+	// Devices (or files) backing the vdev.
+	Devices []string
+
+	err error
+}
+
+// DiskVdev returns the specification of a vdev backed by a single device
+// (or file) without any redundancy.
+func DiskVdev(device string) VdevSpec {
+	return VdevSpec{Type: vdevTypeDisk, Devices: []string{device}}
+}
+
+// MirrorVdev returns the specification of a mirror vdev across the
+// specified devices.
+func MirrorVdev(devices ...string) VdevSpec {
+	result := VdevSpec{Type: vdevTypeMirror, Devices: devices}
+	if len(devices) < 2 {
+		result.err = fmt.Errorf("mirror vdev requires at least 2 devices, but found %d", len(devices))
+	}
+
+	return result
+}
+
+// RaidZVdev returns the specification of a raidz vdev with the specified
+// parity (1, 2 or 3) across the specified devices.
+func RaidZVdev(parity int, devices ...string) VdevSpec {
+	result := VdevSpec{Type: fmt.Sprintf("%s%d", vdevTypeRaidZ, parity), Devices: devices}
+	if parity < 1 || parity > 3 {
+		result.err = fmt.Errorf("raidz vdev parity must be 1, 2 or 3, but found %d", parity)
+	} else if len(devices) < parity+1 {
+		result.err = fmt.Errorf(
+			"raidz%d vdev requires at least %d devices, but found %d", parity, parity+1, len(devices))
+	}
+
+	return result
+}
+
+// DRaidVdev returns the specification of a draid vdev with the specified
+// parity (1, 2 or 3), number of data devices per redundancy group and
+// number of distributed spares across the specified devices. Zero data
+// devices or spares use the zfs defaults.
+func DRaidVdev(parity int, data int, spares int, devices ...string) VdevSpec {
+	spec := fmt.Sprintf("%s%d", vdevTypeDRaid, parity)
+	if data > 0 {
+		spec += fmt.Sprintf(":%dd", data)
+	}
+
+	spec += fmt.Sprintf(":%dc", len(devices))
+
+	if spares > 0 {
+		spec += fmt.Sprintf(":%ds", spares)
+	}
+
+	result := VdevSpec{Type: spec, Devices: devices}
+
+	minDevices := parity + data + spares
+	if data == 0 {
+		minDevices++
+	}
+
+	switch {
+	case parity < 1 || parity > 3:
+		result.err = fmt.Errorf("draid vdev parity must be 1, 2 or 3, but found %d", parity)
+	case data < 0 || spares < 0:
+		result.err = fmt.Errorf("draid vdev data devices and spares must not be negative")
+	case len(devices) < minDevices:
+		result.err = fmt.Errorf(
+			"%s vdev requires at least %d devices, but found %d", spec, minDevices, len(devices))
+	}
+
+	return result
+}
+
+// PoolLayout represents the vdev topology of a pool used to create the
+// pool. The layout is built by chaining the methods, e.g.
+//
+//	NewPoolLayout().Data(MirrorVdev("sda", "sdb")).Log(DiskVdev("nvme0n1"))
+type PoolLayout struct {
+	data    []VdevSpec
+	special []VdevSpec
+	dedup   []VdevSpec
+	log     []VdevSpec
+	cache   []string
+	spares  []string
+}
+
+// NewPoolLayout returns a new empty pool layout.
+func NewPoolLayout() *PoolLayout {
+	return &PoolLayout{}
+}
+
+// Data adds the specified vdevs to the normal allocation class.
+func (l *PoolLayout) Data(vdevs ...VdevSpec) *PoolLayout {
+	l.data = append(l.data, vdevs...)
+	return l
+}
+
+// Special adds the specified vdevs to the special allocation class (used
+// for metadata and optionally small blocks).
+func (l *PoolLayout) Special(vdevs ...VdevSpec) *PoolLayout {
+	l.special = append(l.special, vdevs...)
+	return l
+}
+
+// Dedup adds the specified vdevs to the dedup allocation class.
+func (l *PoolLayout) Dedup(vdevs ...VdevSpec) *PoolLayout {
+	l.dedup = append(l.dedup, vdevs...)
+	return l
+}
+
+// Log adds the specified vdevs as separate intent log devices.
+func (l *PoolLayout) Log(vdevs ...VdevSpec) *PoolLayout {
+	l.log = append(l.log, vdevs...)
+	return l
+}
+
+// Cache adds the specified devices as L2ARC cache devices.
+func (l *PoolLayout) Cache(devices ...string) *PoolLayout {
+	l.cache = append(l.cache, devices...)
+	return l
+}
+
+// Spare adds the specified devices as hot spares.
+func (l *PoolLayout) Spare(devices ...string) *PoolLayout {
+	l.spares = append(l.spares, devices...)
+	return l
+}
+
+// Args validates the layout and returns the list of vdev arguments as
+// accepted by 'zpool create' or 'zpool add'.
+func (l *PoolLayout) Args() ([]string, error) {
+	if len(l.data) == 0 {
+		return nil, fmt.Errorf("pool layout requires at least one data vdev")
+	}
+
+	var result []string
+
+	classes := []struct {
+		keyword string
+		vdevs   []VdevSpec
+	}{
+		{"", l.data},
+		{"special", l.special},
+		{"dedup", l.dedup},
+		{"log", l.log},
+	}
+
+	for _, c := range classes {
+		if len(c.vdevs) == 0 {
+			continue
+		}
+
+		if c.keyword != "" {
+			result = append(result, c.keyword)
+		}
+
+		for _, v := range c.vdevs {
+			args, err := v.args()
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, args...)
+		}
+	}
+
+	if len(l.cache) > 0 {
+		result = append(append(result, "cache"), l.cache...)
+	}
+
+	if len(l.spares) > 0 {
+		result = append(append(result, "spare"), l.spares...)
+	}
+
+	return result, nil
+}
+
+// usesDRaid returns true if any of the vdevs is a draid vdev.
+func (l *PoolLayout) usesDRaid() bool {
+	for _, class := range [][]VdevSpec{l.data, l.special, l.dedup, l.log} {
+		for _, v := range class {
+			if strings.HasPrefix(v.Type, vdevTypeDRaid) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// String returns the string representation of the pool layout.
+func (l *PoolLayout) String() string {
+	args, err := l.Args()
+	if err != nil {
+		return fmt.Sprintf("{PoolLayout Invalid: %v}", err)
+	}
+
+	return fmt.Sprintf("{PoolLayout %s}", strings.Join(args, " "))
+}
+
+func (v VdevSpec) args() ([]string, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	if len(v.Devices) == 0 {
+		return nil, fmt.Errorf("vdev %q requires at least one device", v.Type)
+	}
+
+	for _, d := range v.Devices {
+		if d == "" {
+			return nil, fmt.Errorf("vdev %q includes an empty device name", v.Type)
+		}
+	}
+
+	if v.Type == vdevTypeDisk {
+		if len(v.Devices) != 1 {
+			return nil, fmt.Errorf("disk vdev requires exactly one device, but found %d", len(v.Devices))
+		}
+
+		return v.Devices, nil
+	}
+
+	return append([]string{v.Type}, v.Devices...), nil
+}
+
+// CreatePool creates a new pool with the specified name and layout, sets
+// the specified properties on the pool and the root file system of the
+// pool, and returns the newly created pool.
+func (s *System) CreatePool(
+	name string, layout *PoolLayout, poolProps map[string]string, rootFsProps map[string]string,
+) (*Pool, error) {
+	vdevArgs, err := layout.Args()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool %q, invalid layout, reason: %w", name, err)
+	}
+
+	if layout.usesDRaid() {
+		if err := s.requireCapability(CapabilityDRaid); err != nil {
+			return nil, fmt.Errorf("failed to create pool %q, reason: %w", name, err)
+		}
+	}
+
+	_, err = s.cmd.zpool.create(name, vdevArgs, poolProps, rootFsProps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool %q, reason: %w", name, err)
+	}
+
+	return findPool(s, name)
+}
+
+// Destroy destroys the pool along with all the data within the pool.
+func (p *Pool) Destroy() error {
+	_, err := p.cmd().zpool.destroy(p.Name)
+	if err != nil {
+		return fmt.Errorf("failed to destroy pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
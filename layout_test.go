@@ -0,0 +1,144 @@
+package zfs
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var poolLayoutArgsTests = []struct {
+	name   string
+	layout *PoolLayout
+	want   []string
+}{
+	{
+		name:   "Single disk",
+		layout: NewPoolLayout().Data(DiskVdev("/tmp/d1")),
+		want:   []string{"/tmp/d1"},
+	},
+	{
+		name:   "Striped mirrors",
+		layout: NewPoolLayout().Data(MirrorVdev("sda", "sdb"), MirrorVdev("sdc", "sdd")),
+		want:   []string{"mirror", "sda", "sdb", "mirror", "sdc", "sdd"},
+	},
+	{
+		name: "All allocation classes",
+		layout: NewPoolLayout().
+			Data(RaidZVdev(2, "d1", "d2", "d3", "d4")).
+			Special(MirrorVdev("s1", "s2")).
+			Dedup(MirrorVdev("dd1", "dd2")).
+			Log(MirrorVdev("l1", "l2"), DiskVdev("l3")).
+			Cache("c1", "c2").
+			Spare("sp1"),
+		want: []string{
+			"raidz2", "d1", "d2", "d3", "d4",
+			"special", "mirror", "s1", "s2",
+			"dedup", "mirror", "dd1", "dd2",
+			"log", "mirror", "l1", "l2", "l3",
+			"cache", "c1", "c2",
+			"spare", "sp1",
+		},
+	},
+	{
+		name:   "DRaid with defaults",
+		layout: NewPoolLayout().Data(DRaidVdev(1, 0, 0, "d1", "d2", "d3")),
+		want:   []string{"draid1:3c", "d1", "d2", "d3"},
+	},
+	{
+		name:   "DRaid with data and spares",
+		layout: NewPoolLayout().Data(DRaidVdev(2, 4, 1, "d1", "d2", "d3", "d4", "d5", "d6", "d7", "d8")),
+		want:   []string{"draid2:4d:8c:1s", "d1", "d2", "d3", "d4", "d5", "d6", "d7", "d8"},
+	},
+}
+
+func TestPoolLayoutArgs(t *testing.T) {
+	for _, test := range poolLayoutArgsTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := tc.layout.Args()
+			if nil != gotErr {
+				t.Errorf(
+					"PoolLayout.Args()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"PoolLayout.Args()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var poolLayoutArgsErrorTests = []struct {
+	name   string
+	layout *PoolLayout
+	want   string
+}{
+	{
+		name:   "No data vdevs",
+		layout: NewPoolLayout().Log(DiskVdev("l1")),
+		want:   `pool layout requires at least one data vdev`,
+	},
+	{
+		name:   "Mirror with one device",
+		layout: NewPoolLayout().Data(MirrorVdev("sda")),
+		want:   `mirror vdev requires at least 2 devices, but found 1`,
+	},
+	{
+		name:   "Invalid raidz parity",
+		layout: NewPoolLayout().Data(RaidZVdev(4, "d1", "d2", "d3", "d4", "d5")),
+		want:   `raidz vdev parity must be 1, 2 or 3, but found 4`,
+	},
+	{
+		name:   "Raidz with too few devices",
+		layout: NewPoolLayout().Data(RaidZVdev(3, "d1", "d2", "d3")),
+		want:   `raidz3 vdev requires at least 4 devices, but found 3`,
+	},
+	{
+		name:   "DRaid with too few devices",
+		layout: NewPoolLayout().Data(DRaidVdev(1, 4, 1, "d1", "d2", "d3")),
+		want:   `draid1:4d:3c:1s vdev requires at least 6 devices, but found 3`,
+	},
+	{
+		name:   "Empty device name",
+		layout: NewPoolLayout().Data(MirrorVdev("sda", "")),
+		want:   `vdev "mirror" includes an empty device name`,
+	},
+}
+
+func TestPoolLayoutArgsErrors(t *testing.T) {
+	for _, test := range poolLayoutArgsErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := tc.layout.Args()
+			if gotErr == nil {
+				t.Errorf(
+					"PoolLayout.Args()\nTest Case: %q\nFailure: gotErr == nil\nReason: want = %q",
+					tc.name, tc.want)
+				return
+			}
+
+			match, err := regexp.MatchString(tc.want, gotErr.Error())
+			if err != nil {
+				t.Errorf(
+					"PoolLayout.Args()\nTest Case: %q\nFailure: unexpected exception while matching against gotErr error string\nReason: error = %v",
+					tc.name, err)
+				return
+			}
+
+			if !match {
+				t.Errorf(
+					"PoolLayout.Args()\nTest Case: %q\nFailure: gotErr did not match the want regex\nReason:\n\tgotErr = %q\n\twant   = %q",
+					tc.name, gotErr, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package zfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// MountpointNone indicates that the file system is not mounted by zfs.
+	MountpointNone = "none"
+	// MountpointLegacy indicates that the file system is mounted using the
+	// system mount tools (e.g. fstab) instead of zfs.
+	MountpointLegacy = "legacy"
+)
+
+// MountInfo represents a zfs mount as listed in /proc/self/mountinfo.
+type MountInfo struct {
+	// Full name of the mounted file system.
+	Source string
+	// Path the file system is mounted at.
+	Mountpoint string
+	// Per-mount options, e.g. "rw,noatime".
+	Options string
+}
+
+// Mountpoint returns the path the file system is mounted at when mounted by
+// zfs, i.e. the inherited or local mountpoint which zfs reports prefixed by
+// the alternate root of the pool if any, or one of MountpointNone and
+// MountpointLegacy.
+func (f *FileSystem) Mountpoint() (string, error) {
+	return f.GetProp("mountpoint")
+}
+
+// Mount mounts the file system at its mountpoint.
+func (f *FileSystem) Mount() error {
+	_, err := f.cmd().zfs.mount(f.FullName())
+	if err != nil {
+		return fmt.Errorf("failed to mount file system %q, reason: %w", f, err)
+	}
+
+	return nil
+}
+
+// Unmount unmounts the file system, forcefully unmounting it even if it is
+// busy when force is true.
+func (f *FileSystem) Unmount(force bool) error {
+	_, err := f.cmd().zfs.unmount(f.FullName(), force)
+	if err != nil {
+		return fmt.Errorf("failed to unmount file system %q, reason: %w", f, err)
+	}
+
+	return nil
+}
+
+// Mounts returns the list of places the file system is currently mounted at
+// as per the mount table of the system.
+func (f *FileSystem) Mounts() ([]*MountInfo, error) {
+	mounts, err := readZfsMountInfo(f.Pool.System.mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*MountInfo
+
+	for _, m := range mounts {
+		if m.Source == f.FullName() {
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
+// IsMounted returns true if the file system is mounted at its expected
+// mountpoint as per the mount table of the system, false otherwise. File
+// systems with a legacy mountpoint are considered mounted if mounted
+// anywhere.
+func (f *FileSystem) IsMounted() (bool, error) {
+	mp, err := f.Mountpoint()
+	if err != nil {
+		return false, err
+	}
+
+	if mp == MountpointNone {
+		return false, nil
+	}
+
+	mounts, err := f.Mounts()
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range mounts {
+		if mp == MountpointLegacy || m.Mountpoint == mp {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// readZfsMountInfo returns the list of zfs mounts from the mountinfo file at
+// the specified path.
+func readZfsMountInfo(mountInfoPath string) ([]*MountInfo, error) {
+	file, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mount info %q, reason: %w", mountInfoPath, err)
+	}
+	defer file.Close()
+
+	var result []*MountInfo
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		m, isZfs, err := parseMountInfoLine(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+
+		if isZfs {
+			result = append(result, m)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mount info %q, reason: %w", mountInfoPath, err)
+	}
+
+	return result, nil
+}
+
+// parseMountInfoLine parses a single line of mountinfo, which is of the
+// format:
+// <id> <parent id> <major:minor> <root> <mountpoint> <options> [<optional fields>...] - <fstype> <source> <super options>.
+func parseMountInfoLine(line string) (*MountInfo, bool, error) {
+	fields := strings.Fields(line)
+
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+
+	if len(fields) < 6 || sep < 0 || sep+2 >= len(fields) {
+		return nil, false, fmt.Errorf("parsing \"mount info\", invalid line: %q", line)
+	}
+
+	if fields[sep+1] != "zfs" {
+		return nil, false, nil
+	}
+
+	return &MountInfo{
+		Source:     unescapeMountInfo(fields[sep+2]),
+		Mountpoint: unescapeMountInfo(fields[4]),
+		Options:    fields[5],
+	}, true, nil
+}
+
+// unescapeMountInfo reverses the escaping of space, tab, newline and
+// backslash characters as a backslash followed by three octal digits in
+// mountinfo.
+func unescapeMountInfo(str string) string {
+	if !strings.Contains(str, "\\") {
+		return str
+	}
+
+	var result strings.Builder
+
+	l := len(str)
+	for i := 0; i < l; i++ {
+		if str[i] == '\\' && i+3 < l && isOctal(str[i+1]) && isOctal(str[i+2]) && isOctal(str[i+3]) {
+			result.WriteByte((str[i+1]-'0')<<6 | (str[i+2]-'0')<<3 | (str[i+3] - '0'))
+			i += 3
+
+			continue
+		}
+
+		result.WriteByte(str[i])
+	}
+
+	return result.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
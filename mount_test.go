@@ -0,0 +1,99 @@
+package zfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testMountInfo = `22 28 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:12 - proc proc rw
+28 1 0:25 / / rw,relatime shared:1 - zfs rpool/ROOT/ubuntu rw,xattr,posixacl
+95 28 0:47 / /srv/my\040data rw,relatime shared:50 master:3 - zfs tank/my\040data rw,xattr,noacl
+96 28 0:48 / /mnt/backup rw,relatime - zfs tank/backup rw,xattr,noacl
+97 28 0:48 / /mnt/backup-bind rw,relatime - zfs tank/backup rw,xattr,noacl
+98 28 8:1 / /boot rw,relatime shared:30 - ext4 /dev/sda1 rw
+`
+
+func TestReadZfsMountInfo(t *testing.T) {
+	t.Parallel()
+
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	if err := os.WriteFile(mountInfoPath, []byte(testMountInfo), 0o600); err != nil {
+		t.Fatalf("failed to write test mount info, reason: %v", err)
+	}
+
+	want := []*MountInfo{
+		{Source: "rpool/ROOT/ubuntu", Mountpoint: "/", Options: "rw,relatime"},
+		{Source: "tank/my data", Mountpoint: "/srv/my data", Options: "rw,relatime"},
+		{Source: "tank/backup", Mountpoint: "/mnt/backup", Options: "rw,relatime"},
+		{Source: "tank/backup", Mountpoint: "/mnt/backup-bind", Options: "rw,relatime"},
+	}
+
+	got, gotErr := readZfsMountInfo(mountInfoPath)
+	if nil != gotErr {
+		t.Errorf(
+			"readZfsMountInfo()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(
+			"readZfsMountInfo()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+}
+
+var isMountedTests = []struct {
+	name       string
+	fs         string
+	mountpoint string
+	want       bool
+}{
+	{name: "Mounted", fs: "backup", mountpoint: "/mnt/backup", want: true},
+	{name: "Mounted elsewhere", fs: "backup", mountpoint: "/srv/backup", want: false},
+	{name: "Mounted twice", fs: "backup", mountpoint: "/mnt/backup-bind", want: true},
+	{name: "Not mounted", fs: "other", mountpoint: "/srv/other", want: false},
+	{name: "Legacy", fs: "backup", mountpoint: MountpointLegacy, want: true},
+	{name: "Legacy not mounted", fs: "other", mountpoint: MountpointLegacy, want: false},
+	{name: "None", fs: "backup", mountpoint: MountpointNone, want: false},
+}
+
+func TestIsMounted(t *testing.T) {
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	if err := os.WriteFile(mountInfoPath, []byte(testMountInfo), 0o600); err != nil {
+		t.Fatalf("failed to write test mount info, reason: %v", err)
+	}
+
+	for _, test := range isMountedTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			system := NewSystem(&SystemConfig{
+				Executor: stubExecutor{
+					"zfs get -H -o value mountpoint tank/" + tc.fs: {Stdout: tc.mountpoint + "\n"},
+				},
+				DisableJSON:   true,
+				MountInfoPath: mountInfoPath,
+			})
+			fs := &FileSystem{Name: tc.fs, Pool: &Pool{Name: "tank", System: system}}
+
+			got, gotErr := fs.IsMounted()
+			if gotErr != nil {
+				t.Errorf(
+					"IsMounted()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if got != tc.want {
+				t.Errorf(
+					"IsMounted()\nTest Case: %q\nFailure: want and got differ\nReason: want=%t got=%t",
+					tc.name, tc.want, got)
+			}
+		})
+	}
+}
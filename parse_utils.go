@@ -16,13 +16,50 @@ func splitOnNewLine(input string) []string {
 	return strings.Split(s, "\n")
 }
 
-func strFromOnlyLine(input string) (string, error) {
-	lines := splitOnNewLine(input)
-	if len(lines) != 1 {
-		return "", fmt.Errorf("expected exactly one line of output, but found %d lines, lines = %v", len(lines), lines)
+// splitRows splits the tab-separated output of the '-H' option of the zfs
+// and zpool commands into rows of at most the specified number of columns,
+// the last column retains any tabs within its value.
+func splitRows(input string, cols int) [][]string {
+	var result [][]string
+	for _, line := range splitOnNewLine(input) {
+		result = append(result, strings.SplitN(line, "\t", cols))
 	}
 
-	return lines[0], nil
+	return result
+}
+
+// onlyRow returns the only row of the output.
+func onlyRow(rows [][]string) ([]string, error) {
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("expected exactly one row of output, but found %d rows, rows = %q", len(rows), rows)
+	}
+
+	return rows[0], nil
+}
+
+// valueFromOnlyRow returns the value of the only column of the only row of
+// the output.
+func valueFromOnlyRow(rows [][]string) (string, error) {
+	row, err := onlyRow(rows)
+	if err != nil {
+		return "", err
+	}
+
+	if len(row) != 1 {
+		return "", fmt.Errorf("expected exactly one column of output, but found %d columns, row = %q", len(row), row)
+	}
+
+	return row[0], nil
+}
+
+// dashToEmpty returns an empty string for "-", which zfs uses to represent
+// unset or inapplicable values, or the input string otherwise.
+func dashToEmpty(str string) string {
+	if str == "-" {
+		return ""
+	}
+
+	return str
 }
 
 func parseUint64(str string, desc string) (uint64, error) {
@@ -34,6 +71,16 @@ func parseUint64(str string, desc string) (uint64, error) {
 	return res, nil
 }
 
+// parseUint64OrNone is similar to parseUint64, but additionally parses
+// "none" and "-" (used by zfs for unset or unavailable values) as zero.
+func parseUint64OrNone(str string, desc string) (uint64, error) {
+	if str == "none" || str == "-" {
+		return 0, nil
+	}
+
+	return parseUint64(str, desc)
+}
+
 func parseUint8(str string, desc string) (uint8, error) {
 	res, err := strconv.ParseUint(str, 10, 8)
 	if err != nil {
@@ -43,6 +90,15 @@ func parseUint8(str string, desc string) (uint8, error) {
 	return uint8(res), nil
 }
 
+func parseFloat64(str string, desc string) (float64, error) {
+	res, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %q, unable to convert %q to float64: %w", desc, str, err)
+	}
+
+	return res, nil
+}
+
 func parseUnixTimestamp(str string, desc string) (time.Time, error) {
 	t, err := parseUint64(str, desc)
 	if err != nil {
@@ -52,6 +108,30 @@ func parseUnixTimestamp(str string, desc string) (time.Time, error) {
 	return time.Unix(int64(t), 0), nil
 }
 
+func parseUnixTimestampWithNanos(str string, desc string) (time.Time, error) {
+	secStr, nsecStr, found := strings.Cut(str, ".")
+	if !found {
+		return parseUnixTimestamp(str, desc)
+	}
+
+	sec, err := parseUint64(secStr, desc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(nsecStr) == 0 || len(nsecStr) > 9 {
+		return time.Time{}, fmt.Errorf("parsing %q, invalid fractional seconds in %q", desc, str)
+	}
+
+	// Right pad the fractional part to get the nanoseconds.
+	nsec, err := parseUint64(nsecStr+strings.Repeat("0", 9-len(nsecStr)), desc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(sec), int64(nsec)), nil
+}
+
 func parseTimestamp(str string, layout string, desc string) (time.Time, error) {
 	loc, err := time.LoadLocation("Local")
 	if err != nil {
@@ -83,13 +83,13 @@ func (p *Pool) cmd() *cmd {
 
 // GetProp returns the specified property's value for the pool.
 func (p *Pool) GetProp(prop string) (string, error) {
-	out, err := p.cmd().zpool.get(p.Name, []string{prop}, getPoolPropOutputCols)
+	out, err := p.cmd().zpool.get(p.Name, []string{prop}, getPoolPropOutputCols, false)
 	if err != nil {
 		return "", fmt.Errorf(
 			"failed to get property %q of pool %q, reason: %w", prop, p.Name, err)
 	}
 
-	val, err := strFromOnlyLine(out)
+	val, err := valueFromOnlyRow(out)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse property value %q, reason: %w", out, err)
 	}
@@ -97,10 +97,10 @@ func (p *Pool) GetProp(prop string) (string, error) {
 	return val, nil
 }
 
-func parsePoolInfo(system *System, line string) (*Pool, error) {
-	cols := strings.Split(line, "\t")
+func parsePoolInfo(system *System, cols []string) (*Pool, error) {
 	if len(cols) != 8 {
-		return nil, fmt.Errorf("expected 8 columns per line in pool info, but found %d, line: %q", len(cols), line)
+		return nil, fmt.Errorf(
+			"expected 8 columns per line in pool info, but found %d, line: %q", len(cols), strings.Join(cols, "\t"))
 	}
 
 	name := cols[0]
@@ -162,9 +162,8 @@ func listPools(system *System) (PoolList, error) {
 
 	var result PoolList
 
-	pools := splitOnNewLine(out)
-	for _, p := range pools {
-		pool, err := parsePoolInfo(system, p)
+	for _, row := range out {
+		pool, err := parsePoolInfo(system, row)
 		if err != nil {
 			return nil, err
 		}
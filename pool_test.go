@@ -1,35 +1,21 @@
 package zfs
 
 import (
-	"fmt"
 	"regexp"
 	"testing"
 )
 
 var listPoolsTests = []struct {
-	name  string
-	pools fakeZpools
-	want  PoolList
+	name string
+	out  string
+	want PoolList
 }{
 	{
-		name:  "No zpools",
-		pools: nil,
+		name: "No zpools",
 	},
 	{
 		name: "Single pool",
-		pools: fakeZpools{
-			"TestPool1": &fakeZpool{
-				props: propMap{
-					"guid":          "123456789012345",
-					"size":          "16000000",
-					"allocated":     "10000000",
-					"free":          "6000000",
-					"fragmentation": "5",
-					"health":        "ONLINE",
-					"altroot":       "-",
-				},
-			},
-		},
+		out:  "TestPool1\t123456789012345\t16000000\t10000000\t6000000\t5\tONLINE\t-\n",
 		want: PoolList{
 			&Pool{
 				Name:                 "TestPool1",
@@ -45,74 +31,12 @@ var listPoolsTests = []struct {
 	},
 	{
 		name: "Six pools with various Health Status and AltRoot",
-		pools: fakeZpools{
-			"TestPool1": &fakeZpool{
-				props: propMap{
-					"guid":          "1234567890123451",
-					"size":          "18000000",
-					"allocated":     "14000000",
-					"free":          "4000000",
-					"fragmentation": "10",
-					"health":        "DEGRADED",
-					"altroot":       "-",
-				},
-			},
-			"TestPool2": &fakeZpool{
-				props: propMap{
-					"guid":          "1234567890123452",
-					"size":          "10000000",
-					"allocated":     "1000000",
-					"free":          "9000000",
-					"fragmentation": "11",
-					"health":        "FAULTED",
-					"altroot":       "/foo-bar",
-				},
-			},
-			"TestPool3": &fakeZpool{
-				props: propMap{
-					"guid":          "1234567890123453",
-					"size":          "20000000",
-					"allocated":     "8000000",
-					"free":          "12000000",
-					"fragmentation": "12",
-					"health":        "OFFLINE",
-					"altroot":       "/foo-bar-baz",
-				},
-			},
-			"TestPool4": &fakeZpool{
-				props: propMap{
-					"guid":          "1234567890123454",
-					"size":          "40000000",
-					"allocated":     "9000000",
-					"free":          "31000000",
-					"fragmentation": "13",
-					"health":        "REMOVED",
-					"altroot":       "-",
-				},
-			},
-			"TestPool5": &fakeZpool{
-				props: propMap{
-					"guid":          "1234567890123455",
-					"size":          "12000000",
-					"allocated":     "6000000",
-					"free":          "6000000",
-					"fragmentation": "9",
-					"health":        "UNAVAIL",
-					"altroot":       "/some-other-root",
-				},
-			},
-			"TestPool6": &fakeZpool{
-				props: propMap{
-					"guid":          "1234567890123456",
-					"size":          "25000000",
-					"allocated":     "10000000",
-					"free":          "15000000",
-					"fragmentation": "1",
-					"health":        "ONLINE",
-					"altroot":       "-",
-				},
-			},
-		},
+		out: "TestPool1\t1234567890123451\t18000000\t14000000\t4000000\t10\tDEGRADED\t-\n" +
+			"TestPool2\t1234567890123452\t10000000\t1000000\t9000000\t11\tFAULTED\t/foo-bar\n" +
+			"TestPool3\t1234567890123453\t20000000\t8000000\t12000000\t12\tOFFLINE\t/foo-bar-baz\n" +
+			"TestPool4\t1234567890123454\t40000000\t9000000\t31000000\t13\tREMOVED\t-\n" +
+			"TestPool5\t1234567890123455\t12000000\t6000000\t6000000\t9\tUNAVAIL\t/some-other-root\n" +
+			"TestPool6\t1234567890123456\t25000000\t10000000\t15000000\t1\tONLINE\t-\n",
 		want: PoolList{
 			&Pool{
 				Name:                 "TestPool1",
@@ -184,7 +108,7 @@ func TestListPools(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			system := newFakeSystem(tc.pools)
+			system := newStubSystem(stubExecutor{testZpoolListCmd: {Stdout: tc.out}})
 			updateExpectedPoolsWithSystem(tc.want, system)
 
 			got, gotErr := system.ListPools()
@@ -205,79 +129,44 @@ func TestListPools(t *testing.T) {
 }
 
 var listPoolsColParsingErrorTests = []struct {
-	name  string
-	pools fakeZpools
-	want  string
+	name     string
+	override propMap
+	want     string
 }{
 	{
-		name: "Invalid pool GUID",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"guid": "invalid-guid",
-				}),
-		},
-		want: `parsing "pool info guid", unable to convert "invalid-guid" to uint64:.*`,
+		name:     "Invalid pool GUID",
+		override: propMap{"guid": "invalid-guid"},
+		want:     `parsing "pool info guid", unable to convert "invalid-guid" to uint64:.*`,
 	},
 	{
-		name: "Invalid pool Size",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"size": "invalid-size",
-				}),
-		},
-		want: `parsing "pool info size", unable to convert "invalid-size" to uint64:.*`,
+		name:     "Invalid pool Size",
+		override: propMap{"size": "invalid-size"},
+		want:     `parsing "pool info size", unable to convert "invalid-size" to uint64:.*`,
 	},
 	{
-		name: "Invalid pool Allocated",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"allocated": "invalid-allocated",
-				}),
-		},
-		want: `parsing "pool info allocated", unable to convert "invalid-allocated" to uint64:.*`,
+		name:     "Invalid pool Allocated",
+		override: propMap{"allocated": "invalid-allocated"},
+		want:     `parsing "pool info allocated", unable to convert "invalid-allocated" to uint64:.*`,
 	},
 	{
-		name: "Invalid pool Free",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"free": "invalid-free",
-				}),
-		},
-		want: `parsing "pool info free", unable to convert "invalid-free" to uint64:.*`,
+		name:     "Invalid pool Free",
+		override: propMap{"free": "invalid-free"},
+		want:     `parsing "pool info free", unable to convert "invalid-free" to uint64:.*`,
 	},
 	{
-		name: "Invalid pool Fragmentation",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"fragmentation": "invalid-fragmentation",
-				}),
-		},
-		want: `parsing "pool info fragmentation", unable to convert "invalid-fragmentation" to uint8:.*`,
+		name:     "Invalid pool Fragmentation",
+		override: propMap{"fragmentation": "invalid-fragmentation"},
+		want:     `parsing "pool info fragmentation", unable to convert "invalid-fragmentation" to uint8:.*`,
 	},
 	{
-		name: "Invalid pool Health",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"health": "",
-				}),
-		},
-		want: `parsing "pool info health", invalid empty health: ""`,
+		name:     "Invalid pool Health",
+		override: propMap{"health": ""},
+		want:     `parsing "pool info health", invalid empty health: ""`,
 	},
 	{
-		name: "Invalid pool AltRoot",
-		pools: fakeZpools{
-			"TestPool": fakeZpoolWithPropertyOverride(
-				propMap{
-					"altroot": "\r",
-				}),
-		},
-		want: `parsing "pool info altroot", invalid empty altroot: ""`,
+		name:     "Invalid pool AltRoot",
+		override: propMap{"altroot": "\r"},
+		want:     `parsing "pool info altroot", invalid empty altroot: ""`,
 	},
 }
 
@@ -287,7 +176,9 @@ func TestListPoolsColParsingErrors(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			system := newFakeSystem(tc.pools)
+			system := newStubSystem(stubExecutor{
+				testZpoolListCmd: {Stdout: testPoolInfo("TestPool", tc.override)},
+			})
 
 			_, gotErr := system.ListPools()
 			if gotErr == nil {
@@ -315,32 +206,28 @@ func TestListPoolsColParsingErrors(t *testing.T) {
 }
 
 var listPoolsZpoolListErrorTests = []struct {
-	name         string
-	listOverride func(cols []string) (string, error)
-	want         string
+	name      string
+	zpoolList *RecordedCommand
+	want      string
 }{
 	{
-		name: "Invalid Column Count One Row",
-		listOverride: func(cols []string) (string, error) {
-			return "foo\tbar\tbaz\n", nil
-		},
-		want: `expected 8 columns per line in pool info, but found 3, line: "foo\\tbar\\tbaz"`,
+		name:      "Invalid Column Count One Row",
+		zpoolList: &RecordedCommand{Stdout: "foo\tbar\tbaz\n"},
+		want:      `expected 8 columns per line in pool info, but found 3, line: "foo\\tbar\\tbaz"`,
 	},
 	{
 		name: "Invalid Column Count Multiple rows",
-		listOverride: func(cols []string) (string, error) {
-			return "p1\t1\t2\t3\t4\t5\tONLINE\talt1\n" +
+		zpoolList: &RecordedCommand{
+			Stdout: "p1\t1\t2\t3\t4\t5\tONLINE\talt1\n" +
 				"f1\tf2\tf3\tf4\n" +
-				"p2\t1\t2\t3\t4\t5\tONLINE\talt2\n", nil
+				"p2\t1\t2\t3\t4\t5\tONLINE\talt2\n",
 		},
 		want: `expected 8 columns per line in pool info, but found 4, line: "f1\\tf2\\tf3\\tf4"`,
 	},
 	{
-		name: "zpool list Command Failed With Error",
-		listOverride: func(cols []string) (string, error) {
-			return "", fmt.Errorf("zpool list command failed")
-		},
-		want: "failed to list pools, reason: zpool list command failed",
+		name:      "zpool list Command Failed With Error",
+		zpoolList: &RecordedCommand{ExitCode: 1, Stderr: "zpool list command failed"},
+		want:      `failed to list pools, reason: .*zpool list command failed`,
 	},
 }
 
@@ -350,9 +237,7 @@ func TestListPoolsZpoolListErrors(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			system := newFakeSystem(nil)
-			zpoolCmd := getFakeZpoolCmd(system)
-			zpoolCmd.setListOverride(tc.listOverride)
+			system := newStubSystem(stubExecutor{testZpoolListCmd: tc.zpoolList})
 
 			_, gotErr := system.ListPools()
 			if gotErr == nil {
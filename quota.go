@@ -0,0 +1,252 @@
+package zfs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// QuotaTypeUser represents a per-user quota.
+	QuotaTypeUser QuotaType = iota
+	// QuotaTypeGroup represents a per-group quota.
+	QuotaTypeGroup
+	// QuotaTypeProject represents a per-project quota.
+	QuotaTypeProject
+)
+
+var (
+	quotaTypeToPropPrefix = map[QuotaType]string{
+		QuotaTypeUser:    "userquota@",
+		QuotaTypeGroup:   "groupquota@",
+		QuotaTypeProject: "projectquota@",
+	}
+	byteSizeUnits = []string{"", "K", "M", "G", "T", "P", "E"}
+)
+
+// QuotaType represents the type of the entity (user, group or project) a
+// quota applies to.
+type QuotaType uint8
+
+// ByteSize represents a size in bytes. A zero size used as a quota or a
+// reservation means none.
+type ByteSize uint64
+
+// String returns the exact representation of the size using the largest of
+// the binary units (K, M, G, T, P, E) of zfs that divides the size evenly,
+// e.g. "10G" or "1536K", which ParseByteSize parses back to the same size.
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0"
+	}
+
+	i := 0
+	div := uint64(1)
+
+	for i < len(byteSizeUnits)-1 && uint64(b)%(div*1024) == 0 {
+		div *= 1024
+		i++
+	}
+
+	return strconv.FormatUint(uint64(b)/div, 10) + byteSizeUnits[i]
+}
+
+// ParseByteSize parses a size which is either an exact number of bytes, or a
+// number (optionally fractional) followed by a binary unit as accepted by
+// zfs (K, M, G, T, P, E, optionally followed by "B" or "iB", case
+// insensitive), e.g. "10G", "1.5TiB" or "512K". The value "none" is parsed
+// as zero.
+func ParseByteSize(str string) (ByteSize, error) {
+	s := strings.ToUpper(strings.TrimSpace(str))
+	if s == "NONE" {
+		return 0, nil
+	}
+
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+
+	mult := uint64(1)
+
+	if len(s) > 0 {
+		last := s[len(s)-1:]
+		for i := 1; i < len(byteSizeUnits); i++ {
+			if last == byteSizeUnits[i] {
+				mult = uint64(1) << (10 * i)
+				s = s[:len(s)-1]
+
+				break
+			}
+		}
+	}
+
+	if s == "" || strings.Trim(s, "0123456789.") != "" {
+		return 0, fmt.Errorf("parsing %q, invalid byte size", str)
+	}
+
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		if n > math.MaxUint64/mult {
+			return 0, fmt.Errorf("parsing %q, byte size overflows uint64", str)
+		}
+
+		return ByteSize(n * mult), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %q, invalid byte size", str)
+	}
+
+	res := f * float64(mult)
+	if res >= math.MaxUint64 {
+		return 0, fmt.Errorf("parsing %q, byte size overflows uint64", str)
+	}
+
+	return ByteSize(res), nil
+}
+
+// Quota returns the quota of the file system, which limits the space used
+// by the file system and its descendants.
+func (f *FileSystem) Quota() (ByteSize, error) {
+	return f.getByteSizeProp("quota")
+}
+
+// SetQuota sets the quota of the file system, zero removes the quota.
+func (f *FileSystem) SetQuota(size ByteSize) error {
+	return f.setByteSizeProp("quota", size)
+}
+
+// RefQuota returns the refquota of the file system, which limits the space
+// used by the file system itself excluding descendants and snapshots.
+func (f *FileSystem) RefQuota() (ByteSize, error) {
+	return f.getByteSizeProp("refquota")
+}
+
+// SetRefQuota sets the refquota of the file system, zero removes the quota.
+func (f *FileSystem) SetRefQuota(size ByteSize) error {
+	return f.setByteSizeProp("refquota", size)
+}
+
+// Reservation returns the space guaranteed to the file system and its
+// descendants.
+func (f *FileSystem) Reservation() (ByteSize, error) {
+	return f.getByteSizeProp("reservation")
+}
+
+// SetReservation sets the reservation of the file system, zero removes the
+// reservation.
+func (f *FileSystem) SetReservation(size ByteSize) error {
+	return f.setByteSizeProp("reservation", size)
+}
+
+// RefReservation returns the space guaranteed to the file system itself
+// excluding descendants and snapshots.
+func (f *FileSystem) RefReservation() (ByteSize, error) {
+	return f.getByteSizeProp("refreservation")
+}
+
+// SetRefReservation sets the refreservation of the file system, zero
+// removes the reservation.
+func (f *FileSystem) SetRefReservation(size ByteSize) error {
+	return f.setByteSizeProp("refreservation", size)
+}
+
+// UserQuota returns the quota of the specified user (name or numeric ID)
+// within the file system.
+func (f *FileSystem) UserQuota(user string) (ByteSize, error) {
+	return f.EntityQuota(QuotaTypeUser, user)
+}
+
+// SetUserQuota sets the quota of the specified user (name or numeric ID)
+// within the file system, zero removes the quota.
+func (f *FileSystem) SetUserQuota(user string, size ByteSize) error {
+	return f.SetEntityQuota(QuotaTypeUser, user, size)
+}
+
+// GroupQuota returns the quota of the specified group (name or numeric ID)
+// within the file system.
+func (f *FileSystem) GroupQuota(group string) (ByteSize, error) {
+	return f.EntityQuota(QuotaTypeGroup, group)
+}
+
+// SetGroupQuota sets the quota of the specified group (name or numeric ID)
+// within the file system, zero removes the quota.
+func (f *FileSystem) SetGroupQuota(group string, size ByteSize) error {
+	return f.SetEntityQuota(QuotaTypeGroup, group, size)
+}
+
+// ProjectQuota returns the quota of the specified numeric project ID within
+// the file system.
+func (f *FileSystem) ProjectQuota(project string) (ByteSize, error) {
+	return f.EntityQuota(QuotaTypeProject, project)
+}
+
+// SetProjectQuota sets the quota of the specified numeric project ID within
+// the file system, zero removes the quota.
+func (f *FileSystem) SetProjectQuota(project string, size ByteSize) error {
+	return f.SetEntityQuota(QuotaTypeProject, project, size)
+}
+
+// EntityQuota returns the quota of the specified user, group or project
+// within the file system.
+func (f *FileSystem) EntityQuota(quotaType QuotaType, id string) (ByteSize, error) {
+	prop, err := entityQuotaProp(quotaType, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return f.getByteSizeProp(prop)
+}
+
+// SetEntityQuota sets the quota of the specified user, group or project
+// within the file system, zero removes the quota.
+func (f *FileSystem) SetEntityQuota(quotaType QuotaType, id string, size ByteSize) error {
+	prop, err := entityQuotaProp(quotaType, id)
+	if err != nil {
+		return err
+	}
+
+	return f.setByteSizeProp(prop, size)
+}
+
+// getByteSizeProp returns the size property of the file system, the unset
+// user, group and project quotas are reported as "none" even when parsable.
+func (f *FileSystem) getByteSizeProp(prop string) (ByteSize, error) {
+	out, err := f.cmd().zfs.list(f.FullName(), false, zfsListFilesystems, []string{prop})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list property %q of file system %q, reason: %w", prop, f, err)
+	}
+
+	str, err := valueFromOnlyRow(out)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse property value %q, reason: %w", out, err)
+	}
+
+	val, err := parseUint64OrNone(str, fmt.Sprintf("property %s", prop))
+	if err != nil {
+		return 0, err
+	}
+
+	return ByteSize(val), nil
+}
+
+func (f *FileSystem) setByteSizeProp(prop string, size ByteSize) error {
+	val := "none"
+	if size != 0 {
+		val = strconv.FormatUint(uint64(size), 10)
+	}
+
+	return f.SetProp(prop, val)
+}
+
+func entityQuotaProp(quotaType QuotaType, id string) (string, error) {
+	prefix, ok := quotaTypeToPropPrefix[quotaType]
+	if !ok {
+		return "", fmt.Errorf("invalid quota type %d", quotaType)
+	}
+
+	if id == "" {
+		return "", fmt.Errorf("user, group or project must be specified for %q", prefix)
+	}
+
+	return prefix + id, nil
+}
@@ -0,0 +1,266 @@
+package zfs
+
+import (
+	"testing"
+)
+
+var parseByteSizeTests = []struct {
+	name string
+	str  string
+	want ByteSize
+}{
+	{name: "None", str: "none", want: 0},
+	{name: "Exact bytes", str: "12345", want: 12345},
+	{name: "Bytes suffix", str: "512B", want: 512},
+	{name: "Kilobytes", str: "4K", want: 4 * 1024},
+	{name: "Gigabytes", str: "10G", want: 10 * 1024 * 1024 * 1024},
+	{name: "Gigabytes lower case", str: "10g", want: 10 * 1024 * 1024 * 1024},
+	{name: "Gigabytes with B suffix", str: "10GB", want: 10 * 1024 * 1024 * 1024},
+	{name: "Tebibytes", str: "2TiB", want: 2 * 1024 * 1024 * 1024 * 1024},
+	{name: "Fractional", str: "1.5M", want: 1536 * 1024},
+	{name: "Exabytes", str: "15E", want: 15 << 60},
+}
+
+func TestParseByteSize(t *testing.T) {
+	for _, test := range parseByteSizeTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := ParseByteSize(tc.str)
+			if nil != gotErr {
+				t.Errorf(
+					"ParseByteSize()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if got != tc.want {
+				t.Errorf(
+					"ParseByteSize()\nTest Case: %q\nFailure: want and got differ\nReason: want=%d got=%d",
+					tc.name, tc.want, got)
+			}
+		})
+	}
+}
+
+var parseByteSizeErrorTests = []struct {
+	name string
+	str  string
+}{
+	{name: "Empty", str: ""},
+	{name: "Unit only", str: "G"},
+	{name: "Unknown unit", str: "10Q"},
+	{name: "Negative", str: "-10G"},
+	{name: "Exponent", str: "1e3"},
+	{name: "Overflow", str: "16E"},
+}
+
+func TestParseByteSizeErrors(t *testing.T) {
+	for _, test := range parseByteSizeErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := ParseByteSize(tc.str)
+			if gotErr == nil {
+				t.Errorf(
+					"ParseByteSize()\nTest Case: %q\nFailure: gotErr == nil\nReason: got = %d",
+					tc.name, got)
+			}
+		})
+	}
+}
+
+var byteSizeStringTests = []struct {
+	name string
+	size ByteSize
+	want string
+}{
+	{name: "Zero", size: 0, want: "0"},
+	{name: "Bytes", size: 1023, want: "1023"},
+	{name: "Exact kilobytes", size: 1024, want: "1K"},
+	{name: "Exact gigabytes", size: 10 * 1024 * 1024 * 1024, want: "10G"},
+	{name: "Fractional megabytes", size: 1536 * 1024, want: "1536K"},
+	{name: "Terabytes plus one byte", size: 1024*1024*1024*1024 + 1, want: "1099511627777"},
+	{name: "Exabytes", size: 15 << 60, want: "15E"},
+}
+
+func TestByteSizeString(t *testing.T) {
+	for _, test := range byteSizeStringTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.size.String()
+			if got != tc.want {
+				t.Errorf(
+					"ByteSize.String()\nTest Case: %q\nFailure: want and got differ\nReason: want=%q got=%q",
+					tc.name, tc.want, got)
+			}
+
+			// The representation is exact, i.e. parses back to the same size.
+			if parsed, err := ParseByteSize(got); err != nil || parsed != tc.size {
+				t.Errorf(
+					"ByteSize.String()\nTest Case: %q\nFailure: %q does not parse back to the size\nReason: parsed=%d err=%v",
+					tc.name, got, parsed, err)
+			}
+		})
+	}
+}
+
+var quotaTests = []struct {
+	name    string
+	get     func(fs *FileSystem) (ByteSize, error)
+	set     func(fs *FileSystem, size ByteSize) error
+	prop    string
+	out     string
+	want    ByteSize
+	size    ByteSize
+	wantSet string
+}{
+	{
+		name:    "Quota",
+		get:     (*FileSystem).Quota,
+		set:     (*FileSystem).SetQuota,
+		prop:    "quota",
+		out:     "10737418240",
+		want:    10 << 30,
+		size:    10 << 30,
+		wantSet: "zfs set quota=10737418240 tank/data",
+	},
+	{
+		name:    "Removed quota",
+		get:     (*FileSystem).Quota,
+		set:     (*FileSystem).SetQuota,
+		prop:    "quota",
+		out:     "0",
+		wantSet: "zfs set quota=none tank/data",
+	},
+	{
+		name:    "RefQuota",
+		get:     (*FileSystem).RefQuota,
+		set:     (*FileSystem).SetRefQuota,
+		prop:    "refquota",
+		out:     "1048576",
+		want:    1 << 20,
+		size:    1 << 20,
+		wantSet: "zfs set refquota=1048576 tank/data",
+	},
+	{
+		name:    "Reservation",
+		get:     (*FileSystem).Reservation,
+		set:     (*FileSystem).SetReservation,
+		prop:    "reservation",
+		out:     "4096",
+		want:    4096,
+		size:    4096,
+		wantSet: "zfs set reservation=4096 tank/data",
+	},
+	{
+		name:    "RefReservation",
+		get:     (*FileSystem).RefReservation,
+		set:     (*FileSystem).SetRefReservation,
+		prop:    "refreservation",
+		out:     "8192",
+		want:    8192,
+		size:    8192,
+		wantSet: "zfs set refreservation=8192 tank/data",
+	},
+	{
+		name:    "UserQuota",
+		get:     func(fs *FileSystem) (ByteSize, error) { return fs.UserQuota("alice") },
+		set:     func(fs *FileSystem, size ByteSize) error { return fs.SetUserQuota("alice", size) },
+		prop:    "userquota@alice",
+		out:     "2147483648",
+		want:    2 << 30,
+		size:    2 << 30,
+		wantSet: "zfs set userquota@alice=2147483648 tank/data",
+	},
+	{
+		name:    "Unset UserQuota",
+		get:     func(fs *FileSystem) (ByteSize, error) { return fs.UserQuota("1001") },
+		set:     func(fs *FileSystem, size ByteSize) error { return fs.SetUserQuota("1001", size) },
+		prop:    "userquota@1001",
+		out:     "none",
+		wantSet: "zfs set userquota@1001=none tank/data",
+	},
+	{
+		name:    "GroupQuota",
+		get:     func(fs *FileSystem) (ByteSize, error) { return fs.GroupQuota("staff") },
+		set:     func(fs *FileSystem, size ByteSize) error { return fs.SetGroupQuota("staff", size) },
+		prop:    "groupquota@staff",
+		out:     "536870912",
+		want:    512 << 20,
+		size:    512 << 20,
+		wantSet: "zfs set groupquota@staff=536870912 tank/data",
+	},
+	{
+		name:    "ProjectQuota",
+		get:     func(fs *FileSystem) (ByteSize, error) { return fs.ProjectQuota("42") },
+		set:     func(fs *FileSystem, size ByteSize) error { return fs.SetProjectQuota("42", size) },
+		prop:    "projectquota@42",
+		out:     "1099511627776",
+		want:    1 << 40,
+		size:    1 << 40,
+		wantSet: "zfs set projectquota@42=1099511627776 tank/data",
+	},
+}
+
+func TestQuotas(t *testing.T) {
+	for _, test := range quotaTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, rec := newRecordedFileSystem("data", stubExecutor{
+				"zfs list -H -p -t filesystem -o " + tc.prop + " tank/data": {Stdout: tc.out + "\n"},
+			})
+
+			got, gotErr := tc.get(fs)
+			if gotErr != nil {
+				t.Errorf(
+					"FileSystem.%s()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, tc.name, gotErr)
+				return
+			}
+
+			if got != tc.want {
+				t.Errorf(
+					"FileSystem.%s()\nTest Case: %q\nFailure: want and got differ\nReason: want=%d got=%d",
+					tc.name, tc.name, tc.want, got)
+			}
+
+			if gotErr := tc.set(fs, tc.size); gotErr != nil {
+				t.Errorf(
+					"FileSystem.Set%s()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, tc.name, gotErr)
+				return
+			}
+
+			if last := rec.commands[len(rec.commands)-1]; last != tc.wantSet {
+				t.Errorf(
+					"FileSystem.Set%s()\nTest Case: %q\nFailure: want and got command differ\nReason: want=%q got=%q",
+					tc.name, tc.name, tc.wantSet, last)
+			}
+		})
+	}
+}
+
+func TestEntityQuotaErrors(t *testing.T) {
+	t.Parallel()
+
+	fs, rec := newRecordedFileSystem("data", nil)
+
+	if _, gotErr := fs.EntityQuota(QuotaType(7), "alice"); gotErr == nil {
+		t.Errorf("EntityQuota()\nTest Case: %q\nFailure: gotErr == nil\nReason: invalid quota type", t.Name())
+	}
+
+	if gotErr := fs.SetUserQuota("", 1024); gotErr == nil {
+		t.Errorf("SetUserQuota()\nTest Case: %q\nFailure: gotErr == nil\nReason: empty user", t.Name())
+	}
+
+	if len(rec.commands) != 0 {
+		t.Errorf("EntityQuota()\nTest Case: %q\nFailure: unexpected commands %q", t.Name(), rec.commands)
+	}
+}
@@ -0,0 +1,297 @@
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Recording represents the zfs and zpool commands recorded by a
+// RecordingExecutor, which can be saved as a fixture file and replayed
+// using a ReplayExecutor.
+type Recording struct {
+	// Recorded commands in the order they were run.
+	Commands []*RecordedCommand `json:"commands"`
+}
+
+// RecordedCommand represents a single recorded invocation of a zfs or
+// zpool command. The standard input is never recorded, since it may
+// include key material.
+type RecordedCommand struct {
+	// Command line, i.e. the name of the command followed by the arguments.
+	Args []string `json:"args"`
+	// Standard output of the command.
+	Stdout string `json:"stdout"`
+	// Standard error output of the command.
+	Stderr string `json:"stderr,omitempty"`
+	// Exit status of the command.
+	ExitCode int `json:"exit_code"`
+	// True if the command was started using Executor.Stream.
+	Stream bool `json:"stream,omitempty"`
+}
+
+// String returns the string representation of the recorded command.
+func (c *RecordedCommand) String() string {
+	return fmt.Sprintf("{RecordedCommand Args: %q, ExitCode: %d}", c.Args, c.ExitCode)
+}
+
+// err returns the error returned by the executor for the recorded command.
+func (c *RecordedCommand) err() error {
+	if c.ExitCode == 0 {
+		return nil
+	}
+
+	return &ExitError{ExitCode: c.ExitCode, Stderr: c.Stderr}
+}
+
+// LoadRecording loads the recording from the fixture file.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording %q, reason: %w", path, err)
+	}
+
+	var result Recording
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse recording %q, reason: %w", path, err)
+	}
+
+	return &result, nil
+}
+
+// Save saves the recording to the fixture file.
+func (r *Recording) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording, reason: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write recording %q, reason: %w", path, err)
+	}
+
+	return nil
+}
+
+// RecordingExecutor is an Executor that runs the commands using another
+// executor, and records every command along with its output to a fixture
+// file, which is rewritten after each command so that the recording
+// survives an interrupted run.
+type RecordingExecutor struct {
+	executor  Executor
+	path      string
+	mu        sync.Mutex
+	recording Recording
+}
+
+// NewRecordingExecutor returns a new RecordingExecutor that runs the
+// commands using the specified executor (or on the local system if nil),
+// and records them to the fixture file at path.
+func NewRecordingExecutor(executor Executor, path string) *RecordingExecutor {
+	if executor == nil {
+		executor = &ExecExecutor{}
+	}
+
+	return &RecordingExecutor{executor: executor, path: path}
+}
+
+// Run runs and records the command.
+func (r *RecordingExecutor) Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error) {
+	out, err := r.executor.Run(ctx, stdin, name, args...)
+
+	if recErr := r.record(name, args, out, err, false); recErr != nil {
+		return "", recErr
+	}
+
+	return out, err
+}
+
+// Stream starts the command, which is recorded once the stream is closed.
+func (r *RecordingExecutor) Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	stream, err := r.executor.Stream(ctx, name, args...)
+	if err != nil {
+		if recErr := r.record(name, args, "", err, true); recErr != nil {
+			return nil, recErr
+		}
+
+		return nil, err
+	}
+
+	result := &recordingStream{ReadCloser: stream, recorder: r, name: name, args: args}
+	result.reader = io.TeeReader(stream, &result.stdout)
+
+	return result, nil
+}
+
+// Recording returns a copy of the commands recorded so far.
+func (r *RecordingExecutor) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Recording{Commands: append([]*RecordedCommand{}, r.recording.Commands...)}
+}
+
+// record records the outcome of the command and saves the recording.
+// Errors other than an *ExitError (e.g. the command could not be started)
+// are not recorded.
+func (r *RecordingExecutor) record(name string, args []string, out string, err error, stream bool) error {
+	c := &RecordedCommand{
+		Args:   append([]string{name}, args...),
+		Stdout: out,
+		Stream: stream,
+	}
+
+	if err != nil {
+		var ee *ExitError
+		if !errors.As(err, &ee) {
+			return nil
+		}
+
+		c.ExitCode = ee.ExitCode
+		c.Stderr = ee.Stderr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recording.Commands = append(r.recording.Commands, c)
+
+	return r.recording.Save(r.path)
+}
+
+type recordingStream struct {
+	io.ReadCloser
+	recorder *RecordingExecutor
+	name     string
+	args     []string
+	reader   io.Reader
+	stdout   bytes.Buffer
+}
+
+func (s *recordingStream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+func (s *recordingStream) Close() error {
+	err := s.ReadCloser.Close()
+
+	if recErr := s.recorder.record(s.name, s.args, s.stdout.String(), err, true); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+// ReplayExecutor is an Executor that serves the responses of the commands
+// from a recording without running any commands, and fails the commands
+// that are not part of the recording. The recorded responses of identical
+// commands are served in the order they were recorded, and the last
+// response is served again once they are exhausted (e.g. for polling).
+type ReplayExecutor struct {
+	mu       sync.Mutex
+	commands []*RecordedCommand
+	served   []bool
+}
+
+// NewReplayExecutor returns a new ReplayExecutor serving the responses
+// from the recording.
+func NewReplayExecutor(recording *Recording) *ReplayExecutor {
+	return &ReplayExecutor{
+		commands: recording.Commands,
+		served:   make([]bool, len(recording.Commands)),
+	}
+}
+
+// Run serves the recorded response of the command, ignoring stdin.
+func (r *ReplayExecutor) Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error) {
+	c, err := r.next(name, args, false)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Stdout, c.err()
+}
+
+// Stream serves the recorded output of the command as a stream, closing
+// the stream returns the recorded exit status.
+func (r *ReplayExecutor) Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	c, err := r.next(name, args, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &replayStream{Reader: strings.NewReader(c.Stdout), err: c.err()}, nil
+}
+
+// Unserved returns the recorded commands that were not served so far.
+func (r *ReplayExecutor) Unserved() []*RecordedCommand {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*RecordedCommand
+
+	for i, c := range r.commands {
+		if !r.served[i] {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// next returns the recorded response for the command.
+func (r *ReplayExecutor) next(name string, args []string, stream bool) (*RecordedCommand, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmdLine := append([]string{name}, args...)
+	last := -1
+
+	for i, c := range r.commands {
+		if c.Stream != stream || !argsEqual(c.Args, cmdLine) {
+			continue
+		}
+
+		if !r.served[i] {
+			r.served[i] = true
+			return c, nil
+		}
+
+		last = i
+	}
+
+	if last < 0 {
+		return nil, fmt.Errorf("unexpected command %s %q, not found in the recording", name, args)
+	}
+
+	return r.commands[last], nil
+}
+
+func argsEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type replayStream struct {
+	io.Reader
+	err error
+}
+
+func (s *replayStream) Close() error {
+	return s.err
+}
@@ -0,0 +1,258 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const (
+	testRecordingPools = "testdata/recordings/pools.json"
+)
+
+func newReplaySystem(t *testing.T, path string) (*System, *ReplayExecutor) {
+	t.Helper()
+
+	recording, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", path, err)
+	}
+
+	executor := NewReplayExecutor(recording)
+
+	return NewSystem(&SystemConfig{Executor: executor}), executor
+}
+
+func TestReplayParsing(t *testing.T) {
+	t.Parallel()
+
+	system, executor := newReplaySystem(t, testRecordingPools)
+
+	pools, err := system.ListPools()
+	if err != nil {
+		t.Fatalf("ListPools()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", testRecordingPools, err)
+	}
+
+	wantPools := PoolList{
+		&Pool{
+			Name:                 "backup",
+			GUID:                 4913474432218137203,
+			Size:                 3985729650688,
+			Allocated:            1395718488064,
+			Free:                 2590011162624,
+			FragmentationPercent: 12,
+			HealthStatus:         "DEGRADED",
+			AltRoot:              "/mnt/backup",
+		},
+		&Pool{
+			Name:                 "tank",
+			GUID:                 11806393938123466592,
+			Size:                 1992864825344,
+			Allocated:            502813016064,
+			Free:                 1490051809280,
+			FragmentationPercent: 3,
+			HealthStatus:         "ONLINE",
+			AltRoot:              "-",
+		},
+	}
+	updateExpectedPoolsWithSystem(wantPools, system)
+
+	if matchErr := poolListsEqual(wantPools, pools); matchErr != nil {
+		t.Fatalf("ListPools()\nTest Case: %q\nFailure: want and got differ\nReason: %s", testRecordingPools, matchErr)
+	}
+
+	tank := pools[1]
+
+	fsList, err := tank.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", testRecordingPools, err)
+	}
+
+	wantFsList := FileSystemList{
+		{Name: "tank", IsRoot: true, Pool: tank, GUID: 6321581830434387226, Creation: time.Unix(1697357281, 0)},
+		{Name: "home", Pool: tank, GUID: 2406237466416624013, Creation: time.Unix(1697357390, 0)},
+		{Name: "home/alice", Pool: tank, GUID: 15632940478624371130, Creation: time.Unix(1697357412, 0)},
+	}
+
+	ignore := cmpopts.IgnoreFields(Pool{}, "System")
+
+	if diff := cmp.Diff(wantFsList, fsList, ignore); diff != "" {
+		t.Fatalf("FileSystems()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", testRecordingPools, diff)
+	}
+
+	home := fsList[1]
+
+	snapshots, err := home.Snapshots()
+	if err != nil {
+		t.Fatalf("Snapshots()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", testRecordingPools, err)
+	}
+
+	wantSnapshots := SnapshotList{
+		{Name: "2026-10-17", FileSystem: home, GUID: 9204758283562018291, Creation: time.Unix(1792224000, 0)},
+		{Name: "2026-10-18", FileSystem: home, GUID: 10358722649183749931, Creation: time.Unix(1792310400, 0)},
+	}
+
+	if diff := cmp.Diff(wantSnapshots, snapshots, ignore); diff != "" {
+		t.Fatalf("Snapshots()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", testRecordingPools, diff)
+	}
+
+	wantHolds := []HoldList{
+		{
+			{Tag: "backup", Creation: time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), Snapshot: snapshots[0]},
+			{Tag: "keep", Creation: time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local), Snapshot: snapshots[0]},
+		},
+		nil,
+	}
+
+	for i, s := range snapshots {
+		holds, err := s.Holds()
+		if err != nil {
+			t.Fatalf("Holds()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", s.FullName(), err)
+		}
+
+		if diff := cmp.Diff(wantHolds[i], holds, ignore); diff != "" {
+			t.Errorf("Holds()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", s.FullName(), diff)
+		}
+	}
+
+	missing := &Snapshot{Name: "missing", FileSystem: home}
+
+	_, err = missing.Holds()
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 1 || !strings.Contains(exitErr.Stderr, "dataset does not exist") {
+		t.Errorf("Holds()\nTest Case: %q\nFailure: want the recorded exit error\nGot: %v", missing.FullName(), err)
+	}
+
+	if unserved := executor.Unserved(); len(unserved) != 0 {
+		t.Errorf("Unserved()\nTest Case: %q\nFailure: recorded commands were not served\nGot: %v", testRecordingPools, unserved)
+	}
+}
+
+func TestReplayUnexpectedCommand(t *testing.T) {
+	t.Parallel()
+
+	system, _ := newReplaySystem(t, testRecordingPools)
+
+	_, err := system.cmd.zpool.status("tank")
+	if err == nil || !strings.Contains(err.Error(), "not found in the recording") {
+		t.Errorf("status()\nTest Case: %q\nFailure: want an unexpected command error\nGot: %v", "zpool status", err)
+	}
+}
+
+// stubExecutor serves fixed responses by the command line.
+type stubExecutor map[string]*RecordedCommand
+
+func (s stubExecutor) Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error) {
+	c, ok := s[name+" "+strings.Join(args, " ")]
+	if !ok {
+		return "", errors.New("command not found")
+	}
+
+	return c.Stdout, c.err()
+}
+
+func (s stubExecutor) Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	out, err := s.Run(ctx, nil, name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(strings.NewReader(out)), nil
+}
+
+func TestRecordingExecutor(t *testing.T) {
+	t.Parallel()
+
+	stub := stubExecutor{
+		"zpool list -H -o name": {Stdout: "tank\n"},
+		"zfs get -H -o value compression tank/missing": {
+			ExitCode: 1,
+			Stderr:   "cannot open 'tank/missing': dataset does not exist\n",
+		},
+		"zpool events -H -v -f": {Stdout: "Oct 18 2026 10:00:00.000000000\tsysevent.fs.zfs.config_sync\n"},
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	recorder := NewRecordingExecutor(stub, path)
+	ctx := context.Background()
+
+	_, _ = recorder.Run(ctx, nil, "zpool", "list", "-H", "-o", "name")
+	_, _ = recorder.Run(ctx, nil, "zfs", "get", "-H", "-o", "value", "compression", "tank/missing")
+	_, _ = recorder.Run(ctx, nil, "zfs", "unknown")
+
+	stream, err := recorder.Stream(ctx, "zpool", "events", "-H", "-v", "-f")
+	if err != nil {
+		t.Fatalf("Stream()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "zpool events", err)
+	}
+
+	_, _ = io.ReadAll(stream)
+	stream.Close()
+
+	recording, err := LoadRecording(path)
+	if err != nil {
+		t.Fatalf("LoadRecording()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", path, err)
+	}
+
+	want := &Recording{
+		Commands: []*RecordedCommand{
+			{Args: []string{"zpool", "list", "-H", "-o", "name"}, Stdout: "tank\n"},
+			{
+				Args:     []string{"zfs", "get", "-H", "-o", "value", "compression", "tank/missing"},
+				Stderr:   "cannot open 'tank/missing': dataset does not exist\n",
+				ExitCode: 1,
+			},
+			{
+				Args:   []string{"zpool", "events", "-H", "-v", "-f"},
+				Stdout: "Oct 18 2026 10:00:00.000000000\tsysevent.fs.zfs.config_sync\n",
+				Stream: true,
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, recording); diff != "" {
+		t.Fatalf("LoadRecording()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", path, diff)
+	}
+
+	if diff := cmp.Diff(want, recorder.Recording()); diff != "" {
+		t.Errorf("Recording()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", path, diff)
+	}
+
+	replay := NewReplayExecutor(recording)
+
+	for i := 0; i < 2; i++ {
+		// The last response is served again once exhausted.
+		out, err := replay.Run(ctx, nil, "zpool", "list", "-H", "-o", "name")
+		if err != nil || out != "tank\n" {
+			t.Errorf("Run()\nTest Case: %q\nFailure: want the recorded output\nGot: %q, %v", "zpool list", out, err)
+		}
+	}
+
+	_, err = replay.Run(ctx, nil, "zfs", "get", "-H", "-o", "value", "compression", "tank/missing")
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 1 {
+		t.Errorf("Run()\nTest Case: %q\nFailure: want the recorded exit error\nGot: %v", "zfs get", err)
+	}
+
+	if _, err := replay.Run(ctx, nil, "zpool", "events", "-H", "-v", "-f"); err == nil {
+		t.Errorf("Run()\nTest Case: %q\nFailure: streamed command must not be served by Run\nGot: nil error", "zpool events")
+	}
+
+	stream, err = replay.Stream(ctx, "zpool", "events", "-H", "-v", "-f")
+	if err != nil {
+		t.Fatalf("Stream()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "zpool events", err)
+	}
+
+	out, _ := io.ReadAll(stream)
+	if string(out) != want.Commands[2].Stdout || stream.Close() != nil {
+		t.Errorf("Stream()\nTest Case: %q\nFailure: want the recorded output\nGot: %q", "zpool events", out)
+	}
+}
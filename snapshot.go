@@ -100,8 +100,8 @@ func listSnapshots(fs *FileSystem) (SnapshotList, error) {
 
 	var result SnapshotList
 
-	for _, line := range splitOnNewLine(out) {
-		s, err := parseSnapshotInfo(fs, line)
+	for _, row := range out {
+		s, err := parseSnapshotInfo(fs, row)
 		if err != nil {
 			return nil, err
 		}
@@ -112,10 +112,50 @@ func listSnapshots(fs *FileSystem) (SnapshotList, error) {
 	return result, nil
 }
 
-func parseSnapshotInfo(fs *FileSystem, line string) (*Snapshot, error) {
-	cols := strings.Split(line, "\t")
+// Snapshots returns the snapshots of all the file systems within the pool,
+// the snapshots of all the file systems are listed using a single recursive
+// listing.
+func (p *Pool) Snapshots() (SnapshotList, error) {
+	fsList, err := p.FileSystems()
+	if err != nil {
+		return nil, err
+	}
+
+	fsByName := make(map[string]*FileSystem, len(fsList))
+	for _, fs := range fsList {
+		fsByName[fs.FullName()] = fs
+	}
+
+	out, err := p.cmd().zfs.list(p.Name, true, zfsListSnapshots, listSnapshotsOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots of %q, reason: %w", p, err)
+	}
+
+	var result SnapshotList
+
+	for _, row := range out {
+		fsName := strings.SplitN(row[0], "@", 2)[0]
+
+		fs, ok := fsByName[fsName]
+		if !ok {
+			return nil, fmt.Errorf("snapshot of unknown file system %q, line: %q", fsName, strings.Join(row, "\t"))
+		}
+
+		s, err := parseSnapshotInfo(fs, row)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
+
+func parseSnapshotInfo(fs *FileSystem, cols []string) (*Snapshot, error) {
 	if len(cols) != 3 {
-		return nil, fmt.Errorf("expected 3 columns per line in snapshot info, but found %d, line: %q", len(cols), line)
+		return nil, fmt.Errorf(
+			"expected 3 columns per line in snapshot info, but found %d, line: %q", len(cols), strings.Join(cols, "\t"))
 	}
 
 	name := cols[0]
@@ -214,3 +254,75 @@ func listRecursiveSnapshotGroups(pool *Pool) (RecursiveSnapshotGroupList, error)
 
 	return result, nil
 }
+
+// Clone creates a new file system from the snapshot with the specified
+// target name (relative to the pool) and properties, and returns the newly
+// created file system.
+func (s *Snapshot) Clone(target string, props map[string]string) (*FileSystem, error) {
+	pool := s.FileSystem.Pool
+	fullTarget := fmt.Sprintf("%s/%s", pool.Name, target)
+
+	_, err := s.cmd().zfs.clone(s.FullName(), fullTarget, props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone snapshot %q to %q, reason: %w", s, fullTarget, err)
+	}
+
+	return fileSystemByFullName(pool, fullTarget)
+}
+
+// Clones returns the list of file systems cloned from the snapshot.
+func (s *Snapshot) Clones() (FileSystemList, error) {
+	out, err := s.cmd().zfs.get(s.FullName(), []string{"clones"}, getFsOrSnapPropOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clones of snapshot %q, reason: %w", s, err)
+	}
+
+	// A snapshot without any clones has an empty clones property.
+	if len(out) > 1 {
+		return nil, fmt.Errorf("expected at most one line of clones output, but found %d lines, lines = %q", len(out), out)
+	}
+
+	if len(out) == 0 || out[0][0] == "" || out[0][0] == "-" {
+		return nil, nil
+	}
+
+	var result FileSystemList
+
+	for _, name := range strings.Split(out[0][0], ",") {
+		fs, err := fileSystemByFullName(s.FileSystem.Pool, name)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, fs)
+	}
+
+	return result, nil
+}
+
+// snapshotByFullName looks up the snapshot with the specified full name
+// (i.e. <pool>/<file system>@<snapshot>) within the pool.
+func snapshotByFullName(pool *Pool, fullName string) (*Snapshot, error) {
+	fsName, _, found := strings.Cut(fullName, "@")
+	if !found {
+		return nil, fmt.Errorf("invalid snapshot name %q, missing '@'", fullName)
+	}
+
+	fs, err := fileSystemByFullName(pool, fsName)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := pool.cmd().zfs.list(
+		fullName, false, zfsListSnapshots, listSnapshotsOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot %q, reason: %w", fullName, err)
+	}
+
+	row, err := onlyRow(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot info %q, reason: %w", out, err)
+	}
+
+	return parseSnapshotInfo(fs, row)
+}
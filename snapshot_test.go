@@ -0,0 +1,152 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const (
+	testListCloneCmd = "zfs list -H -p -t filesystem -o name,guid,creation tank/clone"
+)
+
+var cmpIgnorePool = cmpopts.IgnoreFields(FileSystem{}, "Pool")
+
+var cloneTests = []struct {
+	name   string
+	target string
+	props  map[string]string
+	stub   stubExecutor
+	want   []string
+	wantFs *FileSystem
+}{
+	{
+		name:   "No properties",
+		target: "clone",
+		stub:   stubExecutor{testListCloneCmd: {Stdout: "tank/clone\t1001\t1700000000\n"}},
+		want: []string{
+			"zfs clone tank/data@daily tank/clone",
+			testListCloneCmd,
+		},
+		wantFs: &FileSystem{Name: "clone", GUID: 1001, Creation: time.Unix(1700000000, 0)},
+	},
+	{
+		name:   "With properties",
+		target: "clone",
+		props:  map[string]string{"mountpoint": "/mnt/clone", "compression": "zstd"},
+		stub:   stubExecutor{testListCloneCmd: {Stdout: "tank/clone\t1001\t1700000000\n"}},
+		want: []string{
+			"zfs clone -o compression=zstd -o mountpoint=/mnt/clone tank/data@daily tank/clone",
+			testListCloneCmd,
+		},
+		wantFs: &FileSystem{Name: "clone", GUID: 1001, Creation: time.Unix(1700000000, 0)},
+	},
+	{
+		name:   "Clone failed",
+		target: "clone",
+		stub: stubExecutor{
+			"zfs clone tank/data@daily tank/clone": {ExitCode: 1, Stderr: "cannot create 'tank/clone': dataset already exists"},
+		},
+		want: []string{"zfs clone tank/data@daily tank/clone"},
+	},
+}
+
+func TestSnapshotClone(t *testing.T) {
+	for _, test := range cloneTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, rec := newRecordedFileSystem("data", tc.stub)
+			snap := &Snapshot{Name: "daily", FileSystem: fs}
+
+			got, gotErr := snap.Clone(tc.target, tc.props)
+			if gotErr != nil != (tc.wantFs == nil) {
+				t.Errorf(
+					"Snapshot.Clone()\nTest Case: %q\nFailure: unexpected error\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, rec.commands); diff != "" {
+				t.Errorf(
+					"Snapshot.Clone()\nTest Case: %q\nFailure: want and got commands differ\nReason:\n%s",
+					tc.name, diff)
+			}
+
+			if diff := cmp.Diff(tc.wantFs, got, cmpIgnorePool); diff != "" {
+				t.Errorf(
+					"Snapshot.Clone()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var clonesTests = []struct {
+	name    string
+	stub    stubExecutor
+	want    []string
+	wantErr bool
+}{
+	{
+		name: "No clones",
+		stub: stubExecutor{"zfs get -H -o value clones tank/data@daily": {Stdout: "\n"}},
+	},
+	{
+		name: "No clones reported as dash",
+		stub: stubExecutor{"zfs get -H -o value clones tank/data@daily": {Stdout: "-\n"}},
+	},
+	{
+		name: "Two clones",
+		stub: stubExecutor{
+			"zfs get -H -o value clones tank/data@daily": {Stdout: "tank/clone,tank/other\n"},
+			testListCloneCmd: {Stdout: "tank/clone\t1001\t1700000000\n"},
+			"zfs list -H -p -t filesystem -o name,guid,creation tank/other": {Stdout: "tank/other\t1002\t1700000100\n"},
+		},
+		want: []string{"clone", "other"},
+	},
+	{
+		name:    "Multiple lines",
+		stub:    stubExecutor{"zfs get -H -o value clones tank/data@daily": {Stdout: "tank/clone\ntank/other\n"}},
+		wantErr: true,
+	},
+	{
+		name:    "Get failed",
+		stub:    stubExecutor{"zfs get -H -o value clones tank/data@daily": {ExitCode: 1, Stderr: "dataset does not exist"}},
+		wantErr: true,
+	},
+}
+
+func TestSnapshotClones(t *testing.T) {
+	for _, test := range clonesTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs, _ := newRecordedFileSystem("data", tc.stub)
+			snap := &Snapshot{Name: "daily", FileSystem: fs}
+
+			got, gotErr := snap.Clones()
+			if gotErr != nil != tc.wantErr {
+				t.Errorf(
+					"Snapshot.Clones()\nTest Case: %q\nFailure: unexpected error\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			var gotNames []string
+			for _, c := range got {
+				gotNames = append(gotNames, c.Name)
+			}
+
+			if diff := cmp.Diff(tc.want, gotNames); diff != "" {
+				t.Errorf(
+					"Snapshot.Clones()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
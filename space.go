@@ -0,0 +1,157 @@
+package zfs
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	fileSystemSpaceUsageOutputCols = []string{
+		"name",
+		"available",
+		"used",
+		"usedbysnapshots",
+		"usedbydataset",
+		"usedbyrefreservation",
+		"usedbychildren",
+		"referenced",
+		"logicalused",
+		"compressratio",
+	}
+)
+
+// SpaceUsage represents the space accounting breakdown of a file system.
+type SpaceUsage struct {
+	// Number of bytes available to the file system and its children.
+	Available uint64
+	// Total number of bytes consumed by the file system and its descendants.
+	Used uint64
+	// Number of bytes consumed by snapshots of the file system.
+	UsedBySnapshots uint64
+	// Number of bytes consumed by the file system itself.
+	UsedByDataset uint64
+	// Number of bytes consumed by the refreservation of the file system.
+	UsedByRefReservation uint64
+	// Number of bytes consumed by the children of the file system.
+	UsedByChildren uint64
+	// Number of bytes accessible by the file system.
+	Referenced uint64
+	// Number of bytes consumed by the file system and its descendants
+	// before compression.
+	LogicalUsed uint64
+	// Compression ratio achieved for the used space.
+	CompressRatio float64
+}
+
+// String returns the string representation of the space usage.
+func (s *SpaceUsage) String() string {
+	return fmt.Sprintf(
+		"{SpaceUsage Available: %d, Used: %d, UsedBySnapshots: %d, UsedByDataset: %d, "+
+			"UsedByRefReservation: %d, UsedByChildren: %d, Referenced: %d, LogicalUsed: %d, "+
+			"CompressRatio: %.2f}",
+		s.Available,
+		s.Used,
+		s.UsedBySnapshots,
+		s.UsedByDataset,
+		s.UsedByRefReservation,
+		s.UsedByChildren,
+		s.Referenced,
+		s.LogicalUsed,
+		s.CompressRatio,
+	)
+}
+
+// SpaceUsage returns the space accounting breakdown for the file system.
+func (f *FileSystem) SpaceUsage() (*SpaceUsage, error) {
+	out, err := f.cmd().zfs.list(
+		f.FullName(), false, zfsListFilesystems, fileSystemSpaceUsageOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list space usage of file system %q, reason: %w", f, err)
+	}
+
+	row, err := onlyRow(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse space usage %q, reason: %w", out, err)
+	}
+
+	return parseSpaceUsage(row)
+}
+
+// SpaceUsage returns the space accounting breakdown of all the file systems
+// within the pool using a single recursive listing, keyed by the full name
+// of the file system.
+func (p *Pool) SpaceUsage() (map[string]*SpaceUsage, error) {
+	out, err := p.cmd().zfs.list(p.Name, true, zfsListFilesystems, fileSystemSpaceUsageOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list space usage of %q, reason: %w", p, err)
+	}
+
+	result := make(map[string]*SpaceUsage)
+
+	for _, row := range out {
+		space, err := parseSpaceUsage(row)
+		if err != nil {
+			return nil, err
+		}
+
+		result[row[0]] = space
+	}
+
+	return result, nil
+}
+
+// Used returns the number of bytes consumed exclusively by the snapshot.
+func (s *Snapshot) Used() (uint64, error) {
+	return getUint64PropForFsOrSnap(s.FileSystem.Pool, s.FullName(), zfsListSnapshots, "used")
+}
+
+// Referenced returns the number of bytes accessible by the snapshot.
+func (s *Snapshot) Referenced() (uint64, error) {
+	return getUint64PropForFsOrSnap(s.FileSystem.Pool, s.FullName(), zfsListSnapshots, "referenced")
+}
+
+// Written returns the number of bytes written to the file system between
+// the previous snapshot and this snapshot.
+func (s *Snapshot) Written() (uint64, error) {
+	return getUint64PropForFsOrSnap(s.FileSystem.Pool, s.FullName(), zfsListSnapshots, "written")
+}
+
+func parseSpaceUsage(cols []string) (*SpaceUsage, error) {
+	if len(cols) != 10 {
+		return nil, fmt.Errorf(
+			"expected 10 columns per line in space usage, but found %d, line: %q", len(cols), strings.Join(cols, "\t"))
+	}
+
+	result := &SpaceUsage{}
+	fields := []struct {
+		dst  *uint64
+		desc string
+	}{
+		{&result.Available, "space usage avail"},
+		{&result.Used, "space usage used"},
+		{&result.UsedBySnapshots, "space usage usedbysnapshots"},
+		{&result.UsedByDataset, "space usage usedbydataset"},
+		{&result.UsedByRefReservation, "space usage usedbyrefreservation"},
+		{&result.UsedByChildren, "space usage usedbychildren"},
+		{&result.Referenced, "space usage referenced"},
+		{&result.LogicalUsed, "space usage logicalused"},
+	}
+
+	for i, f := range fields {
+		val, err := parseUint64(cols[i+1], f.desc)
+		if err != nil {
+			return nil, err
+		}
+
+		*f.dst = val
+	}
+
+	ratio, err := parseFloat64(strings.TrimSuffix(cols[9], "x"), "space usage compressratio")
+	if err != nil {
+		return nil, err
+	}
+
+	result.CompressRatio = ratio
+
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package zfs
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseSpaceUsageTests = []struct {
+	name string
+	line string
+	want *SpaceUsage
+}{
+	{
+		name: "Parsable compress ratio",
+		line: "tank/data\t1000\t900\t100\t700\t0\t100\t700\t1350\t1.50",
+		want: &SpaceUsage{
+			Available:            1000,
+			Used:                 900,
+			UsedBySnapshots:      100,
+			UsedByDataset:        700,
+			UsedByRefReservation: 0,
+			UsedByChildren:       100,
+			Referenced:           700,
+			LogicalUsed:          1350,
+			CompressRatio:        1.5,
+		},
+	},
+	{
+		name: "Compress ratio with suffix",
+		line: "tank\t5\t4\t3\t2\t1\t0\t2\t4\t1.00x",
+		want: &SpaceUsage{
+			Available:            5,
+			Used:                 4,
+			UsedBySnapshots:      3,
+			UsedByDataset:        2,
+			UsedByRefReservation: 1,
+			UsedByChildren:       0,
+			Referenced:           2,
+			LogicalUsed:          4,
+			CompressRatio:        1,
+		},
+	},
+}
+
+func TestParseSpaceUsage(t *testing.T) {
+	for _, test := range parseSpaceUsageTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := parseSpaceUsage(strings.Split(tc.line, "\t"))
+			if nil != gotErr {
+				t.Errorf(
+					"parseSpaceUsage()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"parseSpaceUsage()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var parseSpaceUsageErrorTests = []struct {
+	name string
+	line string
+	want string
+}{
+	{
+		name: "Invalid column count",
+		line: "tank\t1\t2",
+		want: `expected 10 columns per line in space usage, but found 3, line: .*`,
+	},
+	{
+		name: "Invalid used by snapshots",
+		line: "tank\t5\t4\tfoo\t2\t1\t0\t2\t4\t1.00",
+		want: `parsing "space usage usedbysnapshots", unable to convert "foo" to uint64:.*`,
+	},
+	{
+		name: "Invalid compress ratio",
+		line: "tank\t5\t4\t3\t2\t1\t0\t2\t4\tbar",
+		want: `parsing "space usage compressratio", unable to convert "bar" to float64:.*`,
+	},
+}
+
+func TestParseSpaceUsageErrors(t *testing.T) {
+	for _, test := range parseSpaceUsageErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := parseSpaceUsage(strings.Split(tc.line, "\t"))
+			if gotErr == nil {
+				t.Errorf(
+					"parseSpaceUsage()\nTest Case: %q\nFailure: gotErr == nil\nReason: want = %q",
+					tc.name, tc.want)
+				return
+			}
+
+			match, err := regexp.MatchString(tc.want, gotErr.Error())
+			if err != nil {
+				t.Errorf(
+					"parseSpaceUsage()\nTest Case: %q\nFailure: unexpected exception while matching against gotErr error string\nReason: error = %v",
+					tc.name, err)
+				return
+			}
+
+			if !match {
+				t.Errorf(
+					"parseSpaceUsage()\nTest Case: %q\nFailure: gotErr did not match the want regex\nReason:\n\tgotErr = %q\n\twant   = %q",
+					tc.name, gotErr, tc.want)
+			}
+		})
+	}
+}
+
+func TestPoolSpaceUsage(t *testing.T) {
+	t.Parallel()
+
+	system := newStubSystem(stubExecutor{
+		testZpoolListCmd: {Stdout: testPoolInfo("tank", nil)},
+		"zfs list -H -p -r -t filesystem -o name,available,used,usedbysnapshots,usedbydataset,usedbyrefreservation,usedbychildren,referenced,logicalused,compressratio tank": {
+			Stdout: "tank\t5\t4\t3\t2\t1\t0\t2\t4\t1.00x\n" +
+				"tank/data\t1000\t900\t100\t700\t0\t100\t700\t1350\t1.50\n",
+		},
+	})
+
+	pools, err := system.ListPools()
+	if err != nil {
+		t.Fatalf("ListPools()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", t.Name(), err)
+	}
+
+	got, err := pools[0].SpaceUsage()
+	if err != nil {
+		t.Fatalf("Pool.SpaceUsage()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", t.Name(), err)
+	}
+
+	want := map[string]*SpaceUsage{
+		"tank": {
+			Available: 5, Used: 4, UsedBySnapshots: 3, UsedByDataset: 2, UsedByRefReservation: 1,
+			Referenced: 2, LogicalUsed: 4, CompressRatio: 1,
+		},
+		"tank/data": parseSpaceUsageTests[0].want,
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Pool.SpaceUsage()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s", t.Name(), diff)
+	}
+}
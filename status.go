@@ -0,0 +1,191 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	scanTimestampLayout = "Mon Jan _2 15:04:05 2006"
+)
+
+var (
+	scanCompletedRegex  = regexp.MustCompile(`^(scrub repaired|resilvered) \S+ in \S+ with (\d+) errors on (.+)$`)
+	scanInProgressRegex = regexp.MustCompile(`^(scrub|resilver) in progress since (.+?\d{4})`)
+	scanCanceledRegex   = regexp.MustCompile(`^(scrub|resilver) canceled on (.+)$`)
+	scanPausedRegex     = regexp.MustCompile(`^scrub paused since .+? scrub started on (.+?\d{4})`)
+)
+
+// PoolStatus represents the detailed health status of a pool as reported
+// by 'zpool status'.
+type PoolStatus struct {
+	// Name of the pool.
+	Name string
+	// State of the pool, e.g. "ONLINE" or "DEGRADED".
+	State string
+	// Detailed status of the pool, empty if there are no issues.
+	Status string
+	// Action recommended to resolve the issues, empty if there are no issues.
+	Action string
+	// Status of the last or in-progress scrub or resilver.
+	Scan string
+	// Status of the checkpoint of the pool, e.g. "created Sun Oct 11
+	// 00:24:01 2026, consumes 1.20M" or "discarding", empty if the pool
+	// has no checkpoint.
+	Checkpoint string
+	// Summary of the data errors, e.g. "No known data errors".
+	Errors string
+	// Vdev layout along with the state and error counters of every vdev.
+	Vdevs *VdevTree
+
+	// Parsed scan status of the JSON output, nil for the text output whose
+	// scan status is parsed on demand.
+	scan *ScanStatus
+}
+
+// ScanStatus represents the status of the last or in-progress scrub or
+// resilver of a pool.
+type ScanStatus struct {
+	// Function of the scan, i.e. "scrub" or "resilver", empty if no scan was
+	// ever requested.
+	Function string
+	// True if the scan is in progress (including paused), false otherwise.
+	InProgress bool
+	// True if the scan is paused, false otherwise.
+	Paused bool
+	// True if the scan was canceled, false otherwise.
+	Canceled bool
+	// Number of errors found by the completed scan.
+	Errors uint64
+	// Time the scan started, only set for in-progress (including paused)
+	// scans.
+	StartTime time.Time
+	// Time the scan completed or was canceled.
+	EndTime time.Time
+}
+
+// ScanStatus returns the parsed status of the last or in-progress scrub or
+// resilver of the pool.
+func (s *PoolStatus) ScanStatus() (*ScanStatus, error) {
+	if s.scan != nil {
+		result := *s.scan
+		return &result, nil
+	}
+
+	return parseScanStatus(s.Scan)
+}
+
+// String returns the string representation of the pool status.
+func (s *PoolStatus) String() string {
+	return fmt.Sprintf("{PoolStatus Name: %q, State: %q}", s.Name, s.State)
+}
+
+// Status returns the detailed health status of the pool.
+func (p *Pool) Status() (*PoolStatus, error) {
+	out, err := p.cmd().zpool.status(p.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of pool %q, reason: %w", p, err)
+	}
+
+	return parsePoolStatus(p.Name, out)
+}
+
+// Vdevs returns the current vdev layout of the pool.
+func (p *Pool) Vdevs() (*VdevTree, error) {
+	status, err := p.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	return status.Vdevs, nil
+}
+
+func parsePoolStatus(pool string, out string) (*PoolStatus, error) {
+	if isJSON(out) {
+		return parsePoolStatusJSON(pool, out)
+	}
+
+	blocks := parseStatusBlocks(out)
+	if len(blocks) != 1 {
+		return nil, fmt.Errorf("expected status of exactly one pool, but found %d, output: %q", len(blocks), out)
+	}
+
+	block := blocks[0]
+
+	name := block.value("pool")
+	if name != pool {
+		return nil, fmt.Errorf("expected status of pool %q, but found %q", pool, name)
+	}
+
+	vdevs, err := parseVdevTree(block["config"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config of pool %q, reason: %w", name, err)
+	}
+
+	return &PoolStatus{
+		Name:       name,
+		State:      block.value("state"),
+		Status:     block.value("status"),
+		Action:     block.value("action"),
+		Scan:       block.value("scan"),
+		Checkpoint: block.value("checkpoint"),
+		Errors:     block.value("errors"),
+		Vdevs:      vdevs,
+	}, nil
+}
+
+func parseScanStatus(scan string) (*ScanStatus, error) {
+	if scan == "" || scan == "none requested" {
+		return &ScanStatus{}, nil
+	}
+
+	if m := scanCompletedRegex.FindStringSubmatch(scan); m != nil {
+		errs, err := parseUint64(m[2], "scan status errors")
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseTimestamp(m[3], scanTimestampLayout, "scan status end time")
+		if err != nil {
+			return nil, err
+		}
+
+		function := "scrub"
+		if m[1] == "resilvered" {
+			function = "resilver"
+		}
+
+		return &ScanStatus{Function: function, Errors: errs, EndTime: end}, nil
+	}
+
+	if m := scanInProgressRegex.FindStringSubmatch(scan); m != nil {
+		start, err := parseTimestamp(m[2], scanTimestampLayout, "scan status start time")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanStatus{Function: m[1], InProgress: true, StartTime: start}, nil
+	}
+
+	if m := scanPausedRegex.FindStringSubmatch(scan); m != nil {
+		start, err := parseTimestamp(m[1], scanTimestampLayout, "scan status start time")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanStatus{Function: "scrub", InProgress: true, Paused: true, StartTime: start}, nil
+	}
+
+	if m := scanCanceledRegex.FindStringSubmatch(scan); m != nil {
+		end, err := parseTimestamp(strings.TrimSpace(m[2]), scanTimestampLayout, "scan status end time")
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScanStatus{Function: m[1], Canceled: true, EndTime: end}, nil
+	}
+
+	return nil, fmt.Errorf("parsing \"scan status\", unrecognized scan status: %q", scan)
+}
@@ -0,0 +1,199 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const testZpoolStatusOutput = `  pool: tank
+ state: DEGRADED
+status: One or more devices could not be used because the label is missing or
+	invalid.  Sufficient replicas exist for the pool to continue
+	functioning in a degraded state.
+action: Replace the device using 'zpool replace'.
+   see: https://openzfs.github.io/openzfs-docs/msg/ZFS-8000-4J
+  scan: resilvered 1.20G in 00:01:02 with 0 errors on Sun Oct 18 10:00:00 2026
+config:
+
+	NAME                      STATE     READ WRITE CKSUM
+	tank                      DEGRADED     0     0     0
+	  mirror-0                DEGRADED     0     0     0
+	    sda                   ONLINE       0     0     3
+	    12345678901234567890  UNAVAIL      0     0     0  was /dev/sdb1
+	  mirror-1                ONLINE       0     0     0
+	    sdc                   ONLINE       1     2     0
+	    sdd                   ONLINE       0     0     0
+	special
+	  mirror-2                ONLINE       0     0     0
+	    nvme0n1               ONLINE       0     0     0
+	    nvme1n1               ONLINE       0     0     0
+	logs
+	  nvme2n1                 ONLINE       0     0     0
+	cache
+	  nvme3n1                 ONLINE       0     0     0
+	spares
+	  sde                     AVAIL
+
+errors: No known data errors
+`
+
+func TestParsePoolStatus(t *testing.T) {
+	t.Parallel()
+
+	want := &PoolStatus{
+		Name:  "tank",
+		State: "DEGRADED",
+		Status: "One or more devices could not be used because the label is missing or " +
+			"invalid.  Sufficient replicas exist for the pool to continue " +
+			"functioning in a degraded state.",
+		Action: "Replace the device using 'zpool replace'.",
+		Scan:   "resilvered 1.20G in 00:01:02 with 0 errors on Sun Oct 18 10:00:00 2026",
+		Errors: "No known data errors",
+		Vdevs: &VdevTree{
+			Root: &Vdev{
+				Name:  "tank",
+				State: "DEGRADED",
+				Children: VdevList{
+					&Vdev{
+						Name:  "mirror-0",
+						State: "DEGRADED",
+						Children: VdevList{
+							&Vdev{Name: "sda", State: "ONLINE", ChecksumErrors: 3},
+							&Vdev{Name: "12345678901234567890", State: "UNAVAIL", Message: "was /dev/sdb1"},
+						},
+					},
+					&Vdev{
+						Name:  "mirror-1",
+						State: "ONLINE",
+						Children: VdevList{
+							&Vdev{Name: "sdc", State: "ONLINE", ReadErrors: 1, WriteErrors: 2},
+							&Vdev{Name: "sdd", State: "ONLINE"},
+						},
+					},
+				},
+			},
+			Special: VdevList{
+				&Vdev{
+					Name:  "mirror-2",
+					State: "ONLINE",
+					Children: VdevList{
+						&Vdev{Name: "nvme0n1", State: "ONLINE"},
+						&Vdev{Name: "nvme1n1", State: "ONLINE"},
+					},
+				},
+			},
+			Logs:   VdevList{&Vdev{Name: "nvme2n1", State: "ONLINE"}},
+			Cache:  VdevList{&Vdev{Name: "nvme3n1", State: "ONLINE"}},
+			Spares: VdevList{&Vdev{Name: "sde", State: "AVAIL"}},
+		},
+	}
+
+	got, gotErr := parsePoolStatus("tank", testZpoolStatusOutput)
+	if nil != gotErr {
+		t.Errorf(
+			"parsePoolStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+			t.Name(), gotErr)
+		return
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(PoolStatus{})); diff != "" {
+		t.Errorf(
+			"parsePoolStatus()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+			t.Name(), diff)
+	}
+
+	if v := got.Vdevs.Find("sdc"); v == nil || v.ReadErrors != 1 {
+		t.Errorf(
+			"VdevTree.Find()\nTest Case: %q\nFailure: unexpected vdev for \"sdc\"\nReason: got = %v",
+			t.Name(), v)
+	}
+
+	if leaves := got.Vdevs.Root.Leaves(); len(leaves) != 4 {
+		t.Errorf(
+			"Vdev.Leaves()\nTest Case: %q\nFailure: expected 4 leaves\nReason: got = %v",
+			t.Name(), leaves)
+	}
+}
+
+var parseScanStatusTests = []struct {
+	name string
+	scan string
+	want *ScanStatus
+}{
+	{
+		name: "None requested",
+		scan: "none requested",
+		want: &ScanStatus{},
+	},
+	{
+		name: "Scrub completed",
+		scan: "scrub repaired 0B in 00:00:01 with 2 errors on Sun Oct 18 10:00:00 2026",
+		want: &ScanStatus{
+			Function: "scrub",
+			Errors:   2,
+			EndTime:  time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local),
+		},
+	},
+	{
+		name: "Resilver completed",
+		scan: "resilvered 1.20G in 00:01:02 with 0 errors on Fri Oct  2 08:30:00 2026",
+		want: &ScanStatus{
+			Function: "resilver",
+			EndTime:  time.Date(2026, 10, 2, 8, 30, 0, 0, time.Local),
+		},
+	},
+	{
+		name: "Scrub in progress",
+		scan: "scrub in progress since Sun Oct 18 10:00:00 2026 1.20G scanned at 100M/s, 600M issued at 50M/s, 10G total",
+		want: &ScanStatus{
+			Function:   "scrub",
+			InProgress: true,
+			StartTime:  time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local),
+		},
+	},
+	{
+		name: "Scrub paused",
+		scan: "scrub paused since Sun Oct 18 11:00:00 2026 scrub started on Sun Oct 18 10:00:00 2026 1.20G scanned, 600M issued, 10G total",
+		want: &ScanStatus{
+			Function:   "scrub",
+			InProgress: true,
+			Paused:     true,
+			StartTime:  time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local),
+		},
+	},
+	{
+		name: "Scrub canceled",
+		scan: "scrub canceled on Sun Oct 18 10:00:00 2026",
+		want: &ScanStatus{
+			Function: "scrub",
+			Canceled: true,
+			EndTime:  time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local),
+		},
+	},
+}
+
+func TestParseScanStatus(t *testing.T) {
+	for _, test := range parseScanStatusTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := parseScanStatus(tc.scan)
+			if nil != gotErr {
+				t.Errorf(
+					"parseScanStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"parseScanStatus()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
@@ -1,11 +1,25 @@
 package zfs
 
+const (
+	defaultMountInfoPath = "/proc/self/mountinfo"
+)
+
 type System struct {
-	cmd *cmd
+	cmd           *cmd
+	mountInfoPath string
 }
 
 type SystemConfig struct {
-	alternateCmd *cmd
+	// Executor used to run the zfs and zpool commands, the commands are run
+	// on the local system if nil.
+	Executor Executor
+	// Disables the JSON output of OpenZFS 2.3 and later, which is otherwise
+	// used for listing and parsing whenever supported.
+	DisableJSON bool
+	// Path of the mountinfo file used to determine the mounted file systems,
+	// /proc/self/mountinfo if empty. Allows testing IsMounted and Mounts
+	// against a fixture, e.g. along with a zfstest.Sim executor.
+	MountInfoPath string
 }
 
 func (s *System) ListPools() (PoolList, error) {
@@ -13,11 +27,18 @@ func (s *System) ListPools() (PoolList, error) {
 }
 
 func NewSystem(config *SystemConfig) *System {
+	executor := config.Executor
+	if executor == nil {
+		executor = &ExecExecutor{}
+	}
+
 	result := &System{
-		cmd: config.alternateCmd,
+		cmd:           newExecutorCmd(executor, config.DisableJSON),
+		mountInfoPath: config.MountInfoPath,
 	}
-	if result.cmd == nil {
-		result.cmd = realSystemCmd()
+
+	if result.mountInfoPath == "" {
+		result.mountInfoPath = defaultMountInfoPath
 	}
 
 	return result
@@ -0,0 +1,288 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	activityStart vdevActivityAction = iota
+	activitySuspend
+	activityCancel
+)
+
+const (
+	// VdevActivityNone indicates that the activity was never started on the
+	// vdev, or was canceled.
+	VdevActivityNone VdevActivityState = "none"
+	// VdevActivityActive indicates that the activity is in progress.
+	VdevActivityActive VdevActivityState = "active"
+	// VdevActivitySuspended indicates that the activity is suspended, and
+	// is resumed when started again.
+	VdevActivitySuspended VdevActivityState = "suspended"
+	// VdevActivityComplete indicates that the activity completed.
+	VdevActivityComplete VdevActivityState = "complete"
+	// VdevActivityUnsupported indicates that the vdev does not support the
+	// activity, e.g. trimming a device without TRIM support.
+	VdevActivityUnsupported VdevActivityState = "unsupported"
+)
+
+var (
+	vdevActivityMessageRegex  = regexp.MustCompile(`\(([^()]*)\)`)
+	vdevActivityProgressRegex = regexp.MustCompile(
+		`^(\d+)% (trimmed|initialized), (started|suspended, started|completed) at (.+)$`)
+	vdevActivityKeywordRegex = regexp.MustCompile(`^\d+% (trimmed|initialized)\b`)
+	vdevActivityStates       = map[string]VdevActivityState{
+		"started":            VdevActivityActive,
+		"suspended, started": VdevActivitySuspended,
+		"completed":          VdevActivityComplete,
+	}
+)
+
+type vdevActivityAction uint8
+
+// VdevActivityState represents the state of the trim or initialize
+// activity of a leaf vdev.
+type VdevActivityState string
+
+// TrimOptions represents the options for trimming a pool.
+type TrimOptions struct {
+	// Devices to trim, all the devices of the pool supporting TRIM are
+	// trimmed if empty.
+	Devices []string
+	// Securely trim the devices, which fails for the devices that do not
+	// support secure TRIM.
+	Secure bool
+	// Rate limit of the trim in bytes per second for each device, unlimited
+	// if zero.
+	Rate uint64
+}
+
+// InitializeOptions represents the options for initializing the unallocated
+// space of a pool.
+type InitializeOptions struct {
+	// Devices to initialize, all the devices of the pool are initialized if
+	// empty.
+	Devices []string
+}
+
+// VdevActivityProgress represents the progress of the trim or initialize
+// activity of a leaf vdev.
+type VdevActivityProgress struct {
+	// State of the activity.
+	State VdevActivityState
+	// Completion percentage of the activity, zero if the activity is only
+	// reported as in progress, e.g. "(initializing)".
+	Percent uint8
+	// Time the activity started if active or suspended, or completed if
+	// complete, zero if not reported.
+	Time time.Time
+}
+
+// VdevProgress represents the progress of the trim and initialize
+// activities of a leaf vdev.
+type VdevProgress struct {
+	// Name of the leaf vdev, e.g. "sda".
+	Name string
+	// Progress of the trim, nil if not reported, e.g. for vdevs that are
+	// unavailable.
+	Trim *VdevActivityProgress
+	// Progress of the initialize, nil if not reported. OpenZFS 2.2 or later
+	// reports the vdevs that were never initialized, while the older
+	// versions only report the vdevs that were initialized.
+	Initialize *VdevActivityProgress
+}
+
+// VdevProgressList represents a list of VdevProgress objects.
+type VdevProgressList []*VdevProgress
+
+// String returns the string representation of the vdev progress.
+func (v *VdevProgress) String() string {
+	return fmt.Sprintf("{VdevProgress Name: %q}", v.Name)
+}
+
+// Trim starts (or resumes) trimming the unallocated space of the devices
+// of the pool, the trim runs in the background.
+func (p *Pool) Trim(opts *TrimOptions) error {
+	if opts == nil {
+		opts = &TrimOptions{}
+	}
+
+	_, err := p.cmd().zpool.trim(p.Name, activityStart, opts.Secure, opts.Rate, opts.Devices)
+	if err != nil {
+		return fmt.Errorf("failed to trim pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// SuspendTrim suspends trimming the devices (or all the devices if none are
+// specified) of the pool, the trim is resumed by Trim.
+func (p *Pool) SuspendTrim(devices ...string) error {
+	_, err := p.cmd().zpool.trim(p.Name, activitySuspend, false, 0, devices)
+	if err != nil {
+		return fmt.Errorf("failed to suspend trim of pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// CancelTrim cancels trimming the devices (or all the devices if none are
+// specified) of the pool.
+func (p *Pool) CancelTrim(devices ...string) error {
+	_, err := p.cmd().zpool.trim(p.Name, activityCancel, false, 0, devices)
+	if err != nil {
+		return fmt.Errorf("failed to cancel trim of pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// Initialize starts (or resumes) writing a pattern to the unallocated space
+// of the devices of the pool, the initialize runs in the background.
+func (p *Pool) Initialize(opts *InitializeOptions) error {
+	if opts == nil {
+		opts = &InitializeOptions{}
+	}
+
+	_, err := p.cmd().zpool.initialize(p.Name, activityStart, opts.Devices)
+	if err != nil {
+		return fmt.Errorf("failed to initialize pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// SuspendInitialize suspends initializing the devices (or all the devices
+// if none are specified) of the pool, the initialize is resumed by
+// Initialize.
+func (p *Pool) SuspendInitialize(devices ...string) error {
+	_, err := p.cmd().zpool.initialize(p.Name, activitySuspend, devices)
+	if err != nil {
+		return fmt.Errorf("failed to suspend initialize of pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// CancelInitialize cancels initializing the devices (or all the devices if
+// none are specified) of the pool.
+func (p *Pool) CancelInitialize(devices ...string) error {
+	_, err := p.cmd().zpool.initialize(p.Name, activityCancel, devices)
+	if err != nil {
+		return fmt.Errorf("failed to cancel initialize of pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// VdevProgress returns the progress of the trim and initialize activities
+// of every leaf vdev of the pool except the hot spares, as reported by
+// 'zpool status -t' (along with -i for OpenZFS 2.2 or later).
+func (p *Pool) VdevProgress() (VdevProgressList, error) {
+	out, err := p.progressStatus(true)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := parsePoolStatus(p.Name, out)
+	if err != nil {
+		return nil, err
+	}
+
+	spares := make(map[*Vdev]bool)
+	for _, v := range status.Vdevs.Spares {
+		spares[v] = true
+	}
+
+	var result VdevProgressList
+
+	for _, v := range status.Vdevs.All() {
+		if len(v.Children) > 0 || v == status.Vdevs.Root || spares[v] {
+			continue
+		}
+
+		progress, err := parseVdevProgress(v.Name, v.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse progress of vdev %q of pool %q, reason: %w", v.Name, p, err)
+		}
+
+		result = append(result, progress)
+	}
+
+	return result, nil
+}
+
+// progressStatus returns the text output of 'zpool status' for the pool
+// including the initialize progress of its leaf vdevs, and the trim
+// progress if trim is true.
+func (p *Pool) progressStatus(trim bool) (string, error) {
+	// The initialize progress is only reported by default by the versions
+	// older than 2.2, which do not support -i.
+	initialize, _ := p.System.Supports(CapabilityInitializeStatus)
+
+	out, err := p.cmd().zpool.statusProgress(p.Name, trim, initialize)
+	if err != nil {
+		return "", fmt.Errorf("failed to get status of pool %q, reason: %w", p, err)
+	}
+
+	return out, nil
+}
+
+// parseVdevProgress parses the trim and initialize progress in the message
+// of a leaf vdev, e.g. "(15% trimmed, started at Sun Oct 11 00:24:01 2026)".
+// Any other messages, e.g. "(resilvering)", are ignored.
+func parseVdevProgress(name string, message string) (*VdevProgress, error) {
+	result := &VdevProgress{Name: name}
+
+	for _, m := range vdevActivityMessageRegex.FindAllStringSubmatch(message, -1) {
+		switch m[1] {
+		case "untrimmed":
+			result.Trim = &VdevActivityProgress{State: VdevActivityNone}
+			continue
+		case "trim unsupported":
+			result.Trim = &VdevActivityProgress{State: VdevActivityUnsupported}
+			continue
+		case "uninitialized":
+			result.Initialize = &VdevActivityProgress{State: VdevActivityNone}
+			continue
+		case "trimming":
+			result.Trim = &VdevActivityProgress{State: VdevActivityActive}
+			continue
+		case "initializing":
+			result.Initialize = &VdevActivityProgress{State: VdevActivityActive}
+			continue
+		}
+
+		p := vdevActivityProgressRegex.FindStringSubmatch(m[1])
+		if p == nil {
+			if vdevActivityKeywordRegex.MatchString(m[1]) {
+				return nil, fmt.Errorf("parsing \"vdev activity\", unrecognized progress %q", m[1])
+			}
+
+			continue
+		}
+
+		percent, err := parseUint8(p[1], "vdev activity percent")
+		if err != nil {
+			return nil, err
+		}
+
+		progress := &VdevActivityProgress{State: vdevActivityStates[p[3]], Percent: percent}
+
+		progress.Time, err = parseTimestamp(strings.TrimSpace(p[4]), scanTimestampLayout, "vdev activity time")
+		if err != nil {
+			return nil, err
+		}
+
+		if p[2] == "trimmed" {
+			result.Trim = progress
+		} else {
+			result.Initialize = progress
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,245 @@
+package zfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseVdevProgressTests = []struct {
+	name    string
+	message string
+	want    *VdevProgress
+	wantErr bool
+}{
+	{
+		name: "Not reported",
+		want: &VdevProgress{Name: "sda"},
+	},
+	{
+		name:    "Trimming",
+		message: "(15% trimmed, started at Sun Oct 11 00:24:01 2026)",
+		want: &VdevProgress{
+			Name: "sda",
+			Trim: &VdevActivityProgress{
+				State:   VdevActivityActive,
+				Percent: 15,
+				Time:    time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+		},
+	},
+	{
+		name:    "Initialized and untrimmed",
+		message: "(100% initialized, completed at Sun Oct  4 09:12:45 2026)  (untrimmed)",
+		want: &VdevProgress{
+			Name: "sda",
+			Trim: &VdevActivityProgress{State: VdevActivityNone},
+			Initialize: &VdevActivityProgress{
+				State:   VdevActivityComplete,
+				Percent: 100,
+				Time:    time.Date(2026, 10, 4, 9, 12, 45, 0, time.Local),
+			},
+		},
+	},
+	{
+		name:    "Suspended",
+		message: "(42% initialized, suspended, started at Sun Oct 11 00:24:01 2026)  (trim unsupported)",
+		want: &VdevProgress{
+			Name: "sda",
+			Trim: &VdevActivityProgress{State: VdevActivityUnsupported},
+			Initialize: &VdevActivityProgress{
+				State:   VdevActivitySuspended,
+				Percent: 42,
+				Time:    time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+		},
+	},
+	{
+		name:    "Suspended trim",
+		message: "(uninitialized)  (7% trimmed, suspended, started at Sun Oct 11 00:24:01 2026)",
+		want: &VdevProgress{
+			Name: "sda",
+			Trim: &VdevActivityProgress{
+				State:   VdevActivitySuspended,
+				Percent: 7,
+				Time:    time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+			Initialize: &VdevActivityProgress{State: VdevActivityNone},
+		},
+	},
+	{
+		name:    "In progress without the percentage",
+		message: "(initializing)  (trimming)",
+		want: &VdevProgress{
+			Name:       "sda",
+			Trim:       &VdevActivityProgress{State: VdevActivityActive},
+			Initialize: &VdevActivityProgress{State: VdevActivityActive},
+		},
+	},
+	{
+		name:    "Other messages",
+		message: "(resilvering)",
+		want:    &VdevProgress{Name: "sda"},
+	},
+	{
+		name:    "Unrecognized progress",
+		message: "(15% trimmed, paused at Sun Oct 11 00:24:01 2026)",
+		wantErr: true,
+	},
+	{
+		name:    "Uninitialized along with a message",
+		message: "was /dev/sda1 (uninitialized)",
+		want: &VdevProgress{
+			Name:       "sda",
+			Initialize: &VdevActivityProgress{State: VdevActivityNone},
+		},
+	},
+}
+
+func TestParseVdevProgress(t *testing.T) {
+	for _, test := range parseVdevProgressTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseVdevProgress("sda", tc.message)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("parseVdevProgress()\nTest Case: %q\nFailure: gotErr == nil\nGot: %v", tc.name, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseVdevProgress()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", tc.name, err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("parseVdevProgress()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestPoolTrim(t *testing.T) {
+	t.Parallel()
+
+	status := "  pool: tank\n" +
+		" state: ONLINE\n" +
+		"  scan: none requested\n" +
+		"config:\n\n" +
+		"\tNAME        STATE     READ WRITE CKSUM\n" +
+		"\ttank        ONLINE       0     0     0\n" +
+		"\t  mirror-0  ONLINE       0     0     0\n" +
+		"\t    sda     ONLINE       0     0     0 (uninitialized)  (15% trimmed, started at Sun Oct 11 00:24:01 2026)\n" +
+		"\t    sdb     ONLINE       0     0     0  (42% initialized, suspended, started at Sun Oct 11 00:24:01 2026)  (untrimmed)\n" +
+		"\tcache\n" +
+		"\t  nvme0n1   ONLINE       0     0     0 (uninitialized)  (trim unsupported)\n\n" +
+		"errors: No known data errors\n"
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zpool trim -d -r 104857600 tank sda": {},
+			"zpool trim -s tank":                  {},
+			"zpool initialize -c tank sdb":        {},
+			"zfs version":                         {Stdout: "zfs-2.2.6-1\nzfs-kmod-2.2.6-1\n"},
+			"zpool status -t -i -p tank":          {Stdout: status},
+		},
+		DisableJSON: true,
+	})
+	pool := &Pool{Name: "tank", System: system}
+
+	if err := pool.Trim(&TrimOptions{Devices: []string{"sda"}, Secure: true, Rate: 100 << 20}); err != nil {
+		t.Errorf("Trim()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Secure rate limited trim", err)
+	}
+
+	if err := pool.SuspendTrim(); err != nil {
+		t.Errorf("SuspendTrim()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "All devices", err)
+	}
+
+	if err := pool.CancelInitialize("sdb"); err != nil {
+		t.Errorf("CancelInitialize()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Single device", err)
+	}
+
+	got, err := pool.VdevProgress()
+	if err != nil {
+		t.Fatalf("VdevProgress()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Trim progress", err)
+	}
+
+	want := VdevProgressList{
+		{
+			Name: "sda",
+			Trim: &VdevActivityProgress{
+				State:   VdevActivityActive,
+				Percent: 15,
+				Time:    time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+			Initialize: &VdevActivityProgress{State: VdevActivityNone},
+		},
+		{
+			Name: "sdb",
+			Trim: &VdevActivityProgress{State: VdevActivityNone},
+			Initialize: &VdevActivityProgress{
+				State:   VdevActivitySuspended,
+				Percent: 42,
+				Time:    time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+		},
+		{
+			Name:       "nvme0n1",
+			Trim:       &VdevActivityProgress{State: VdevActivityUnsupported},
+			Initialize: &VdevActivityProgress{State: VdevActivityNone},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("VdevProgress()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "Trim progress", diff)
+	}
+}
+
+func TestPoolVdevProgressWithoutInitializeStatus(t *testing.T) {
+	t.Parallel()
+
+	// The versions older than 2.2 report the initialize progress without
+	// -i, which they do not support.
+	status := "  pool: tank\n" +
+		" state: ONLINE\n" +
+		"  scan: none requested\n" +
+		"config:\n\n" +
+		"\tNAME        STATE     READ WRITE CKSUM\n" +
+		"\ttank        ONLINE       0     0     0\n" +
+		"\t  sda       ONLINE       0     0     0  (42% initialized, started at Sun Oct 11 00:24:01 2026)  (untrimmed)\n\n" +
+		"errors: No known data errors\n"
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zfs version":             {Stdout: "zfs-2.1.5-1\nzfs-kmod-2.1.5-1\n"},
+			"zpool status -t -p tank": {Stdout: status},
+		},
+		DisableJSON: true,
+	})
+	pool := &Pool{Name: "tank", System: system}
+
+	got, err := pool.VdevProgress()
+	if err != nil {
+		t.Fatalf("VdevProgress()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "OpenZFS 2.1", err)
+	}
+
+	want := VdevProgressList{
+		{
+			Name: "sda",
+			Trim: &VdevActivityProgress{State: VdevActivityNone},
+			Initialize: &VdevActivityProgress{
+				State:   VdevActivityActive,
+				Percent: 42,
+				Time:    time.Date(2026, 10, 11, 0, 24, 1, 0, time.Local),
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("VdevProgress()\nTest Case: %q\nFailure: want and got differ\ndiff:\n%s", "OpenZFS 2.1", diff)
+	}
+}
@@ -0,0 +1,127 @@
+package zfs
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	userSpaceOutputCols = []string{
+		"type",
+		"name",
+		"used",
+		"quota",
+		"objused",
+		"objquota",
+	}
+)
+
+// UserSpace represents the space consumed by a user, group or project
+// within a file system.
+type UserSpace struct {
+	// Type of the entity, e.g. "POSIX User", "POSIX Group" or "Project".
+	Type string
+	// Name of the entity, or its numeric ID if it cannot be resolved.
+	Name string
+	// Number of bytes used.
+	Used uint64
+	// Quota in bytes, zero if none.
+	Quota uint64
+	// Number of objects (files and directories) used.
+	ObjUsed uint64
+	// Quota in number of objects, zero if none.
+	ObjQuota uint64
+	// The file system the space is accounted in.
+	FileSystem *FileSystem
+}
+
+// UserSpaceList represents a list of UserSpace objects.
+type UserSpaceList []*UserSpace
+
+// String returns the string representation of the user space.
+func (u *UserSpace) String() string {
+	return fmt.Sprintf(
+		"{UserSpace Type: %q, Name: %q, Used: %d, Quota: %d, ObjUsed: %d, ObjQuota: %d, FileSystem: %v}",
+		u.Type,
+		u.Name,
+		u.Used,
+		u.Quota,
+		u.ObjUsed,
+		u.ObjQuota,
+		u.FileSystem,
+	)
+}
+
+// UserSpace returns the space consumed by each user within the file system.
+func (f *FileSystem) UserSpace() (UserSpaceList, error) {
+	return listUserSpace(f, zfsUserSpace)
+}
+
+// GroupSpace returns the space consumed by each group within the file system.
+func (f *FileSystem) GroupSpace() (UserSpaceList, error) {
+	return listUserSpace(f, zfsGroupSpace)
+}
+
+// ProjectSpace returns the space consumed by each project within the file
+// system.
+func (f *FileSystem) ProjectSpace() (UserSpaceList, error) {
+	return listUserSpace(f, zfsProjectSpace)
+}
+
+func listUserSpace(fs *FileSystem, spaceType zfsSpaceType) (UserSpaceList, error) {
+	out, err := fs.cmd().zfs.userspace(fs.FullName(), spaceType, userSpaceOutputCols)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to list %s of file system %q, reason: %w", zfsSpaceTypeToStr[spaceType], fs, err)
+	}
+
+	var result UserSpaceList
+
+	for _, line := range splitOnNewLine(out) {
+		u, err := parseUserSpaceInfo(fs, line)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, u)
+	}
+
+	return result, nil
+}
+
+func parseUserSpaceInfo(fs *FileSystem, line string) (*UserSpace, error) {
+	cols := strings.Split(line, "\t")
+	if len(cols) != 6 {
+		return nil, fmt.Errorf("expected 6 columns per line in user space info, but found %d, line: %q", len(cols), line)
+	}
+
+	used, err := parseUint64(cols[2], "user space info used")
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := parseUint64OrNone(cols[3], "user space info quota")
+	if err != nil {
+		return nil, err
+	}
+
+	objUsed, err := parseUint64OrNone(cols[4], "user space info objused")
+	if err != nil {
+		return nil, err
+	}
+
+	objQuota, err := parseUint64OrNone(cols[5], "user space info objquota")
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserSpace{
+		Type:       cols[0],
+		Name:       cols[1],
+		Used:       used,
+		Quota:      quota,
+		ObjUsed:    objUsed,
+		ObjQuota:   objQuota,
+		FileSystem: fs,
+	}, nil
+}
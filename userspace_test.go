@@ -0,0 +1,123 @@
+package zfs
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseUserSpaceInfoTests = []struct {
+	name string
+	line string
+	want *UserSpace
+}{
+	{
+		name: "User with quotas",
+		line: "POSIX User\talice\t1048576\t10737418240\t42\t1000",
+		want: &UserSpace{
+			Type:     "POSIX User",
+			Name:     "alice",
+			Used:     1048576,
+			Quota:    10737418240,
+			ObjUsed:  42,
+			ObjQuota: 1000,
+		},
+	},
+	{
+		name: "Group without quotas",
+		line: "POSIX Group\t1001\t4096\tnone\t3\tnone",
+		want: &UserSpace{
+			Type:    "POSIX Group",
+			Name:    "1001",
+			Used:    4096,
+			ObjUsed: 3,
+		},
+	},
+	{
+		name: "Project without object accounting",
+		line: "Project\t7\t512\t1024\t-\t-",
+		want: &UserSpace{
+			Type:  "Project",
+			Name:  "7",
+			Used:  512,
+			Quota: 1024,
+		},
+	},
+}
+
+func TestParseUserSpaceInfo(t *testing.T) {
+	for _, test := range parseUserSpaceInfoTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := parseUserSpaceInfo(nil, tc.line)
+			if nil != gotErr {
+				t.Errorf(
+					"parseUserSpaceInfo()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v",
+					tc.name, gotErr)
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf(
+					"parseUserSpaceInfo()\nTest Case: %q\nFailure: want and got differ\nReason:\n%s",
+					tc.name, diff)
+			}
+		})
+	}
+}
+
+var parseUserSpaceInfoErrorTests = []struct {
+	name string
+	line string
+	want string
+}{
+	{
+		name: "Invalid column count",
+		line: "POSIX User\talice\t1",
+		want: `expected 6 columns per line in user space info, but found 3, line: .*`,
+	},
+	{
+		name: "Invalid used",
+		line: "POSIX User\talice\tnone\tnone\t1\t1",
+		want: `parsing "user space info used", unable to convert "none" to uint64:.*`,
+	},
+	{
+		name: "Invalid quota",
+		line: "POSIX User\talice\t1\t10G\t1\t1",
+		want: `parsing "user space info quota", unable to convert "10G" to uint64:.*`,
+	},
+}
+
+func TestParseUserSpaceInfoErrors(t *testing.T) {
+	for _, test := range parseUserSpaceInfoErrorTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, gotErr := parseUserSpaceInfo(nil, tc.line)
+			if gotErr == nil {
+				t.Errorf(
+					"parseUserSpaceInfo()\nTest Case: %q\nFailure: gotErr == nil\nReason: want = %q",
+					tc.name, tc.want)
+				return
+			}
+
+			match, err := regexp.MatchString(tc.want, gotErr.Error())
+			if err != nil {
+				t.Errorf(
+					"parseUserSpaceInfo()\nTest Case: %q\nFailure: unexpected exception while matching against gotErr error string\nReason: error = %v",
+					tc.name, err)
+				return
+			}
+
+			if !match {
+				t.Errorf(
+					"parseUserSpaceInfo()\nTest Case: %q\nFailure: gotErr did not match the want regex\nReason:\n\tgotErr = %q\n\twant   = %q",
+					tc.name, gotErr, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package zfs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	vdevClassLogs    = "logs"
+	vdevClassCache   = "cache"
+	vdevClassSpares  = "spares"
+	vdevClassSpecial = "special"
+	vdevClassDedup   = "dedup"
+)
+
+var (
+	statusKeyRegex = regexp.MustCompile(`^ *([a-z]+): ?(.*)$`)
+	vdevClasses    = map[string]bool{
+		vdevClassLogs:    true,
+		vdevClassCache:   true,
+		vdevClassSpares:  true,
+		vdevClassSpecial: true,
+		vdevClassDedup:   true,
+	}
+)
+
+// Vdev represents a virtual device within the configuration of a pool.
+type Vdev struct {
+	// Name of the vdev, e.g. "mirror-0" or "sda".
+	Name string
+	// State of the vdev, e.g. "ONLINE" or "DEGRADED".
+	State string
+	// Number of read errors.
+	ReadErrors uint64
+	// Number of write errors.
+	WriteErrors uint64
+	// Number of checksum errors.
+	ChecksumErrors uint64
+	// Any additional text reported for the vdev, e.g. "cannot open".
+	Message string
+	// Child vdevs.
+	Children VdevList
+}
+
+// VdevList represents a list of Vdev objects.
+type VdevList []*Vdev
+
+// VdevTree represents the configuration of all the vdevs within a pool.
+type VdevTree struct {
+	// Root vdev which is named after the pool, the children are the
+	// top-level vdevs of the normal class.
+	Root *Vdev
+	// Top-level vdevs of the special allocation class.
+	Special VdevList
+	// Top-level vdevs of the dedup allocation class.
+	Dedup VdevList
+	// Top-level log vdevs.
+	Logs VdevList
+	// Cache devices.
+	Cache VdevList
+	// Hot spares.
+	Spares VdevList
+}
+
+// String returns the string representation of the vdev.
+func (v *Vdev) String() string {
+	return fmt.Sprintf("{Vdev Name: %q, State: %q}", v.Name, v.State)
+}
+
+// VerboseString returns a verbose string representation of the vdev.
+func (v *Vdev) VerboseString() string {
+	return fmt.Sprintf(
+		"{Vdev Name: %q, State: %q, ReadErrors: %d, WriteErrors: %d, ChecksumErrors: %d, Message: %q, Children: %v}",
+		v.Name,
+		v.State,
+		v.ReadErrors,
+		v.WriteErrors,
+		v.ChecksumErrors,
+		v.Message,
+		v.Children,
+	)
+}
+
+// Leaves returns the list of leaf vdevs (i.e. the actual devices) under this
+// vdev, or the vdev itself if it is a leaf.
+func (v *Vdev) Leaves() VdevList {
+	if len(v.Children) == 0 {
+		return VdevList{v}
+	}
+
+	var result VdevList
+	for _, c := range v.Children {
+		result = append(result, c.Leaves()...)
+	}
+
+	return result
+}
+
+// Find returns the first vdev with the specified name in the tree, or nil
+// if not found.
+func (t *VdevTree) Find(name string) *Vdev {
+	for _, v := range t.All() {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// All returns the list of all the vdevs within the tree in depth first
+// order, starting with the root vdev.
+func (t *VdevTree) All() VdevList {
+	var result VdevList
+
+	var walk func(v *Vdev)
+	walk = func(v *Vdev) {
+		result = append(result, v)
+		for _, c := range v.Children {
+			walk(c)
+		}
+	}
+
+	if t.Root != nil {
+		walk(t.Root)
+	}
+
+	for _, class := range []VdevList{t.Special, t.Dedup, t.Logs, t.Cache, t.Spares} {
+		for _, v := range class {
+			walk(v)
+		}
+	}
+
+	return result
+}
+
+// statusBlock represents the "key: value" sections of a single pool in the
+// output of 'zpool status' or 'zpool import', where the value of each key
+// includes all the continuation lines.
+type statusBlock map[string][]string
+
+// value returns the value of the key with the continuation lines joined by
+// a space.
+func (s statusBlock) value(key string) string {
+	var parts []string
+	for _, l := range s[key] {
+		if l = strings.TrimSpace(l); l != "" {
+			parts = append(parts, l)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// parseStatusBlocks splits the output of 'zpool status' or 'zpool import'
+// into one block per pool. Each block begins with the "pool" key.
+func parseStatusBlocks(out string) []statusBlock {
+	var result []statusBlock
+
+	var block statusBlock
+
+	key := ""
+
+	for _, line := range splitOnNewLine(out) {
+		if !strings.HasPrefix(line, "\t") {
+			if m := statusKeyRegex.FindStringSubmatch(line); m != nil {
+				key = m[1]
+				if key == "pool" {
+					block = make(statusBlock)
+					result = append(result, block)
+				}
+
+				if block != nil {
+					block[key] = append(block[key], m[2])
+				}
+
+				continue
+			}
+		}
+
+		if block != nil && key != "" {
+			block[key] = append(block[key], line)
+		}
+	}
+
+	return result
+}
+
+// parseVdevTree parses the lines of the "config" section in the output of
+// 'zpool status' or 'zpool import'.
+func parseVdevTree(lines []string) (*VdevTree, error) {
+	result := &VdevTree{}
+
+	// Stack of the most recent vdev at each depth, a nil entry represents
+	// the header of an allocation class (e.g. "logs").
+	var stack []*Vdev
+
+	var class *VdevList
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" || !strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line[1:], " ")
+		depth := (len(line) - 1 - len(trimmed)) / 2
+		fields := strings.Fields(trimmed)
+
+		if depth == 0 && fields[0] == "NAME" {
+			continue
+		}
+
+		if depth == 0 && len(fields) == 1 && vdevClasses[fields[0]] {
+			class = result.classList(fields[0])
+			stack = []*Vdev{nil}
+
+			continue
+		}
+
+		v, err := parseVdevLine(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		if depth == 0 {
+			if result.Root != nil {
+				return nil, fmt.Errorf("parsing \"vdev config\", found more than one root vdev, line: %q", line)
+			}
+
+			result.Root = v
+		} else {
+			if depth > len(stack) {
+				return nil, fmt.Errorf("parsing \"vdev config\", vdev without a parent, line: %q", line)
+			}
+
+			if parent := stack[depth-1]; parent != nil {
+				parent.Children = append(parent.Children, v)
+			} else {
+				*class = append(*class, v)
+			}
+		}
+
+		stack = append(stack[:depth], v)
+	}
+
+	if result.Root == nil {
+		return nil, fmt.Errorf("parsing \"vdev config\", root vdev not found")
+	}
+
+	return result, nil
+}
+
+func (t *VdevTree) classList(class string) *VdevList {
+	switch class {
+	case vdevClassLogs:
+		return &t.Logs
+	case vdevClassCache:
+		return &t.Cache
+	case vdevClassSpares:
+		return &t.Spares
+	case vdevClassSpecial:
+		return &t.Special
+	default:
+		return &t.Dedup
+	}
+}
+
+// parseVdevLine parses the columns of a single vdev, which are the name,
+// state, optionally followed by the read, write and checksum error counts,
+// optionally followed by a message.
+func parseVdevLine(fields []string) (*Vdev, error) {
+	v := &Vdev{Name: fields[0]}
+	rest := fields[1:]
+
+	if len(rest) > 0 {
+		v.State = rest[0]
+		rest = rest[1:]
+	}
+
+	if len(rest) >= 3 && isVdevErrorCount(rest[0]) && isVdevErrorCount(rest[1]) && isVdevErrorCount(rest[2]) {
+		counts := []*uint64{&v.ReadErrors, &v.WriteErrors, &v.ChecksumErrors}
+		for i, c := range counts {
+			val, err := parseVdevErrorCount(rest[i])
+			if err != nil {
+				return nil, err
+			}
+
+			*c = val
+		}
+
+		rest = rest[3:]
+	}
+
+	v.Message = strings.Join(rest, " ")
+
+	return v, nil
+}
+
+func isVdevErrorCount(str string) bool {
+	_, err := parseVdevErrorCount(str)
+	return err == nil
+}
+
+// parseVdevErrorCount parses the error counts, which are exact numbers when
+// using 'zpool status -p', but otherwise could be abbreviated (e.g. "1.2K").
+func parseVdevErrorCount(str string) (uint64, error) {
+	if val, err := strconv.ParseUint(str, 10, 64); err == nil {
+		return val, nil
+	}
+
+	if str == "" || strings.Trim(str, "0123456789.KMGTPE") != "" {
+		return 0, fmt.Errorf("parsing \"vdev error count\", unable to convert %q to uint64", str)
+	}
+
+	size, err := ParseByteSize(str)
+	if err != nil {
+		return 0, fmt.Errorf("parsing \"vdev error count\", unable to convert %q to uint64: %w", str, err)
+	}
+
+	return uint64(size), nil
+}
@@ -0,0 +1,220 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+const (
+	// CapabilityJSON is the JSON output of the zfs and zpool commands.
+	CapabilityJSON Capability = "JSON output"
+	// CapabilityWait is 'zpool wait'.
+	CapabilityWait Capability = "zpool wait"
+	// CapabilityDRaid is the draid vdev type.
+	CapabilityDRaid Capability = "draid vdevs"
+	// CapabilityTrim is 'zpool trim' along with 'zpool status -t'.
+	CapabilityTrim Capability = "zpool trim"
+	// CapabilityInitializeStatus is 'zpool status -i', without which the
+	// newer versions only report the initialize as in progress.
+	CapabilityInitializeStatus Capability = "zpool status -i"
+	// CapabilityRedact is 'zfs redact' for creating redaction bookmarks.
+	CapabilityRedact Capability = "zfs redact"
+)
+
+var (
+	// ErrUnsupported indicates that the operation is not supported by the
+	// installed version of zfs.
+	ErrUnsupported = errors.New("not supported by the installed zfs version")
+
+	// Minimum OpenZFS version required by each capability.
+	capabilityVersions = map[Capability]Version{
+		CapabilityJSON:             {Major: 2, Minor: 3},
+		CapabilityWait:             {Major: 2, Minor: 0},
+		CapabilityDRaid:            {Major: 2, Minor: 1},
+		CapabilityInitializeStatus: {Major: 2, Minor: 2},
+		CapabilityTrim:             {Major: 0, Minor: 8},
+		CapabilityRedact:           {Major: 2, Minor: 0},
+	}
+
+	versionRegex = regexp.MustCompile(`^zfs-(kmod-)?(\d+)\.(\d+)(?:\.(\d+))?`)
+)
+
+// Capability represents a feature of the zfs and zpool commands that is
+// only available in newer versions of OpenZFS.
+type Capability string
+
+// Version represents the version of the zfs userland tools or the zfs
+// kernel module.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Full version as reported by 'zfs version', e.g.
+	// "zfs-2.1.5-1ubuntu6~22.04.1", empty if unknown.
+	Full string
+}
+
+// VersionInfo represents the versions of the zfs userland tools and the
+// zfs kernel module, which can differ e.g. after an upgrade until a reboot.
+type VersionInfo struct {
+	// Version of the zfs userland tools.
+	Userland Version
+	// Version of the zfs kernel module, the full version is empty if the
+	// kernel module is not loaded.
+	Kernel Version
+}
+
+// String returns the string representation of the version.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// AtLeast returns true if the version is the same or newer than the
+// specified version.
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+
+	return v.Patch >= other.Patch
+}
+
+// String returns the string representation of the version info.
+func (v *VersionInfo) String() string {
+	return fmt.Sprintf("{VersionInfo Userland: %q, Kernel: %q}", v.Userland.Full, v.Kernel.Full)
+}
+
+// Supports returns true if both the userland tools and the kernel module
+// (if loaded) support the capability.
+func (v *VersionInfo) Supports(c Capability) bool {
+	required, ok := capabilityVersions[c]
+	if !ok {
+		return false
+	}
+
+	if v.Kernel.Full != "" && !v.Kernel.AtLeast(required) {
+		return false
+	}
+
+	return v.Userland.AtLeast(required)
+}
+
+// versionDetector detects the versions of zfs, shared by the System and the
+// detection of the JSON output so that 'zfs version' runs only once.
+type versionDetector struct {
+	zfs zfsCmd
+
+	mu      sync.Mutex
+	version *VersionInfo
+}
+
+// Version returns the versions of the zfs userland tools and the kernel
+// module as reported by 'zfs version', which requires OpenZFS 0.8 or later.
+// The versions are cached after the first successful call.
+func (s *System) Version() (*VersionInfo, error) {
+	return s.cmd.versions.get()
+}
+
+// get returns the cached versions, running 'zfs version' until it first
+// succeeds.
+func (d *versionDetector) get() (*VersionInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.version != nil {
+		return d.version, nil
+	}
+
+	out, err := d.zfs.version()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zfs version, reason: %w", err)
+	}
+
+	version, err := parseVersion(out)
+	if err != nil {
+		return nil, err
+	}
+
+	d.version = version
+
+	return version, nil
+}
+
+// Supports returns true if the installed version of zfs supports the
+// capability.
+func (s *System) Supports(c Capability) (bool, error) {
+	version, err := s.Version()
+	if err != nil {
+		return false, err
+	}
+
+	return version.Supports(c), nil
+}
+
+// requireCapability returns an error wrapping ErrUnsupported if the
+// installed version of zfs does not support the capability. The command
+// is attempted (i.e. nil is returned) if the version cannot be determined,
+// e.g. for versions older than 0.8.
+func (s *System) requireCapability(c Capability) error {
+	version, err := s.Version()
+	if err != nil {
+		return nil
+	}
+
+	if !version.Supports(c) {
+		return fmt.Errorf(
+			"%s requires OpenZFS %s or later, found %s: %w",
+			c, capabilityVersions[c], versionSummary(version), ErrUnsupported)
+	}
+
+	return nil
+}
+
+// versionSummary returns the oldest of the userland and kernel versions.
+func versionSummary(v *VersionInfo) Version {
+	if v.Kernel.Full != "" && !v.Kernel.AtLeast(v.Userland) {
+		return v.Kernel
+	}
+
+	return v.Userland
+}
+
+// parseVersion parses the output of 'zfs version', i.e. the userland
+// version followed by the kernel module version.
+func parseVersion(out string) (*VersionInfo, error) {
+	result := &VersionInfo{}
+
+	for _, line := range splitOnNewLine(out) {
+		m := versionRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		v := Version{Full: line}
+		v.Major, _ = strconv.Atoi(m[2])
+		v.Minor, _ = strconv.Atoi(m[3])
+
+		if m[4] != "" {
+			v.Patch, _ = strconv.Atoi(m[4])
+		}
+
+		if m[1] != "" {
+			result.Kernel = v
+		} else {
+			result.Userland = v
+		}
+	}
+
+	if result.Userland.Full == "" {
+		return nil, fmt.Errorf("parsing \"zfs version\", userland version not found, output: %q", out)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,221 @@
+package zfs
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var parseVersionTests = []struct {
+	name string
+	out  string
+	want *VersionInfo
+}{
+	{
+		name: "OpenZFS 0.8",
+		out:  "zfs-0.8.3-1ubuntu12.17\nzfs-kmod-0.8.3-1ubuntu12.17\n",
+		want: &VersionInfo{
+			Userland: Version{Major: 0, Minor: 8, Patch: 3, Full: "zfs-0.8.3-1ubuntu12.17"},
+			Kernel:   Version{Major: 0, Minor: 8, Patch: 3, Full: "zfs-kmod-0.8.3-1ubuntu12.17"},
+		},
+	},
+	{
+		name: "OpenZFS 2.1",
+		out:  "zfs-2.1.5-1ubuntu6~22.04.1\nzfs-kmod-2.1.5-1ubuntu6~22.04.1\n",
+		want: &VersionInfo{
+			Userland: Version{Major: 2, Minor: 1, Patch: 5, Full: "zfs-2.1.5-1ubuntu6~22.04.1"},
+			Kernel:   Version{Major: 2, Minor: 1, Patch: 5, Full: "zfs-kmod-2.1.5-1ubuntu6~22.04.1"},
+		},
+	},
+	{
+		name: "Userland newer than kernel",
+		out:  "zfs-2.2.2-0ubuntu9\nzfs-kmod-2.1.5-1ubuntu6~22.04.1\n",
+		want: &VersionInfo{
+			Userland: Version{Major: 2, Minor: 2, Patch: 2, Full: "zfs-2.2.2-0ubuntu9"},
+			Kernel:   Version{Major: 2, Minor: 1, Patch: 5, Full: "zfs-kmod-2.1.5-1ubuntu6~22.04.1"},
+		},
+	},
+	{
+		name: "Release candidate",
+		out:  "zfs-2.3.0-rc1\nzfs-kmod-2.3.0-rc1\n",
+		want: &VersionInfo{
+			Userland: Version{Major: 2, Minor: 3, Patch: 0, Full: "zfs-2.3.0-rc1"},
+			Kernel:   Version{Major: 2, Minor: 3, Patch: 0, Full: "zfs-kmod-2.3.0-rc1"},
+		},
+	},
+	{
+		name: "Kernel module not loaded",
+		out:  "zfs-2.2.6-1\n",
+		want: &VersionInfo{
+			Userland: Version{Major: 2, Minor: 2, Patch: 6, Full: "zfs-2.2.6-1"},
+		},
+	},
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, test := range parseVersionTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseVersion(tc.out)
+			if err != nil {
+				t.Errorf("parseVersion()\nTest Case: %q\nFailure: err != nil\nReason: %v", tc.name, err)
+				return
+			}
+
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf(
+					"parseVersion()\nTest Case: %q\nFailure: got != want\nDiff:\n%s",
+					tc.name, cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestParseVersionErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, out := range []string{"", "zfs-kmod-2.1.5-1\n", "unrecognized command 'version'\n"} {
+		if _, err := parseVersion(out); err == nil {
+			t.Errorf("parseVersion()\nTest Case: %q\nFailure: err == nil", out)
+		}
+	}
+}
+
+var supportsTests = []struct {
+	name    string
+	version string
+	want    map[Capability]bool
+}{
+	{
+		name:    "OpenZFS 0.8",
+		version: "zfs-0.8.3-1\nzfs-kmod-0.8.3-1\n",
+		want: map[Capability]bool{
+			CapabilityJSON:             false,
+			CapabilityWait:             false,
+			CapabilityDRaid:            false,
+			CapabilityInitializeStatus: false,
+			CapabilityTrim:             true,
+			CapabilityRedact:           false,
+		},
+	},
+	{
+		name:    "OpenZFS 2.1",
+		version: "zfs-2.1.5-1\nzfs-kmod-2.1.5-1\n",
+		want: map[Capability]bool{
+			CapabilityJSON:             false,
+			CapabilityWait:             true,
+			CapabilityDRaid:            true,
+			CapabilityInitializeStatus: false,
+			CapabilityTrim:             true,
+			CapabilityRedact:           true,
+		},
+	},
+	{
+		name:    "OpenZFS 2.3",
+		version: "zfs-2.3.0-1\nzfs-kmod-2.3.0-1\n",
+		want: map[Capability]bool{
+			CapabilityJSON:             true,
+			CapabilityWait:             true,
+			CapabilityDRaid:            true,
+			CapabilityInitializeStatus: true,
+			CapabilityTrim:             true,
+			CapabilityRedact:           true,
+		},
+	},
+	{
+		name:    "Kernel module older than userland",
+		version: "zfs-2.1.5-1\nzfs-kmod-2.0.7-1\n",
+		want: map[Capability]bool{
+			CapabilityJSON:             false,
+			CapabilityWait:             true,
+			CapabilityDRaid:            false,
+			CapabilityInitializeStatus: false,
+			CapabilityTrim:             true,
+			CapabilityRedact:           true,
+		},
+	},
+}
+
+func TestSupports(t *testing.T) {
+	for _, test := range supportsTests {
+		tc := test
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			system := NewSystem(&SystemConfig{
+				Executor: stubExecutor{"zfs version": {Stdout: tc.version}},
+			})
+
+			for c, want := range tc.want {
+				got, err := system.Supports(c)
+				if err != nil {
+					t.Errorf("Supports()\nTest Case: %q\nFailure: err != nil\nReason: %v", tc.name, err)
+					return
+				}
+
+				if got != want {
+					t.Errorf("Supports(%q)\nTest Case: %q\nFailure: got %t, want %t", c, tc.name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCreatePoolDRaidUnsupported(t *testing.T) {
+	t.Parallel()
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{"zfs version": {Stdout: "zfs-2.0.7-1\nzfs-kmod-2.0.7-1\n"}},
+	})
+
+	layout := NewPoolLayout().Data(DRaidVdev(1, 2, 1, "sda", "sdb", "sdc", "sdd"))
+
+	_, err := system.CreatePool("tank", layout, nil, nil)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("CreatePool()\nTest Case: %q\nFailure: want ErrUnsupported\nGot: %v", "draid on OpenZFS 2.0", err)
+	}
+}
+
+func TestVersionDetectedOnce(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewRecordingExecutor(stubExecutor{
+		"zfs version":    {Stdout: "zfs-2.3.0-1\nzfs-kmod-2.3.0-1\n"},
+		testZpoolListCmd: {Stdout: testPoolInfo("tank", nil)},
+		"zpool list -j -p -o name,guid,size,allocated,free,fragmentation,health,altroot": {Stdout: testJSONZpoolList},
+		"zpool status -j -p tank": {Stdout: testJSONZpoolStatus},
+	}, filepath.Join(t.TempDir(), "recording.json"))
+	system := NewSystem(&SystemConfig{Executor: recorder})
+
+	if _, err := system.Version(); err != nil {
+		t.Fatalf("Version()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Shared detection", err)
+	}
+
+	pools, err := system.ListPools()
+	if err != nil {
+		t.Fatalf("ListPools()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Shared detection", err)
+	}
+
+	if _, err := pools[0].Status(); err != nil {
+		t.Fatalf("Status()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Shared detection", err)
+	}
+
+	probes := 0
+
+	for _, c := range recorder.Recording().Commands {
+		if strings.Join(c.Args, " ") == "zfs version" {
+			probes++
+		}
+	}
+
+	if probes != 1 {
+		t.Errorf(
+			"Version()\nTest Case: %q\nFailure: 'zfs version' ran %d times, want once",
+			"Shared detection", probes)
+	}
+}
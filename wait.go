@@ -0,0 +1,246 @@
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// WaitDiscardCheckpoint waits for the checkpoint to be discarded.
+	WaitDiscardCheckpoint WaitActivity = "discard"
+	// WaitFree waits for the background freeing of the destroyed datasets.
+	WaitFree WaitActivity = "free"
+	// WaitInitialize waits for the devices to stop initializing.
+	WaitInitialize WaitActivity = "initialize"
+	// WaitReplace waits for the replacements of the devices to complete.
+	WaitReplace WaitActivity = "replace"
+	// WaitRemove waits for the removal of the devices to complete.
+	WaitRemove WaitActivity = "remove"
+	// WaitResilver waits for the resilver to complete.
+	WaitResilver WaitActivity = "resilver"
+	// WaitScrub waits for the scrub to complete or to be paused.
+	WaitScrub WaitActivity = "scrub"
+	// WaitTrim waits for the devices to stop trimming.
+	WaitTrim WaitActivity = "trim"
+)
+
+var (
+	// Activities waited for when none are specified, in the order listed by
+	// 'zpool wait'.
+	allWaitActivities = []WaitActivity{
+		WaitDiscardCheckpoint,
+		WaitFree,
+		WaitInitialize,
+		WaitReplace,
+		WaitRemove,
+		WaitResilver,
+		WaitScrub,
+		WaitTrim,
+	}
+
+	// Interval between the status checks of versions without 'zpool wait'.
+	waitPollInterval = 5 * time.Second
+)
+
+// WaitActivity represents a background activity of a pool, named as
+// expected by 'zpool wait -t'.
+type WaitActivity string
+
+// Wait blocks until none of the activities (or none of the background
+// activities if none are specified) are in progress on the pool, or ctx is
+// done. Versions of zfs without 'zpool wait' are polled using the status of
+// the pool.
+func (p *Pool) Wait(ctx context.Context, activities ...WaitActivity) error {
+	if len(activities) == 0 {
+		activities = allWaitActivities
+	}
+
+	for _, a := range activities {
+		if !isWaitActivity(a) {
+			return fmt.Errorf("failed to wait for pool %q, reason: unknown activity %q", p, a)
+		}
+	}
+
+	// Versions older than 0.8 cannot report their version, and do not
+	// support 'zpool wait' either.
+	if ok, _ := p.System.Supports(CapabilityWait); !ok {
+		return p.pollWait(ctx, activities)
+	}
+
+	names := make([]string, len(activities))
+	for i, a := range activities {
+		names[i] = string(a)
+	}
+
+	if _, err := p.cmd().zpool.wait(ctx, p.Name, names); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to wait for pool %q, reason: %w", p, ctx.Err())
+		}
+
+		return fmt.Errorf("failed to wait for pool %q, reason: %w", p, err)
+	}
+
+	return nil
+}
+
+// Wait blocks until the file system has no pending deletions, i.e. the
+// files that were removed while still open are freed, or ctx is done.
+func (f *FileSystem) Wait(ctx context.Context) error {
+	if err := f.Pool.System.requireCapability(CapabilityWait); err != nil {
+		return fmt.Errorf("failed to wait for file system %q, reason: %w", f.FullName(), err)
+	}
+
+	if _, err := f.cmd().zfs.wait(ctx, f.FullName()); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to wait for file system %q, reason: %w", f.FullName(), ctx.Err())
+		}
+
+		return fmt.Errorf("failed to wait for file system %q, reason: %w", f.FullName(), err)
+	}
+
+	return nil
+}
+
+// pollWait checks the status of the pool periodically until none of the
+// activities are in progress, or ctx is done.
+func (p *Pool) pollWait(ctx context.Context, activities []WaitActivity) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		busy, err := p.activitiesInProgress(activities)
+		if err != nil {
+			return err
+		}
+
+		if !busy {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for pool %q, reason: %w", p, ctx.Err())
+		}
+	}
+}
+
+// activitiesInProgress returns true if any of the activities is in progress
+// on the pool.
+func (p *Pool) activitiesInProgress(activities []WaitActivity) (bool, error) {
+	// The versions older than 0.8 cannot report their version, and do not
+	// support -t either.
+	trim, _ := p.System.Supports(CapabilityTrim)
+
+	out, err := p.progressStatus(trim)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := parsePoolStatus(p.Name, out)
+	if err != nil {
+		return false, err
+	}
+
+	for _, a := range activities {
+		var busy bool
+
+		switch a {
+		case WaitFree:
+			freeing, err := p.GetProp("freeing")
+			if err != nil {
+				return false, err
+			}
+
+			busy = freeing != "0" && freeing != "-"
+		case WaitRemove:
+			busy = isRemoving(out)
+		default:
+			busy, err = statusActivityInProgress(status, a)
+			if err != nil {
+				return false, fmt.Errorf("failed to get status of pool %q, reason: %w", p, err)
+			}
+		}
+
+		if busy {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// statusActivityInProgress returns true if the activity is in progress as
+// reported by the status of the pool. The removal and freeing are not
+// reported by the parsed status.
+func statusActivityInProgress(status *PoolStatus, a WaitActivity) (bool, error) {
+	switch a {
+	case WaitScrub, WaitResilver:
+		scan, err := status.ScanStatus()
+		if err != nil {
+			return false, err
+		}
+
+		if scan.Function != string(a) || !scan.InProgress {
+			return false, nil
+		}
+
+		// A paused scrub is no longer waited for.
+		return a == WaitResilver || !scan.Paused, nil
+	case WaitDiscardCheckpoint:
+		return status.Checkpoint == "discarding", nil
+	case WaitReplace:
+		for _, v := range status.Vdevs.All() {
+			if strings.HasPrefix(v.Name, "replacing-") {
+				return true, nil
+			}
+		}
+	case WaitTrim, WaitInitialize:
+		for _, v := range status.Vdevs.All() {
+			if len(v.Children) > 0 {
+				continue
+			}
+
+			progress, err := parseVdevProgress(v.Name, v.Message)
+			if err != nil {
+				return false, err
+			}
+
+			activity := progress.Initialize
+			if a == WaitTrim {
+				activity = progress.Trim
+			}
+
+			if activity != nil && activity.State == VdevActivityActive {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// isRemoving returns true if the text output of 'zpool status' reports a
+// device removal in progress, e.g.
+// "remove: Evacuation of /dev/sdb in progress since Sun Oct 11 00:24:01 2026".
+func isRemoving(out string) bool {
+	blocks := parseStatusBlocks(out)
+	if len(blocks) != 1 {
+		return false
+	}
+
+	return strings.Contains(blocks[0].value("remove"), "in progress since")
+}
+
+// isWaitActivity returns true if the activity is known to 'zpool wait'.
+func isWaitActivity(a WaitActivity) bool {
+	for _, known := range allWaitActivities {
+		if a == known {
+			return true
+		}
+	}
+
+	return false
+}
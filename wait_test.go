@@ -0,0 +1,128 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const waitTestStatus = "  pool: tank\n" +
+	" state: ONLINE\n" +
+	"  scan: resilver in progress since Sun Oct 18 10:00:00 2026\n" +
+	"\t1.20G scanned at 100M/s, 600M issued at 50M/s, 10G total\n" +
+	"remove: Evacuation of /dev/sdd in progress since Sun Oct 18 09:00:00 2026\n" +
+	"config:\n\n" +
+	"\tNAME             STATE     READ WRITE CKSUM\n" +
+	"\ttank             ONLINE       0     0     0\n" +
+	"\t  mirror-0       ONLINE       0     0     0\n" +
+	"\t    sda          ONLINE       0     0     0  (15% trimmed, started at Sun Oct 11 00:24:01 2026)\n" +
+	"\t    replacing-1  ONLINE       0     0     0\n" +
+	"\t      sdb        ONLINE       0     0     0  (untrimmed)\n" +
+	"\t      sdc        ONLINE       0     0     0  (untrimmed)\n\n" +
+	"errors: No known data errors\n"
+
+func TestStatusActivityInProgress(t *testing.T) {
+	t.Parallel()
+
+	status, err := parsePoolStatus("tank", waitTestStatus)
+	if err != nil {
+		t.Fatalf("parsePoolStatus()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Busy pool", err)
+	}
+
+	tests := []struct {
+		activity WaitActivity
+		want     bool
+	}{
+		{WaitResilver, true},
+		{WaitScrub, false},
+		{WaitReplace, true},
+		{WaitTrim, true},
+		{WaitInitialize, false},
+		{WaitDiscardCheckpoint, false},
+	}
+
+	for _, tc := range tests {
+		got, err := statusActivityInProgress(status, tc.activity)
+		if err != nil {
+			t.Fatalf("statusActivityInProgress()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", tc.activity, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("statusActivityInProgress()\nTest Case: %q\nFailure: got %t, want %t", tc.activity, got, tc.want)
+		}
+	}
+
+	if !isRemoving(waitTestStatus) {
+		t.Errorf("isRemoving()\nTest Case: %q\nFailure: got false, want true", "Evacuation in progress")
+	}
+}
+
+func TestPoolWait(t *testing.T) {
+	t.Parallel()
+
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zfs version":                       {Stdout: "zfs-2.2.6-1\nzfs-kmod-2.2.6-1\n"},
+			"zpool wait -t resilver,scrub tank": {},
+			"zfs wait -t deleteq tank/home":     {},
+		},
+		DisableJSON: true,
+	})
+	pool := &Pool{Name: "tank", System: system}
+
+	if err := pool.Wait(context.Background(), WaitResilver, WaitScrub); err != nil {
+		t.Errorf("Wait()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "zpool wait", err)
+	}
+
+	if err := pool.Wait(context.Background(), "rebuild"); err == nil {
+		t.Errorf("Wait()\nTest Case: %q\nFailure: gotErr == nil", "Unknown activity")
+	}
+
+	fs := &FileSystem{Name: "home", Pool: pool}
+	if err := fs.Wait(context.Background()); err != nil {
+		t.Errorf("FileSystem.Wait()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "zfs wait", err)
+	}
+}
+
+func TestPoolWaitPolling(t *testing.T) {
+	t.Parallel()
+
+	// 'zfs version' is not supported by the versions older than 0.8.
+	system := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zpool status -p tank": {Stdout: waitTestStatus},
+		},
+		DisableJSON: true,
+	})
+	pool := &Pool{Name: "tank", System: system}
+
+	if err := pool.Wait(context.Background(), WaitScrub, WaitInitialize); err != nil {
+		t.Errorf("Wait()\nTest Case: %q\nFailure: gotErr != nil\nReason: %v", "Idle activities", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := pool.Wait(ctx, WaitResilver); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait()\nTest Case: %q\nFailure: got %v, want %v", "Resilver in progress", err, context.Canceled)
+	}
+
+	fs := &FileSystem{Name: "home", Pool: pool}
+	if err := fs.Wait(context.Background()); err == nil {
+		t.Errorf("FileSystem.Wait()\nTest Case: %q\nFailure: gotErr == nil", "zfs wait not supported")
+	}
+	// OpenZFS 0.8 reports the trim progress, but does not support 'zpool
+	// wait' either.
+	trimSystem := NewSystem(&SystemConfig{
+		Executor: stubExecutor{
+			"zfs version":             {Stdout: "zfs-0.8.6-1\nzfs-kmod-0.8.6-1\n"},
+			"zpool status -t -p tank": {Stdout: waitTestStatus},
+		},
+		DisableJSON: true,
+	})
+	pool = &Pool{Name: "tank", System: trimSystem}
+
+	if err := pool.Wait(ctx, WaitTrim); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait()\nTest Case: %q\nFailure: got %v, want %v", "Trim in progress", err, context.Canceled)
+	}
+}
@@ -0,0 +1,93 @@
+package zfstest
+
+import (
+	"strings"
+)
+
+// cmdArgs represents the parsed arguments of a subcommand.
+type cmdArgs struct {
+	// Values of the flags by the flag letter, boolean flags have an empty
+	// value for each occurrence.
+	flags map[byte][]string
+	// Positional arguments.
+	pos []string
+}
+
+// parseArgs parses the arguments of a subcommand similar to getopt, where
+// valueFlags are the letters of the flags that accept a value.
+func parseArgs(args []string, valueFlags string) (*cmdArgs, error) {
+	result := &cmdArgs{flags: make(map[byte][]string)}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' {
+			result.pos = append(result.pos, arg)
+			continue
+		}
+
+		for j := 1; j < len(arg); j++ {
+			flag := arg[j]
+			if !strings.ContainsRune(valueFlags, rune(flag)) {
+				result.flags[flag] = append(result.flags[flag], "")
+				continue
+			}
+
+			val := arg[j+1:]
+			if val == "" {
+				i++
+				if i >= len(args) {
+					return nil, usageError("missing argument for '-%c' option", flag)
+				}
+
+				val = args[i]
+			}
+
+			result.flags[flag] = append(result.flags[flag], val)
+
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// has returns true if the flag was specified.
+func (a *cmdArgs) has(flag byte) bool {
+	return len(a.flags[flag]) > 0
+}
+
+// value returns the last value of the flag, or an empty string.
+func (a *cmdArgs) value(flag byte) string {
+	vals := a.flags[flag]
+	if len(vals) == 0 {
+		return ""
+	}
+
+	return vals[len(vals)-1]
+}
+
+// props returns the "prop=value" values of the flag as a map.
+func (a *cmdArgs) props(flag byte) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, kv := range a.flags[flag] {
+		k, v, found := strings.Cut(kv, "=")
+		if !found {
+			return nil, usageError("missing '=' for property=value argument")
+		}
+
+		result[k] = v
+	}
+
+	return result, nil
+}
+
+// list returns the comma separated values of the flag.
+func (a *cmdArgs) list(flag byte) []string {
+	var result []string
+	for _, v := range a.flags[flag] {
+		result = append(result, strings.Split(v, ",")...)
+	}
+
+	return result
+}
@@ -0,0 +1,135 @@
+package zfstest
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	checkpointTimestampLayout = "Mon Jan _2 15:04:05 2006"
+
+	rewindToCheckpointArg = "--rewind-to-checkpoint"
+)
+
+// checkpoint represents the checkpoint of a simulated pool.
+type checkpoint struct {
+	created time.Time
+	// Copy of the file systems and snapshots at the time of the checkpoint.
+	datasets map[string]*dataset
+	// True if the checkpoint is being discarded.
+	discarding bool
+}
+
+// CompleteCheckpointDiscard completes discarding the checkpoint of the
+// pool, which is otherwise reported as being discarded until the next
+// 'zpool checkpoint'.
+func (s *Sim) CompleteCheckpointDiscard(poolName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.lookupPool(poolName)
+	if err != nil {
+		return err
+	}
+
+	if p.checkpoint != nil && p.checkpoint.discarding {
+		p.checkpoint = nil
+	}
+
+	return nil
+}
+
+func (s *Sim) zpoolCheckpoint(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing pool argument")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	if a.has('d') {
+		if p.checkpoint == nil {
+			return "", cmdError("cannot discard checkpoint in '%s': checkpoint does not exist", p.name)
+		}
+
+		if p.checkpoint.discarding {
+			return "", cmdError("cannot discard checkpoint in '%s': checkpoint is being discarded", p.name)
+		}
+
+		p.checkpoint.discarding = true
+
+		return "", nil
+	}
+
+	if p.checkpoint != nil {
+		if p.checkpoint.discarding {
+			return "", cmdError("cannot checkpoint '%s': checkpoint is being discarded", p.name)
+		}
+
+		return "", cmdError("cannot checkpoint '%s': checkpoint exists", p.name)
+	}
+
+	datasets := make(map[string]*dataset, len(p.datasets))
+	for name, d := range p.datasets {
+		datasets[name] = d.clone()
+	}
+
+	p.checkpoint = &checkpoint{created: s.now(), datasets: datasets}
+
+	return "", nil
+}
+
+// rewindToCheckpoint restores the file systems and snapshots of the pool
+// to the checkpoint, and removes the checkpoint.
+func (p *pool) rewindToCheckpoint() error {
+	if p.checkpoint == nil || p.checkpoint.discarding {
+		return cmdError("cannot import '%s': checkpoint does not exist", p.name)
+	}
+
+	p.datasets = p.checkpoint.datasets
+	p.checkpoint = nil
+
+	return nil
+}
+
+// checkpointStatus returns the checkpoint status of the pool as reported
+// by 'zpool status', empty if the pool has no checkpoint.
+func (p *pool) checkpointStatus() string {
+	switch {
+	case p.checkpoint == nil:
+		return ""
+	case p.checkpoint.discarding:
+		return "discarding"
+	}
+
+	val, _ := p.get("checkpoint")
+	space, _ := strconv.ParseUint(val, 10, 64)
+
+	return "created " + p.checkpoint.created.Format(checkpointTimestampLayout) + ", consumes " + niceNum(space)
+}
+
+// stripArg returns the arguments without the specified argument, and
+// whether the argument was found.
+func stripArg(args []string, arg string) ([]string, bool) {
+	var result []string
+
+	found := false
+
+	for _, a := range args {
+		if a == arg {
+			found = true
+			continue
+		}
+
+		result = append(result, a)
+	}
+
+	return result, found
+}
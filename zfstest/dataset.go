@@ -0,0 +1,967 @@
+package zfstest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuxdude/zfs"
+)
+
+const (
+	typeFileSystem = "filesystem"
+	typeSnapshot   = "snapshot"
+
+	sourceLocal   = "local"
+	sourceDefault = "default"
+	sourceNone    = "-"
+)
+
+var (
+	// Default values of the native properties.
+	datasetPropDefaults = map[string]string{
+		"aclinherit":           "restricted",
+		"aclmode":              "discard",
+		"acltype":              "off",
+		"atime":                "on",
+		"canmount":             "on",
+		"casesensitivity":      "sensitive",
+		"checksum":             "on",
+		"compression":          "off",
+		"copies":               "1",
+		"dedup":                "off",
+		"devices":              "on",
+		"dnodesize":            "legacy",
+		"encryption":           "off",
+		"exec":                 "on",
+		"logbias":              "latency",
+		"normalization":        "none",
+		"overlay":              "on",
+		"pbkdf2iters":          "0",
+		"primarycache":         "all",
+		"readonly":             "off",
+		"recordsize":           "131072",
+		"redundant_metadata":   "all",
+		"relatime":             "on",
+		"secondarycache":       "all",
+		"setuid":               "on",
+		"sharenfs":             "off",
+		"sharesmb":             "off",
+		"snapdev":              "hidden",
+		"snapdir":              "hidden",
+		"special_small_blocks": "0",
+		"sync":                 "standard",
+		"utf8only":             "off",
+		"version":              "5",
+		"volmode":              "default",
+		"xattr":                "on",
+	}
+	// Properties that are not inherited by the descendants.
+	datasetPropNotInherited = map[string]bool{
+		"canmount":       true,
+		"quota":          true,
+		"refquota":       true,
+		"reservation":    true,
+		"refreservation": true,
+	}
+	// Properties that only apply to file systems.
+	datasetPropFileSystemOnly = map[string]bool{
+		"available":            true,
+		"canmount":             true,
+		"mounted":              true,
+		"mountpoint":           true,
+		"quota":                true,
+		"refquota":             true,
+		"reservation":          true,
+		"refreservation":       true,
+		"usedbychildren":       true,
+		"usedbydataset":        true,
+		"usedbysnapshots":      true,
+		"usedbyrefreservation": true,
+	}
+	// Properties that cannot be set using 'zfs set'.
+	datasetPropReadOnly = map[string]bool{
+		"available":            true,
+		"clones":               true,
+		"compressratio":        true,
+		"creation":             true,
+		"createtxg":            true,
+		"encryption":           true,
+		"encryptionroot":       true,
+		"guid":                 true,
+		"keystatus":            true,
+		"logicalused":          true,
+		"mounted":              true,
+		"name":                 true,
+		"origin":               true,
+		"referenced":           true,
+		"type":                 true,
+		"used":                 true,
+		"usedbychildren":       true,
+		"usedbydataset":        true,
+		"usedbyrefreservation": true,
+		"usedbysnapshots":      true,
+		"userrefs":             true,
+		"written":              true,
+	}
+	// Properties that are byte sizes, which are formatted for human
+	// consumption unless the exact values are requested.
+	datasetPropSizes = map[string]bool{
+		"available":            true,
+		"logicalused":          true,
+		"quota":                true,
+		"recordsize":           true,
+		"referenced":           true,
+		"refquota":             true,
+		"refreservation":       true,
+		"reservation":          true,
+		"used":                 true,
+		"usedbychildren":       true,
+		"usedbydataset":        true,
+		"usedbyrefreservation": true,
+		"usedbysnapshots":      true,
+		"written":              true,
+	}
+	datasetPropAliases = map[string]string{
+		"avail":     "available",
+		"lused":     "logicalused",
+		"ratio":     "compressratio",
+		"refer":     "referenced",
+		"refreserv": "refreservation",
+		"reserv":    "reservation",
+	}
+	// Prefixes of the user, group and project quotas that are byte sizes.
+	datasetEntitySizePropPrefixes = []string{
+		"userquota@",
+		"groupquota@",
+		"projectquota@",
+	}
+	// Prefixes of the user, group and project accounting properties.
+	datasetEntityPropPrefixes = []string{
+		"userquota@",
+		"groupquota@",
+		"projectquota@",
+		"userobjquota@",
+		"groupobjquota@",
+		"projectobjquota@",
+		"userused@",
+		"groupused@",
+		"projectused@",
+	}
+)
+
+// dataset represents a simulated file system or snapshot.
+type dataset struct {
+	name     string
+	pool     *pool
+	snapshot bool
+	guid     uint64
+	txg      uint64
+	creation time.Time
+	// Locally set properties, including the simulated space usage.
+	props map[string]string
+	// Full name of the origin snapshot for clones.
+	origin string
+	// Holds on the snapshot by tag.
+	holds map[string]time.Time
+
+	mounted bool
+	busy    bool
+
+	// True if this is an encryption root.
+	encRoot bool
+	// Key material of an encryption root, any key is accepted if empty.
+	key       string
+	keyLoaded bool
+
+	// Space used by the users, groups and projects by zfs subcommand (e.g.
+	// "userspace") and name.
+	space map[string]map[string]*entitySpace
+	// Changes to the files of the file system in the order made.
+	changes []*fileChange
+}
+
+// clone returns a copy of the dataset, e.g. for the checkpoint of the pool.
+func (d *dataset) clone() *dataset {
+	result := *d
+
+	result.props = make(map[string]string, len(d.props))
+	for k, v := range d.props {
+		result.props[k] = v
+	}
+
+	result.holds = make(map[string]time.Time, len(d.holds))
+	for k, v := range d.holds {
+		result.holds[k] = v
+	}
+
+	result.space = make(map[string]map[string]*entitySpace, len(d.space))
+	for sub, entities := range d.space {
+		result.space[sub] = make(map[string]*entitySpace, len(entities))
+		for name, usage := range entities {
+			u := *usage
+			result.space[sub][name] = &u
+		}
+	}
+
+	result.changes = append([]*fileChange{}, d.changes...)
+
+	return &result
+}
+
+// propValue represents the value of a property along with its source.
+type propValue struct {
+	value  string
+	source string
+	// True if the value is a byte size or a number.
+	numeric bool
+}
+
+// CreateFileSystem creates a file system with the specified full name and
+// properties. The parent file system must exist. Setting the "encryption"
+// property makes the file system an encryption root, with the key loaded.
+func (s *Sim) CreateFileSystem(name string, props map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.poolOf(name)
+	if !ok || strings.Contains(name, "@") {
+		return fmt.Errorf("cannot create %q: no such pool or invalid name", name)
+	}
+
+	parent, ok := p.datasets[parentName(name)]
+	if !ok || parent.snapshot {
+		return fmt.Errorf("cannot create %q: parent does not exist", name)
+	}
+
+	_, err := s.createFileSystem(p, name, props)
+
+	return err
+}
+
+// Snapshot creates a snapshot with the specified full name (e.g.
+// "tank/home@daily"), along with the snapshots of the same name of all the
+// descendant file systems if recursive is true.
+func (s *Sim) Snapshot(name string, recursive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fsName, snapName, found := strings.Cut(name, "@")
+	if !found || snapName == "" {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	fs, ok := s.dataset(fsName)
+	if !ok || fs.snapshot {
+		return fmt.Errorf("cannot create snapshot %q: dataset does not exist", name)
+	}
+
+	targets := []*dataset{fs}
+	if recursive {
+		targets = fs.pool.descendants(fs, false)
+	}
+
+	for _, t := range targets {
+		if _, ok := t.pool.datasets[t.name+"@"+snapName]; ok {
+			return fmt.Errorf("cannot create snapshot %q: dataset already exists", t.name+"@"+snapName)
+		}
+	}
+
+	// All the snapshots of a recursive snapshot share the same creation
+	// time and transaction group.
+	now := s.now()
+	txg := s.txg()
+
+	for _, t := range targets {
+		snap := &dataset{
+			name:     t.name + "@" + snapName,
+			pool:     t.pool,
+			snapshot: true,
+			guid:     s.guid(),
+			txg:      txg,
+			creation: now,
+			props: map[string]string{
+				"referenced": t.propOrDefault("referenced", "0"),
+			},
+			holds: make(map[string]time.Time),
+		}
+		t.pool.datasets[snap.name] = snap
+	}
+
+	return nil
+}
+
+// Hold places a hold with the specified tag on the snapshot, along with the
+// snapshots of the same name of all the descendant file systems if
+// recursive is true.
+func (s *Sim) Hold(snapshot string, tag string, recursive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, ok := s.dataset(snapshot)
+	if !ok || !snap.snapshot {
+		return fmt.Errorf("cannot hold %q: snapshot does not exist", snapshot)
+	}
+
+	targets := []*dataset{snap}
+
+	if recursive {
+		fsName, snapName, _ := strings.Cut(snapshot, "@")
+		fs := snap.pool.datasets[fsName]
+
+		targets = nil
+
+		for _, d := range snap.pool.descendants(fs, false) {
+			if t, ok := snap.pool.datasets[d.name+"@"+snapName]; ok {
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	now := s.now()
+
+	for _, t := range targets {
+		if _, ok := t.holds[tag]; ok {
+			return fmt.Errorf("cannot hold %q: tag already exists on this dataset", t.name)
+		}
+	}
+
+	for _, t := range targets {
+		t.holds[tag] = now
+	}
+
+	return nil
+}
+
+// Release removes the hold with the specified tag from the snapshot.
+func (s *Sim) Release(snapshot string, tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, ok := s.dataset(snapshot)
+	if !ok || !snap.snapshot {
+		return fmt.Errorf("cannot release hold from %q: snapshot does not exist", snapshot)
+	}
+
+	if _, ok := snap.holds[tag]; !ok {
+		return fmt.Errorf("cannot release hold from %q: no such tag on this dataset", snapshot)
+	}
+
+	delete(snap.holds, tag)
+
+	return nil
+}
+
+// SetProp sets the property of the file system or snapshot, including the
+// read-only properties such as "used", "referenced" or "written" which are
+// used to simulate the space usage.
+func (s *Sim) SetProp(name string, prop string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ds, ok := s.dataset(name)
+	if !ok {
+		return fmt.Errorf("cannot set property for %q: dataset does not exist", name)
+	}
+
+	prop = canonicalProp(prop)
+
+	value, err := normalizeProp(prop, value)
+	if err != nil {
+		return fmt.Errorf("cannot set property for %q: %w", name, err)
+	}
+
+	ds.props[prop] = value
+
+	return nil
+}
+
+// SetKey sets the key material of the encryption root, which must be
+// supplied to load the key. Any key is accepted for encryption roots
+// without key material.
+func (s *Sim) SetKey(name string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ds, ok := s.dataset(name)
+	if !ok || !ds.encRoot {
+		return fmt.Errorf("%q is not an encryption root", name)
+	}
+
+	ds.key = key
+
+	return nil
+}
+
+// SetBusy marks the file system as busy (or not), which fails unmounting
+// the file system without forcing it.
+func (s *Sim) SetBusy(name string, busy bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ds, ok := s.dataset(name)
+	if !ok || ds.snapshot {
+		return fmt.Errorf("file system %q does not exist", name)
+	}
+
+	ds.busy = busy
+
+	return nil
+}
+
+func (s *Sim) createFileSystem(p *pool, name string, props map[string]string) (*dataset, error) {
+	if _, ok := p.datasets[name]; ok {
+		return nil, cmdError("cannot create '%s': dataset already exists", name)
+	}
+
+	ds := &dataset{
+		name:     name,
+		pool:     p,
+		guid:     s.guid(),
+		txg:      s.txg(),
+		creation: s.now(),
+		props:    make(map[string]string),
+	}
+
+	for k, v := range props {
+		k = canonicalProp(k)
+
+		v, err := normalizeProp(k, v)
+		if err != nil {
+			return nil, cmdError("cannot create '%s': %v", name, err)
+		}
+
+		ds.props[k] = v
+	}
+
+	if enc, ok := ds.props["encryption"]; ok && enc != "off" {
+		if enc == "on" {
+			ds.props["encryption"] = "aes-256-gcm"
+		}
+
+		ds.encRoot = true
+		ds.keyLoaded = true
+
+		if _, ok := ds.props["keyformat"]; !ok {
+			ds.props["keyformat"] = "passphrase"
+		}
+
+		if _, ok := ds.props["keylocation"]; !ok {
+			ds.props["keylocation"] = "prompt"
+		}
+	}
+
+	p.datasets[name] = ds
+
+	if ds.canMount() {
+		ds.mounted = true
+	}
+
+	return ds, nil
+}
+
+// canMount returns true if the file system can be mounted automatically.
+func (d *dataset) canMount() bool {
+	if d.snapshot || d.get("canmount").value != "on" {
+		return false
+	}
+
+	mp := d.get("mountpoint").value
+	if mp == "none" || mp == "legacy" {
+		return false
+	}
+
+	return d.get("keystatus").value != "unavailable"
+}
+
+// parent returns the parent file system, or the file system of a
+// snapshot, or nil for the root file system.
+func (d *dataset) parent() *dataset {
+	if d.snapshot {
+		fsName, _, _ := strings.Cut(d.name, "@")
+		return d.pool.datasets[fsName]
+	}
+
+	if !strings.Contains(d.name, "/") {
+		return nil
+	}
+
+	return d.pool.datasets[parentName(d.name)]
+}
+
+// encryptionRoot returns the encryption root the dataset inherits its key
+// from, or nil if the dataset is not encrypted.
+func (d *dataset) encryptionRoot() *dataset {
+	for cur := d; cur != nil; cur = cur.parent() {
+		if cur.encRoot {
+			return cur
+		}
+	}
+
+	return nil
+}
+
+func (d *dataset) propOrDefault(prop string, def string) string {
+	if val, ok := d.props[prop]; ok {
+		return val
+	}
+
+	return def
+}
+
+// sizeProp returns the numeric value of the locally set property, or zero.
+func (d *dataset) sizeProp(prop string) uint64 {
+	val, err := strconv.ParseUint(d.props[prop], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return val
+}
+
+// get returns the value of the property, or an empty source if the
+// property is invalid.
+func (d *dataset) get(prop string) propValue {
+	prop = canonicalProp(prop)
+
+	if d.snapshot && datasetPropFileSystemOnly[prop] {
+		return propValue{value: "-", source: sourceNone}
+	}
+
+	if val, ok := d.computed(prop); ok {
+		return val
+	}
+
+	if strings.Contains(prop, "@") {
+		return d.entityProp(prop)
+	}
+
+	if val, ok := d.props[prop]; ok {
+		return propValue{value: val, source: sourceLocal, numeric: datasetPropSizes[prop]}
+	}
+
+	if !datasetPropNotInherited[prop] {
+		for cur := d.parent(); cur != nil; cur = cur.parent() {
+			if val, ok := cur.props[prop]; ok {
+				return propValue{
+					value:   val,
+					source:  "inherited from " + cur.name,
+					numeric: datasetPropSizes[prop],
+				}
+			}
+		}
+	}
+
+	if def, ok := datasetPropDefaults[prop]; ok {
+		return propValue{value: def, source: sourceDefault, numeric: datasetPropSizes[prop]}
+	}
+
+	if strings.Contains(prop, ":") {
+		// Unset user properties.
+		return propValue{value: "-", source: sourceNone}
+	}
+
+	return propValue{}
+}
+
+// computed returns the value of the properties derived from the simulated
+// state rather than set by the user.
+func (d *dataset) computed(prop string) (propValue, bool) {
+	str := func(val string) (propValue, bool) {
+		return propValue{value: val, source: sourceNone}, true
+	}
+
+	size := func(val uint64) (propValue, bool) {
+		if _, ok := d.props[prop]; ok {
+			return propValue{value: d.props[prop], source: sourceNone, numeric: true}, true
+		}
+
+		return propValue{value: strconv.FormatUint(val, 10), source: sourceNone, numeric: true}, true
+	}
+
+	switch prop {
+	case "name":
+		return str(d.name)
+	case "type":
+		if d.snapshot {
+			return str(typeSnapshot)
+		}
+
+		return str(typeFileSystem)
+	case "guid":
+		return str(strconv.FormatUint(d.guid, 10))
+	case "createtxg":
+		return str(strconv.FormatUint(d.txg, 10))
+	case "creation":
+		return str(strconv.FormatInt(d.creation.Unix(), 10))
+	case "origin":
+		if d.origin == "" {
+			return str("-")
+		}
+
+		return str(d.origin)
+	case "clones":
+		if !d.snapshot {
+			return str("-")
+		}
+
+		return str(strings.Join(d.pool.clonesOf(d.name), ","))
+	case "userrefs":
+		if !d.snapshot {
+			return str("-")
+		}
+
+		return str(strconv.Itoa(len(d.holds)))
+	case "mounted":
+		if d.mounted {
+			return str("yes")
+		}
+
+		return str("no")
+	case "mountpoint":
+		return d.mountpoint(), true
+	case "compressratio":
+		return str(d.propOrDefault(prop, "1.00"))
+	case "used":
+		return size(d.used())
+	case "usedbydataset":
+		return size(d.usedByDataset())
+	case "usedbysnapshots":
+		return size(d.usedBySnapshots())
+	case "usedbychildren":
+		return size(d.usedByChildren())
+	case "usedbyrefreservation":
+		return size(0)
+	case "referenced", "written":
+		return size(0)
+	case "logicalused":
+		return size(d.used())
+	case "available":
+		return size(d.available())
+	case "encryption":
+		if root := d.encryptionRoot(); root != nil {
+			return propValue{value: root.props["encryption"], source: sourceNone}, true
+		}
+
+		return str("off")
+	case "encryptionroot":
+		if root := d.encryptionRoot(); root != nil {
+			return str(root.name)
+		}
+
+		return str("-")
+	case "keystatus":
+		root := d.encryptionRoot()
+		if root == nil {
+			return str("-")
+		}
+
+		if root.keyLoaded {
+			return str("available")
+		}
+
+		return str("unavailable")
+	case "keyformat":
+		if root := d.encryptionRoot(); root != nil {
+			return str(root.props["keyformat"])
+		}
+
+		return str("none")
+	case "keylocation":
+		if d.encRoot {
+			return propValue{value: d.props["keylocation"], source: sourceLocal}, true
+		}
+
+		return str("none")
+	case "quota", "refquota", "reservation", "refreservation":
+		if val, ok := d.props[prop]; ok {
+			return propValue{value: val, source: sourceLocal, numeric: true}, true
+		}
+
+		return propValue{value: "none", source: sourceDefault, numeric: true}, true
+	}
+
+	return propValue{}, false
+}
+
+// mountpoint returns the mountpoint, which is either set locally, or
+// derived from the nearest ancestor with a mountpoint set.
+func (d *dataset) mountpoint() propValue {
+	suffix := ""
+
+	for cur := d; cur != nil; cur = cur.parent() {
+		if mp, ok := cur.props["mountpoint"]; ok {
+			source := sourceLocal
+			if cur != d {
+				source = "inherited from " + cur.name
+			}
+
+			if mp != "none" && mp != "legacy" {
+				mp = strings.TrimSuffix(mp, "/") + suffix
+				if mp == "" {
+					mp = "/"
+				}
+			}
+
+			return propValue{value: mp, source: source}
+		}
+
+		suffix = "/" + cur.name[strings.LastIndex(cur.name, "/")+1:] + suffix
+	}
+
+	return propValue{value: "/" + d.name, source: sourceDefault}
+}
+
+func (d *dataset) entityProp(prop string) propValue {
+	for _, prefix := range datasetEntityPropPrefixes {
+		if strings.HasPrefix(prop, prefix) && len(prop) > len(prefix) {
+			def := "none"
+			if strings.Contains(prefix, "used@") {
+				def = "0"
+			}
+
+			return propValue{value: d.propOrDefault(prop, def), source: sourceLocal, numeric: true}
+		}
+	}
+
+	return propValue{}
+}
+
+func (d *dataset) usedByDataset() uint64 {
+	if _, ok := d.props["usedbydataset"]; ok {
+		return d.sizeProp("usedbydataset")
+	}
+
+	return d.sizeProp("referenced")
+}
+
+func (d *dataset) usedBySnapshots() uint64 {
+	var result uint64
+
+	for _, snap := range d.pool.snapshotsOf(d.name) {
+		result += snap.sizeProp("used")
+	}
+
+	return result
+}
+
+func (d *dataset) usedByChildren() uint64 {
+	var result uint64
+
+	for _, c := range d.pool.children(d.name) {
+		result += c.used()
+	}
+
+	return result
+}
+
+func (d *dataset) used() uint64 {
+	if _, ok := d.props["used"]; ok {
+		return d.sizeProp("used")
+	}
+
+	if d.snapshot {
+		return 0
+	}
+
+	return d.usedByDataset() + d.usedBySnapshots() + d.usedByChildren()
+}
+
+// available returns the available space, limited by the quotas of the file
+// system and its ancestors.
+func (d *dataset) available() uint64 {
+	result := d.pool.free()
+
+	for cur := d; cur != nil; cur = cur.parent() {
+		quota := cur.sizeProp("quota")
+		if quota == 0 {
+			continue
+		}
+
+		used := cur.used()
+		if used >= quota {
+			return 0
+		}
+
+		if quota-used < result {
+			result = quota - used
+		}
+	}
+
+	return result
+}
+
+// format returns the value of the property as output by zfs, i.e. exact
+// values if parsable is true, otherwise formatted for human consumption.
+func (v propValue) format(parsable bool) string {
+	if !v.numeric {
+		return v.value
+	}
+
+	if v.value == "none" || v.value == "" {
+		if parsable {
+			return "0"
+		}
+
+		return "none"
+	}
+
+	val, err := strconv.ParseUint(v.value, 10, 64)
+	if err != nil {
+		return v.value
+	}
+
+	if parsable {
+		return v.value
+	}
+
+	return niceNum(val)
+}
+
+// niceNum formats the number of bytes similar to zfs, e.g. "1.50K".
+func niceNum(val uint64) string {
+	const suffixes = "BKMGTPE"
+
+	if val < 1024 {
+		return strconv.FormatUint(val, 10)
+	}
+
+	idx := 0
+	n := float64(val)
+
+	for n >= 1024 && idx < len(suffixes)-1 {
+		n /= 1024
+		idx++
+	}
+
+	if val%(uint64(1)<<(10*idx)) == 0 {
+		return fmt.Sprintf("%d%c", val>>(10*idx), suffixes[idx])
+	}
+
+	var result string
+	for precision := 2; precision >= 0; precision-- {
+		result = fmt.Sprintf("%.*f%c", precision, n, suffixes[idx])
+		if len(result) <= 5 {
+			break
+		}
+	}
+
+	return result
+}
+
+func canonicalProp(prop string) string {
+	if alias, ok := datasetPropAliases[prop]; ok {
+		return alias
+	}
+
+	return prop
+}
+
+// isValidProp returns true if the property is a native, user or
+// accounting property.
+func isValidProp(prop string) bool {
+	prop = canonicalProp(prop)
+
+	if _, ok := datasetPropDefaults[prop]; ok {
+		return true
+	}
+
+	if datasetPropReadOnly[prop] || datasetPropSizes[prop] || datasetPropFileSystemOnly[prop] {
+		return true
+	}
+
+	switch prop {
+	case "keyformat", "keylocation", "createtxg":
+		return true
+	}
+
+	if strings.Contains(prop, ":") {
+		return true
+	}
+
+	for _, prefix := range datasetEntityPropPrefixes {
+		if strings.HasPrefix(prop, prefix) && len(prop) > len(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeProp returns the value of the property as stored by zfs, i.e.
+// the byte sizes accepted in human readable form (e.g. "10G") are stored as
+// the exact number of bytes.
+func normalizeProp(prop string, value string) (string, error) {
+	if value == "none" || !isSizeProp(prop) {
+		return value, nil
+	}
+
+	size, err := zfs.ParseByteSize(value)
+	if err != nil {
+		return "", fmt.Errorf("bad numeric value '%s'", value)
+	}
+
+	return strconv.FormatUint(uint64(size), 10), nil
+}
+
+// isSizeProp returns true if the value of the property is a byte size.
+func isSizeProp(prop string) bool {
+	if datasetPropSizes[prop] {
+		return true
+	}
+
+	for _, prefix := range datasetEntitySizePropPrefixes {
+		if strings.HasPrefix(prop, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parentName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+
+	return ""
+}
+
+// compareDatasets orders the datasets in the order listed by zfs, i.e. the
+// file systems depth first by name, each followed by its snapshots in the
+// order of creation.
+func compareDatasets(a *dataset, b *dataset) bool {
+	aFs, _, _ := strings.Cut(a.name, "@")
+	bFs, _, _ := strings.Cut(b.name, "@")
+
+	if aFs != bFs {
+		aParts := strings.Split(aFs, "/")
+		bParts := strings.Split(bFs, "/")
+
+		for i := 0; i < len(aParts) && i < len(bParts); i++ {
+			if aParts[i] != bParts[i] {
+				return aParts[i] < bParts[i]
+			}
+		}
+
+		return len(aParts) < len(bParts)
+	}
+
+	if a.snapshot != b.snapshot {
+		return !a.snapshot
+	}
+
+	if a.txg != b.txg {
+		return a.txg < b.txg
+	}
+
+	return a.name < b.name
+}
+
+func sortDatasets(list []*dataset) {
+	sort.Slice(list, func(i, j int) bool {
+		return compareDatasets(list[i], list[j])
+	})
+}
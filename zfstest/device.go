@@ -0,0 +1,89 @@
+package zfstest
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SetDeviceState sets the state of the device (e.g. "FAULTED" or
+// "REMOVED") of the pool, and posts a "resource.fs.zfs.statechange" event
+// if the state changed.
+func (s *Sim) SetDeviceState(poolName string, device string, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := vdevStateNums[state]; !ok {
+		return fmt.Errorf("invalid device state %q", state)
+	}
+
+	p, v, err := s.leafDevice(poolName, device)
+	if err != nil {
+		return err
+	}
+
+	s.setDeviceState(p, v, state)
+
+	return nil
+}
+
+// AddDeviceErrors increments the read, write and checksum error counters
+// of the device of the pool, and posts an "ereport.fs.zfs.io" or
+// "ereport.fs.zfs.checksum" event for the errors.
+func (s *Sim) AddDeviceErrors(poolName string, device string, read uint64, write uint64, checksum uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, v, err := s.leafDevice(poolName, device)
+	if err != nil {
+		return err
+	}
+
+	v.read += read
+	v.write += write
+	v.checksum += checksum
+
+	if read+write > 0 {
+		s.postEvent(eventClassIO, p, v, nil)
+	}
+
+	if checksum > 0 {
+		s.postEvent(eventClassChecksum, p, v, nil)
+	}
+
+	return nil
+}
+
+// leafDevice returns the pool with the specified name and its device.
+func (s *Sim) leafDevice(poolName string, device string) (*pool, *vdev, error) {
+	p, ok := s.pools[poolName]
+	if !ok {
+		return nil, nil, fmt.Errorf("pool %q does not exist", poolName)
+	}
+
+	v, _ := p.vdevs.find(device)
+	if v == nil || !v.isLeaf() {
+		return nil, nil, fmt.Errorf("device %q does not exist in pool %q", device, poolName)
+	}
+
+	return p, v, nil
+}
+
+// setDeviceState sets the state of the device, and posts a state change
+// event if the state changed.
+func (s *Sim) setDeviceState(p *pool, v *vdev, state string) {
+	prev := v.state
+	if prev == state {
+		return
+	}
+
+	v.state = state
+
+	hex := func(state string) string {
+		return "0x" + strconv.FormatUint(vdevStateNums[state], 16)
+	}
+
+	s.postEvent(eventClassStateChange, p, v, map[string]string{
+		"vdev_state":     hex(state),
+		"vdev_laststate": hex(prev),
+	})
+}
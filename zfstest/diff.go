@@ -0,0 +1,159 @@
+package zfstest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// Change types reported by 'zfs diff'.
+	diffChangeTypes = map[string]bool{"-": true, "+": true, "M": true, "R": true}
+	// File types reported by 'zfs diff -F'.
+	diffFileTypes = map[string]bool{
+		"B": true, "C": true, "/": true, ">": true, "|": true, "@": true, "P": true, "=": true, "F": true,
+	}
+)
+
+// fileChange represents a change to a file of a simulated file system.
+type fileChange struct {
+	txg      uint64
+	time     time.Time
+	change   string
+	fileType string
+	path     string
+	newPath  string
+}
+
+// RecordFileChange records a change to a file of the file system as
+// reported by 'zfs diff', e.g. change "M" (modified) of the fileType "F"
+// (regular file) at path "/tank/home/notes.txt". The newPath is only
+// specified for change "R" (renamed). The changes made after a snapshot
+// are reported when diffing the snapshot.
+func (s *Sim) RecordFileChange(fsName string, change string, fileType string, path string, newPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ds, ok := s.dataset(fsName)
+	if !ok || ds.snapshot {
+		return fmt.Errorf("file system %q does not exist", fsName)
+	}
+
+	if !diffChangeTypes[change] {
+		return fmt.Errorf("invalid change type %q", change)
+	}
+
+	if !diffFileTypes[fileType] {
+		return fmt.Errorf("invalid file type %q", fileType)
+	}
+
+	if (change == "R") != (newPath != "") {
+		return fmt.Errorf("the new path must only be specified for renames")
+	}
+
+	ds.changes = append(ds.changes, &fileChange{
+		txg:      s.txg(),
+		time:     s.now(),
+		change:   change,
+		fileType: fileType,
+		path:     path,
+		newPath:  newPath,
+	})
+
+	return nil
+}
+
+// zfsDiff runs 'zfs diff', which reports the changes made to the file
+// system after the snapshot and up to the later snapshot if specified.
+func (s *Sim) zfsDiff(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) == 0 || len(a.pos) > 2 {
+		return "", usageError("must provide at least one snapshot name")
+	}
+
+	snap, err := s.lookup(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	if !snap.snapshot {
+		return "", cmdError("Badly formed snapshot name %s", snap.name)
+	}
+
+	fs := snap.parent()
+	until := ^uint64(0)
+
+	if len(a.pos) == 2 {
+		other, err := s.lookup(a.pos[1])
+		if err != nil {
+			return "", err
+		}
+
+		otherFs := other
+		if other.snapshot {
+			otherFs = other.parent()
+			until = other.txg
+		}
+
+		if otherFs != fs {
+			return "", cmdError("Cannot diff snapshots of different file systems: '%s' and '%s'", snap.name, other.name)
+		}
+
+		if other.snapshot && other.txg < snap.txg {
+			return "", cmdError("Not an earlier snapshot from the same fs: '%s'", snap.name)
+		}
+	}
+
+	var b strings.Builder
+
+	for _, c := range fs.changes {
+		if c.txg <= snap.txg || c.txg > until {
+			continue
+		}
+
+		var cols []string
+
+		if a.has('t') {
+			cols = append(cols, fmt.Sprintf("%d.%09d", c.time.Unix(), c.time.Nanosecond()))
+		}
+
+		cols = append(cols, c.change)
+
+		if a.has('F') {
+			cols = append(cols, c.fileType)
+		}
+
+		cols = append(cols, escapeDiffPath(c.path))
+
+		if c.newPath != "" {
+			cols = append(cols, escapeDiffPath(c.newPath))
+		}
+
+		b.WriteString(strings.Join(cols, "\t") + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// escapeDiffPath escapes the path as done by 'zfs diff', i.e. every byte
+// that is not a printable ASCII character (including the space and the
+// backslash) is written as a backslash followed by four octal digits.
+func escapeDiffPath(path string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c <= ' ' || c >= 0x7f || c == '\\' {
+			fmt.Fprintf(&b, "\\%04o", c)
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
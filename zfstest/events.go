@@ -0,0 +1,181 @@
+package zfstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	eventTimeLayout = "Jan 02 2006 15:04:05.000000000"
+
+	eventClassStateChange = "resource.fs.zfs.statechange"
+	eventClassChecksum    = "ereport.fs.zfs.checksum"
+	eventClassIO          = "ereport.fs.zfs.io"
+)
+
+// event represents a simulated event as reported by 'zpool events -v'.
+type event struct {
+	time  time.Time
+	class string
+	// Payload fields in the order reported, the values are already
+	// formatted (i.e. quoted strings and hex numbers).
+	fields []eventField
+}
+
+type eventField struct {
+	key   string
+	value string
+}
+
+// PostEvent posts an event of the specified class (e.g.
+// "ereport.fs.zfs.io") for the pool, and optionally the device, which is
+// reported to the watchers of 'zpool events -f'. The payload values are
+// reported as numbers if they have a "0x" prefix, and as strings
+// otherwise.
+func (s *Sim) PostEvent(class string, poolName string, device string, payload map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pools[poolName]
+	if !ok {
+		return fmt.Errorf("pool %q does not exist", poolName)
+	}
+
+	var v *vdev
+	if device != "" {
+		if v, _ = p.vdevs.find(device); v == nil {
+			return fmt.Errorf("device %q does not exist in pool %q", device, poolName)
+		}
+	}
+
+	s.postEvent(class, p, v, payload)
+
+	return nil
+}
+
+// postEvent records the event and wakes up the watchers.
+func (s *Sim) postEvent(class string, p *pool, v *vdev, payload map[string]string) {
+	e := &event{time: s.now(), class: class}
+
+	add := func(key string, value string) {
+		e.fields = append(e.fields, eventField{key: key, value: value})
+	}
+
+	hex := func(val uint64) string {
+		return "0x" + strconv.FormatUint(val, 16)
+	}
+
+	add("class", strconv.Quote(class))
+	add("eid", hex(s.nextEID))
+	add("pool", strconv.Quote(p.name))
+	add("pool_guid", hex(p.guid))
+
+	if v != nil {
+		add("vdev_guid", hex(v.guid))
+		add("vdev_type", strconv.Quote(v.typ))
+
+		if v.isLeaf() {
+			add("vdev_path", strconv.Quote("/dev/"+v.name))
+		}
+	}
+
+	keys := make([]string, 0, len(payload))
+	for k := range payload {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		val := payload[k]
+		if !strings.HasPrefix(val, "0x") {
+			val = strconv.Quote(val)
+		}
+
+		add(k, val)
+	}
+
+	add("time", fmt.Sprintf("%s %s", hex(uint64(e.time.Unix())), hex(uint64(e.time.Nanosecond()))))
+
+	s.nextEID++
+	s.events = append(s.events, e)
+
+	close(s.eventsCh)
+	s.eventsCh = make(chan struct{})
+}
+
+// format formats the event as reported by 'zpool events -H -v'.
+func (e *event) format() string {
+	var b strings.Builder
+
+	b.WriteString(e.time.Local().Format(eventTimeLayout) + "\t" + e.class + "\n")
+
+	for _, f := range e.fields {
+		b.WriteString("        " + f.key + " = " + f.value + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// followEvents returns the output of 'zpool events -f', which reports all
+// the events posted so far followed by the events posted until the reader
+// is closed or the context is done. Must be called with the lock held.
+func (s *Sim) followEvents(ctx context.Context, args []string) (io.ReadCloser, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.has('f') {
+		return nil, usageError("only 'zpool events -f' is supported")
+	}
+
+	r, w := io.Pipe()
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		next := 0
+
+		for {
+			s.mu.Lock()
+			pending := s.events[next:]
+			wake := s.eventsCh
+			s.mu.Unlock()
+
+			for _, e := range pending {
+				if _, err := io.WriteString(w, e.format()); err != nil {
+					return
+				}
+			}
+
+			next += len(pending)
+
+			select {
+			case <-wake:
+			case <-ctx.Done():
+				w.CloseWithError(ctx.Err())
+				return
+			}
+		}
+	}()
+
+	return &eventsReader{PipeReader: r, cancel: cancel}, nil
+}
+
+// eventsReader stops following the events once closed.
+type eventsReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (r *eventsReader) Close() error {
+	r.cancel()
+	return r.PipeReader.Close()
+}
@@ -0,0 +1,210 @@
+package zfstest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	featureDisabled = "disabled"
+	featureEnabled  = "enabled"
+	featureActive   = "active"
+)
+
+// simFeature represents a pool feature supported by the simulator.
+type simFeature struct {
+	name               string
+	readOnlyCompatible bool
+	description        string
+}
+
+var (
+	// Features supported by the simulator in the order listed by zpool.
+	simFeatures = []simFeature{
+		{"async_destroy", true, "Destroy filesystems asynchronously."},
+		{"empty_bpobj", true, "Snapshots use less space."},
+		{"lz4_compress", false, "LZ4 compression algorithm support."},
+		{"spacemap_histogram", true, "Spacemaps maintain space histograms."},
+		{"enabled_txg", true, "Record txg at which a feature is enabled"},
+		{"hole_birth", false, "Retain hole birth txg for more precise zfs send"},
+		{"extensible_dataset", false, "Enhanced dataset functionality, used by other features."},
+		{"bookmarks", true, "\"zfs bookmark\" command"},
+		{"large_blocks", false, "Support for blocks larger than 128KB."},
+		{"encryption", false, "Support for dataset level encryption"},
+		{"project_quota", true, "space/object accounting based on project ID."},
+		{"draid", false, "Support for distributed spare RAID"},
+	}
+)
+
+// SetFeature sets the state (i.e. "disabled", "enabled" or "active") of the
+// pool feature, e.g. to simulate a feature that is in use.
+func (s *Sim) SetFeature(poolName string, feature string, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pools[poolName]
+	if !ok {
+		return fmt.Errorf("pool %q does not exist", poolName)
+	}
+
+	if _, ok := p.features[feature]; !ok {
+		return fmt.Errorf("feature %q is not supported", feature)
+	}
+
+	p.features[feature] = state
+
+	return nil
+}
+
+// newFeatures returns the initial state of the features of a new pool,
+// where all the features are enabled unless disabled is true.
+func newFeatures(disabled bool) map[string]string {
+	result := make(map[string]string)
+
+	for _, f := range simFeatures {
+		result[f.name] = featureEnabled
+		if disabled {
+			result[f.name] = featureDisabled
+		}
+	}
+
+	return result
+}
+
+// allPoolProps returns the names of all the properties of the pool in the
+// order listed by 'zpool get all'.
+func (p *pool) allPoolProps() []string {
+	result := []string{"size", "capacity", "health", "guid", "free", "allocated", "fragmentation", "freeing", "checkpoint"}
+	for prop := range poolPropDefaults {
+		result = append(result, prop)
+	}
+
+	sort.Strings(result)
+
+	for _, f := range simFeatures {
+		result = append(result, "feature@"+f.name)
+	}
+
+	var user []string
+
+	for prop := range p.props {
+		if strings.Contains(prop, ":") {
+			user = append(user, prop)
+		}
+	}
+
+	sort.Strings(user)
+
+	return append(result, user...)
+}
+
+// setPoolProp sets the property of the pool as done by 'zpool set'.
+func (p *pool) setPoolProp(prop string, value string) error {
+	if name := strings.TrimPrefix(prop, "feature@"); name != prop {
+		state, ok := p.features[name]
+		if !ok {
+			return cmdError("property '%s' is not a valid pool property", prop)
+		}
+
+		if value != featureEnabled {
+			return cmdError("property '%s' can only be set to 'enabled'", prop)
+		}
+
+		if state == featureDisabled {
+			p.features[name] = featureEnabled
+		}
+
+		return nil
+	}
+
+	if _, ok := poolPropDefaults[prop]; !ok && !strings.Contains(prop, ":") {
+		return cmdError("property '%s' is not a valid pool property", prop)
+	}
+
+	p.props[prop] = value
+
+	return nil
+}
+
+func (s *Sim) zpoolSet(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 2 {
+		return "", usageError("missing property=value or pool argument")
+	}
+
+	prop, value, found := strings.Cut(a.pos[0], "=")
+	if !found {
+		return "", usageError("missing '=' for property=value argument")
+	}
+
+	p, err := s.lookupPool(a.pos[1])
+	if err != nil {
+		return "", err
+	}
+
+	return "", p.setPoolProp(prop, value)
+}
+
+func (s *Sim) zpoolUpgrade(args []string) (string, error) {
+	a, err := parseArgs(args, "V")
+	if err != nil {
+		return "", err
+	}
+
+	if a.has('v') {
+		return formatFeatureDescriptions(), nil
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing pool argument")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	if compat, _ := p.get("compatibility"); compat == "legacy" {
+		return fmt.Sprintf("Pool '%s' is restricted to legacy features by its compatibility property.\n", p.name), nil
+	}
+
+	for name, state := range p.features {
+		if state == featureDisabled {
+			p.features[name] = featureEnabled
+		}
+	}
+
+	return fmt.Sprintf("This system supports ZFS pool feature flags.\n\nEnabled all features on pool '%s'.\n", p.name), nil
+}
+
+// formatFeatureDescriptions returns the output of 'zpool upgrade -v'.
+func formatFeatureDescriptions() string {
+	var b strings.Builder
+
+	b.WriteString("This system supports ZFS pool feature flags.\n\n")
+	b.WriteString("The following features are supported:\n\n")
+	b.WriteString("FEAT DESCRIPTION\n")
+	b.WriteString(strings.Repeat("-", 61) + "\n")
+
+	for _, f := range simFeatures {
+		compat := ""
+		if f.readOnlyCompatible {
+			compat = "(read-only compatible)"
+		}
+
+		fmt.Fprintf(&b, "%-37s %s\n", f.name, compat)
+		fmt.Fprintf(&b, "     %s\n", f.description)
+	}
+
+	b.WriteString("\nThe following legacy versions are also supported:\n\n")
+	b.WriteString("VER  DESCRIPTION\n")
+	b.WriteString("---  " + strings.Repeat("-", 56) + "\n")
+	b.WriteString(" 1   Initial ZFS version\n")
+
+	return b.String()
+}
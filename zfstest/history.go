@@ -0,0 +1,141 @@
+package zfstest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	historyTimestampLayout = "2006-01-02.15:04:05"
+
+	// Hostname logged in the pool history for all the commands.
+	historyHostname = "zfstest"
+)
+
+var (
+	// Subcommands logged in the pool history by name, the remaining
+	// subcommands do not modify the pools.
+	historySubcommands = map[string]map[string]bool{
+		"zfs": {
+			"set":        true,
+			"clone":      true,
+			"promote":    true,
+			"change-key": true,
+		},
+		"zpool": {
+			"create":     true,
+			"import":     true,
+			"set":        true,
+			"upgrade":    true,
+			"attach":     true,
+			"detach":     true,
+			"replace":    true,
+			"online":     true,
+			"offline":    true,
+			"clear":      true,
+			"remove":     true,
+			"checkpoint": true,
+			"trim":       true,
+			"initialize": true,
+		},
+	}
+)
+
+// historyRecord represents an entry of the pool history.
+type historyRecord struct {
+	time    time.Time
+	command string
+	// Transaction group of the internal event, 0 for user commands.
+	txg uint64
+	// Dataset and its id for internal events.
+	dataset   string
+	datasetID uint64
+}
+
+// logHistory logs the successful command along with the internal event in
+// the history of the pool it operates on, if the command modifies the
+// pool.
+func (s *Sim) logHistory(name string, args []string) {
+	if len(args) == 0 || !historySubcommands[name][args[0]] {
+		return
+	}
+
+	if name == "zpool" && args[0] == "upgrade" && len(args) > 1 && args[1] == "-v" {
+		return
+	}
+
+	for _, arg := range args[1:] {
+		p, ok := s.poolOf(arg)
+		if !ok {
+			continue
+		}
+
+		// The internal event is logged when the change is synced, i.e.
+		// before the command completes and is logged.
+		now := s.now()
+		internal := &historyRecord{time: now, command: args[0], txg: s.txg()}
+
+		if ds, ok := p.datasets[arg]; ok && name == "zfs" {
+			internal.dataset, internal.datasetID = ds.name, ds.guid%1000
+		}
+
+		p.log = append(p.log, internal, &historyRecord{time: now, command: name + " " + strings.Join(args, " ")})
+
+		return
+	}
+}
+
+func (s *Sim) zpoolHistory(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	names := a.pos
+	if len(names) == 0 {
+		names = s.sortedPoolNames()
+	}
+
+	var b strings.Builder
+
+	for _, name := range names {
+		p, err := s.lookupPool(name)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&b, "History for '%s':\n", p.name)
+
+		for _, r := range p.log {
+			if r.txg != 0 && !a.has('i') {
+				continue
+			}
+
+			b.WriteString(r.time.Format(historyTimestampLayout))
+
+			if r.txg == 0 {
+				b.WriteString(" " + r.command)
+			} else {
+				fmt.Fprintf(&b, " [txg:%d] %s", r.txg, r.command)
+				if r.dataset != "" {
+					fmt.Fprintf(&b, " %s (%d)", r.dataset, r.datasetID)
+				}
+			}
+
+			if a.has('l') {
+				if r.txg == 0 {
+					fmt.Fprintf(&b, " [user 0 (root) on %s:linux]", historyHostname)
+				} else {
+					fmt.Fprintf(&b, " [on %s]", historyHostname)
+				}
+			}
+
+			b.WriteString("\n")
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
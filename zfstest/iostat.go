@@ -0,0 +1,167 @@
+package zfstest
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Number of latency and queue columns reported by 'zpool iostat -l -q'.
+	iostatLatencyCols = 11
+	iostatQueueCols   = 14
+)
+
+// iostat starts 'zpool iostat', which reports a sample without any I/O
+// activity after each interval, until the count is reached if specified or
+// the context is cancelled.
+func (s *Sim) iostat(ctx context.Context, args []string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.begin("zpool", append([]string{"iostat"}, args...)); err != nil {
+		return nil, err
+	}
+
+	a, err := parseArgs(args, "cT")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.pos) != 2 && len(a.pos) != 3 {
+		return nil, usageError("expected <pool> <interval> [count] arguments")
+	}
+
+	name := a.pos[0]
+	if _, err := s.lookupPool(name); err != nil {
+		return nil, err
+	}
+
+	secs, err := strconv.ParseFloat(a.pos[1], 64)
+	if err != nil || secs <= 0 {
+		return nil, usageError("interval cannot be zero")
+	}
+
+	count := 0
+	if len(a.pos) == 3 {
+		count, err = strconv.Atoi(a.pos[2])
+		if err != nil || count <= 0 {
+			return nil, usageError("count cannot be zero")
+		}
+	}
+
+	latency, queue, histogram := a.has('l'), a.has('q'), a.has('w')
+	r, w := io.Pipe()
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(secs * float64(time.Second)))
+		defer ticker.Stop()
+
+		for i := 0; count == 0 || i < count; i++ {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				w.CloseWithError(ctx.Err())
+				return
+			}
+
+			s.mu.Lock()
+			p, err := s.lookupPool(name)
+
+			var out string
+			if err == nil {
+				out = p.formatIOStats(latency, queue, histogram)
+			}
+			s.mu.Unlock()
+
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+
+			// Each sample is written at once, the write fails once the
+			// stream is closed by the reader.
+			if _, err := io.WriteString(w, out); err != nil {
+				return
+			}
+		}
+
+		w.Close()
+	}()
+
+	return r, nil
+}
+
+// formatIOStats formats the I/O statistics of the pool and its vdevs as
+// reported by 'zpool iostat -H -p -v'.
+func (p *pool) formatIOStats(latency bool, queue bool, histogram bool) string {
+	var b strings.Builder
+
+	write := func(name string, alloc string, free string) {
+		if histogram {
+			b.WriteString(name + "\n")
+			return
+		}
+
+		cols := []string{name, alloc, free, "0", "0", "0", "0"}
+
+		if latency {
+			cols = append(cols, repeat("0", iostatLatencyCols)...)
+		}
+
+		if queue {
+			cols = append(cols, repeat("0", iostatQueueCols)...)
+		}
+
+		b.WriteString(strings.Join(cols, "\t") + "\n")
+	}
+
+	var walk func(v *vdev, topLevel bool)
+	walk = func(v *vdev, topLevel bool) {
+		alloc, free := "-", "-"
+		if topLevel {
+			alloc, free = "0", strconv.FormatUint(v.capacity(), 10)
+		}
+
+		write(v.name, alloc, free)
+
+		for _, c := range v.children {
+			walk(c, false)
+		}
+	}
+
+	write(p.name, strconv.FormatUint(p.allocated(), 10), strconv.FormatUint(p.free(), 10))
+
+	for _, v := range p.vdevs.root.children {
+		walk(v, true)
+	}
+
+	for _, c := range p.vdevs.classes() {
+		if len(*c.vdevs) == 0 || c.header == "spares" {
+			continue
+		}
+
+		if histogram {
+			b.WriteString(c.header + "\n")
+		} else {
+			b.WriteString(strings.Join(append([]string{c.header}, repeat("-", 6)...), "\t") + "\n")
+		}
+
+		for _, v := range *c.vdevs {
+			walk(v, c.header != "cache")
+		}
+	}
+
+	return b.String()
+}
+
+func repeat(val string, n int) []string {
+	result := make([]string, n)
+	for i := range result {
+		result[i] = val
+	}
+
+	return result
+}
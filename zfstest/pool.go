@@ -0,0 +1,280 @@
+package zfstest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Default values of the pool properties.
+	poolPropDefaults = map[string]string{
+		"altroot":       "-",
+		"ashift":        "0",
+		"autoexpand":    "off",
+		"autoreplace":   "off",
+		"autotrim":      "off",
+		"bootfs":        "-",
+		"cachefile":     "-",
+		"comment":       "-",
+		"compatibility": "off",
+		"dedupratio":    "1.00",
+		"delegation":    "on",
+		"failmode":      "wait",
+		"listsnapshots": "off",
+		"multihost":     "off",
+		"readonly":      "off",
+		"version":       "-",
+	}
+)
+
+// pool represents a simulated pool.
+type pool struct {
+	name  string
+	guid  uint64
+	props map[string]string
+	// State of the features by name.
+	features map[string]string
+	vdevs    *vdevTree
+	// File systems and snapshots by full name.
+	datasets map[string]*dataset
+	// Scan status as reported by zpool status, "none requested" if empty.
+	scan string
+	// Command history along with the internal events.
+	log []*historyRecord
+	// Checkpoint of the pool, nil if the pool has no checkpoint.
+	checkpoint *checkpoint
+}
+
+// SetPoolProp sets the property of the pool, including the read-only
+// properties such as "allocated" which are used to simulate the space
+// usage.
+func (s *Sim) SetPoolProp(poolName string, prop string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pools[poolName]
+	if !ok {
+		return fmt.Errorf("pool %q does not exist", poolName)
+	}
+
+	p.props[prop] = value
+
+	return nil
+}
+
+// SetScan sets the scan status of the pool as reported by 'zpool status',
+// e.g. "scrub repaired 0B in 00:00:01 with 0 errors on Sun Jan  1 00:00:00 2023".
+func (s *Sim) SetScan(poolName string, scan string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pools[poolName]
+	if !ok {
+		return fmt.Errorf("pool %q does not exist", poolName)
+	}
+
+	p.scan = scan
+
+	return nil
+}
+
+// get returns the value of the pool property, or false if the property is
+// invalid.
+func (p *pool) get(prop string) (string, bool) {
+	switch prop {
+	case "name":
+		return p.name, true
+	case "guid":
+		return strconv.FormatUint(p.guid, 10), true
+	case "health":
+		return p.vdevs.root.computedState(), true
+	case "size":
+		return strconv.FormatUint(p.size(), 10), true
+	case "allocated":
+		return strconv.FormatUint(p.allocated(), 10), true
+	case "free":
+		return strconv.FormatUint(p.free(), 10), true
+	case "capacity":
+		if p.size() == 0 {
+			return "0", true
+		}
+
+		return strconv.FormatUint(p.allocated()*100/p.size(), 10), true
+	case "fragmentation", "freeing":
+		if val, ok := p.props[prop]; ok {
+			return val, true
+		}
+
+		return "0", true
+	case "checkpoint":
+		if p.checkpoint == nil {
+			return "-", true
+		}
+
+		if val, ok := p.props[prop]; ok {
+			return val, true
+		}
+
+		return "0", true
+	}
+
+	if name := strings.TrimPrefix(prop, "feature@"); name != prop {
+		val, ok := p.features[name]
+		return val, ok
+	}
+
+	if val, ok := p.props[prop]; ok {
+		return val, true
+	}
+
+	if val, ok := poolPropDefaults[prop]; ok {
+		return val, true
+	}
+
+	if strings.Contains(prop, ":") {
+		return "-", true
+	}
+
+	return "", false
+}
+
+func (p *pool) size() uint64 {
+	if val, ok := p.props["size"]; ok {
+		size, _ := strconv.ParseUint(val, 10, 64)
+		return size
+	}
+
+	var result uint64
+	for _, v := range p.vdevs.root.children {
+		result += v.capacity()
+	}
+
+	return result
+}
+
+func (p *pool) allocated() uint64 {
+	if val, ok := p.props["allocated"]; ok {
+		alloc, _ := strconv.ParseUint(val, 10, 64)
+		return alloc
+	}
+
+	if root, ok := p.datasets[p.name]; ok {
+		return root.used()
+	}
+
+	return 0
+}
+
+func (p *pool) free() uint64 {
+	size, alloc := p.size(), p.allocated()
+	if alloc >= size {
+		return 0
+	}
+
+	return size - alloc
+}
+
+// sortedDatasets returns all the file systems and snapshots of the pool in
+// the order listed by zfs.
+func (p *pool) sortedDatasets() []*dataset {
+	result := make([]*dataset, 0, len(p.datasets))
+	for _, d := range p.datasets {
+		result = append(result, d)
+	}
+
+	sortDatasets(result)
+
+	return result
+}
+
+// descendants returns the file system along with all its descendant file
+// systems, and their snapshots if snapshots is true.
+func (p *pool) descendants(fs *dataset, snapshots bool) []*dataset {
+	var result []*dataset
+
+	for _, d := range p.sortedDatasets() {
+		fsName, _, _ := strings.Cut(d.name, "@")
+		if fsName != fs.name && !strings.HasPrefix(fsName, fs.name+"/") {
+			continue
+		}
+
+		if d.snapshot && !snapshots {
+			continue
+		}
+
+		result = append(result, d)
+	}
+
+	return result
+}
+
+// children returns the immediate child file systems.
+func (p *pool) children(fsName string) []*dataset {
+	var result []*dataset
+
+	for _, d := range p.sortedDatasets() {
+		if !d.snapshot && parentName(d.name) == fsName && strings.Contains(d.name, "/") {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// snapshotsOf returns the snapshots of the file system in the order of
+// creation.
+func (p *pool) snapshotsOf(fsName string) []*dataset {
+	var result []*dataset
+
+	for _, d := range p.sortedDatasets() {
+		if d.snapshot && strings.HasPrefix(d.name, fsName+"@") {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// clonesOf returns the names of the file systems cloned from the snapshot.
+func (p *pool) clonesOf(snapName string) []string {
+	var result []string
+
+	for _, d := range p.datasets {
+		if d.origin == snapName {
+			result = append(result, d.name)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// unmountAll unmounts all the file systems of the pool, failing if any of
+// them are busy unless force is true.
+func (p *pool) unmountAll(force bool) error {
+	if !force {
+		for _, d := range p.datasets {
+			if d.mounted && d.busy {
+				return cmdError("cannot unmount '%s': pool or dataset is busy", d.get("mountpoint").value)
+			}
+		}
+	}
+
+	for _, d := range p.datasets {
+		d.mounted = false
+	}
+
+	return nil
+}
+
+// mountAll mounts all the file systems of the pool that can be mounted.
+func (p *pool) mountAll() {
+	for _, d := range p.datasets {
+		if d.canMount() {
+			d.mounted = true
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// Package zfstest provides a stateful in-memory simulator of zfs, which
+// implements all the zfs and zpool commands issued by the zfs package, so
+// that code using the zfs package can be tested without root privileges or
+// a real zfs installation.
+//
+// The simulator is seeded with pools, file systems, snapshots and holds
+// using its methods (or using the zfs package, e.g. System.CreatePool), and
+// a System backed by the simulator is obtained using Sim.System:
+//
+//	sim := zfstest.New()
+//	sys := sim.System()
+//	pool, err := sys.CreatePool("tank", zfs.NewPoolLayout().Data(zfs.MirrorVdev("sda", "sdb")), nil, nil)
+//	err = sim.CreateFileSystem("tank/home", map[string]string{"compression": "lz4"})
+//	err = sim.Snapshot("tank/home@daily", false)
+//
+// The contents of the file systems are not simulated, instead the changes
+// reported by 'zfs diff' and the space reported by 'zfs userspace' are
+// seeded using Sim.RecordFileChange and Sim.SetUserSpace. The mount state is
+// only reported through the "mounted" property, FileSystem.IsMounted reads
+// the mount table instead, which can be substituted using a fixture:
+//
+//	system := zfs.NewSystem(&zfs.SystemConfig{
+//		Executor:      sim,
+//		DisableJSON:   true,
+//		MountInfoPath: "testdata/mountinfo",
+//	})
+package zfstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuxdude/zfs"
+)
+
+const (
+	// DeviceSize is the size in bytes of every simulated device.
+	DeviceSize = 10 * 1024 * 1024 * 1024
+
+	// DefaultVersion is the OpenZFS version reported by the simulator
+	// unless overridden using Sim.SetVersion.
+	DefaultVersion = "2.2.6"
+
+	firstGUID = 1000000000000000000
+)
+
+// Sim represents the simulated state of all the pools in a system. All the
+// methods are safe for concurrent use.
+type Sim struct {
+	mu       sync.Mutex
+	pools    map[string]*pool
+	exported map[string]*pool
+	now      func() time.Time
+	version  string
+	nextGUID uint64
+	nextTXG  uint64
+	history  []string
+	failures map[string][]*failure
+
+	events   []*event
+	nextEID  uint64
+	eventsCh chan struct{}
+}
+
+type failure struct {
+	exitCode int
+	stderr   string
+}
+
+// New returns a new simulator without any pools.
+func New() *Sim {
+	return &Sim{
+		pools:    make(map[string]*pool),
+		exported: make(map[string]*pool),
+		now:      time.Now,
+		version:  DefaultVersion,
+		nextGUID: firstGUID,
+		nextTXG:  1,
+		failures: make(map[string][]*failure),
+		nextEID:  1,
+		eventsCh: make(chan struct{}),
+	}
+}
+
+// System returns a new System backed by the simulator.
+func (s *Sim) System() *zfs.System {
+	// The JSON output is not simulated, regardless of the version.
+	return zfs.NewSystem(&zfs.SystemConfig{Executor: s, DisableJSON: true})
+}
+
+// SetClock sets the function used to obtain the current time, e.g. for
+// the creation time of the file systems, snapshots and holds.
+func (s *Sim) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+}
+
+// SetVersion sets the OpenZFS version (e.g. "2.1.5") reported by 'zfs
+// version' for both the userland tools and the kernel module. The JSON
+// output of OpenZFS 2.3 and later is not simulated, the commands fail if
+// it is requested.
+func (s *Sim) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version = version
+}
+
+// versionAtLeast returns true if the simulated version is the same or newer
+// than the specified major and minor version.
+func (s *Sim) versionAtLeast(major int, minor int) bool {
+	var curMajor, curMinor int
+	if _, err := fmt.Sscanf(s.version, "%d.%d", &curMajor, &curMinor); err != nil {
+		return false
+	}
+
+	if curMajor != major {
+		return curMajor > major
+	}
+
+	return curMinor >= minor
+}
+
+// Commands returns the command lines of all the commands run so far, e.g.
+// "zpool list -H -p -o name,guid".
+func (s *Sim) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.history...)
+}
+
+// FailNext makes the next invocation of the specified subcommand (e.g.
+// "zfs", "set") fail with the specified exit code and standard error
+// output, without any change to the simulated state.
+func (s *Sim) FailNext(name string, subcommand string, exitCode int, stderr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := name + " " + subcommand
+	s.failures[key] = append(s.failures[key], &failure{exitCode: exitCode, stderr: stderr})
+}
+
+// Run runs the zfs or zpool command against the simulated state, and
+// implements zfs.Executor.
+func (s *Sim) Run(ctx context.Context, stdin io.Reader, name string, args ...string) (string, error) {
+	if len(args) > 0 && name == "zpool" && args[0] == "iostat" {
+		// Sample the I/O statistics over the requested intervals without
+		// blocking the other commands.
+		stream, err := s.iostat(ctx, args[1:])
+		if err != nil {
+			return "", err
+		}
+		defer stream.Close()
+
+		out, err := io.ReadAll(stream)
+
+		return string(out), err
+	}
+
+	if len(args) > 0 && name == "zpool" && args[0] == "wait" {
+		// Poll the state without blocking the commands that complete the
+		// activities.
+		return s.zpoolWait(ctx, args[1:])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.begin(name, args); err != nil {
+		return "", err
+	}
+
+	var out string
+
+	var err error
+
+	switch name {
+	case "zfs":
+		out, err = s.runZfs(stdin, args)
+	case "zpool":
+		out, err = s.runZpool(args)
+	default:
+		err = usageError("%s: command not found", name)
+	}
+
+	if err == nil {
+		s.logHistory(name, args)
+	}
+
+	return out, err
+}
+
+// Stream runs the streaming zfs or zpool command against the simulated
+// state, and implements zfs.Executor. Only 'zpool events -f' and 'zpool iostat'
+// with an interval are supported.
+func (s *Sim) Stream(ctx context.Context, name string, args ...string) (io.ReadCloser, error) {
+	if len(args) > 0 && name == "zpool" && args[0] == "iostat" {
+		return s.iostat(ctx, args[1:])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.begin(name, args); err != nil {
+		return nil, err
+	}
+
+	if name != "zpool" || len(args) == 0 || args[0] != "events" {
+		return nil, usageError("streaming is not supported for %s %v", name, args)
+	}
+
+	return s.followEvents(ctx, args[1:])
+}
+
+// begin records the command in the history, and returns the injected
+// failure for the command if any.
+func (s *Sim) begin(name string, args []string) error {
+	s.history = append(s.history, strings.TrimSpace(name+" "+strings.Join(args, " ")))
+
+	for _, arg := range args {
+		if arg == "-j" || strings.HasPrefix(arg, "--json") {
+			return usageError("the JSON output is not supported by the simulator")
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	key := name + " " + args[0]
+	if fs := s.failures[key]; len(fs) > 0 {
+		s.failures[key] = fs[1:]
+		return &zfs.ExitError{ExitCode: fs[0].exitCode, Stderr: fs[0].stderr}
+	}
+
+	return nil
+}
+
+func (s *Sim) guid() uint64 {
+	s.nextGUID++
+	return s.nextGUID
+}
+
+func (s *Sim) txg() uint64 {
+	s.nextTXG++
+	return s.nextTXG
+}
+
+// cmdError returns an error for a command that failed with exit status 1.
+func cmdError(format string, args ...interface{}) error {
+	return &zfs.ExitError{ExitCode: 1, Stderr: fmt.Sprintf(format, args...) + "\n"}
+}
+
+// usageError returns an error for a command that was invoked incorrectly,
+// which exits with status 2.
+func usageError(format string, args ...interface{}) error {
+	return &zfs.ExitError{ExitCode: 2, Stderr: fmt.Sprintf(format, args...) + "\n"}
+}
+
+// poolOf returns the pool of the dataset with the specified full name.
+func (s *Sim) poolOf(name string) (*pool, bool) {
+	poolName := name
+	if i := strings.IndexAny(poolName, "/@"); i >= 0 {
+		poolName = poolName[:i]
+	}
+
+	p, ok := s.pools[poolName]
+
+	return p, ok
+}
+
+// dataset returns the file system or snapshot with the specified full
+// name.
+func (s *Sim) dataset(name string) (*dataset, bool) {
+	p, ok := s.poolOf(name)
+	if !ok {
+		return nil, false
+	}
+
+	ds, ok := p.datasets[name]
+
+	return ds, ok
+}
+
+// sortedPoolNames returns the names of the imported pools in sorted order.
+func (s *Sim) sortedPoolNames() []string {
+	result := make([]string, 0, len(s.pools))
+	for name := range s.pools {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// sortedExported returns the exported pools sorted by name.
+func (s *Sim) sortedExported() []*pool {
+	result := make([]*pool, 0, len(s.exported))
+	for _, p := range s.exported {
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].name != result[j].name {
+			return result[i].name < result[j].name
+		}
+
+		return result[i].guid < result[j].guid
+	})
+
+	return result
+}
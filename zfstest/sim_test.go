@@ -0,0 +1,1006 @@
+package zfstest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/tuxdude/zfs"
+	"github.com/tuxdude/zfs/zfstest"
+)
+
+func newTestPool(t *testing.T, sim *zfstest.Sim) *zfs.Pool {
+	t.Helper()
+
+	layout := zfs.NewPoolLayout().Data(zfs.MirrorVdev("sda", "sdb")).Spare("sdc")
+
+	pool, err := sim.System().CreatePool("tank", layout, nil, map[string]string{"compression": "lz4"})
+	if err != nil {
+		t.Fatalf("CreatePool()\nTest: Creating the test pool\nFailed with error: %v", err)
+	}
+
+	return pool
+}
+
+func fsNames(list zfs.FileSystemList) []string {
+	var result []string
+	for _, fs := range list {
+		result = append(result, fs.FullName())
+	}
+
+	return result
+}
+
+func snapNames(list zfs.SnapshotList) []string {
+	var result []string
+	for _, s := range list {
+		result = append(result, s.FullName())
+	}
+
+	return result
+}
+
+func TestSimPools(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	if pool.Name != "tank" || pool.HealthStatus != "ONLINE" || pool.Size != zfstest.DeviceSize {
+		t.Errorf("CreatePool()\nTest: Creating a mirror pool\nGot: %v\nWant: name tank, ONLINE, size %d", pool, zfstest.DeviceSize)
+	}
+
+	pools, err := sim.System().ListPools()
+	if err != nil || len(pools) != 1 {
+		t.Fatalf("ListPools()\nTest: Listing the created pool\nGot: %v, %v", pools, err)
+	}
+
+	if err := pool.Export(false); err != nil {
+		t.Fatalf("Export()\nTest: Exporting the pool\nFailed with error: %v", err)
+	}
+
+	importable, err := sim.System().ImportablePools()
+	if err != nil {
+		t.Fatalf("ImportablePools()\nTest: Listing the exported pool\nFailed with error: %v", err)
+	}
+
+	if len(importable) != 1 || importable[0].Name != "tank" || importable[0].GUID != pool.GUID {
+		t.Fatalf("ImportablePools()\nTest: Listing the exported pool\nGot: %v", importable)
+	}
+
+	if _, err := sim.System().ImportPool("missing", nil); !errors.Is(err, zfs.ErrNotFound) {
+		t.Errorf("ImportPool()\nTest: Importing a missing pool\nGot: %v\nWant: %v", err, zfs.ErrNotFound)
+	}
+
+	imported, err := sim.System().ImportPool("tank", &zfs.ImportPoolOptions{NewName: "data"})
+	if err != nil {
+		t.Fatalf("ImportPool()\nTest: Importing the pool with a new name\nFailed with error: %v", err)
+	}
+
+	if imported.GUID != pool.GUID {
+		t.Errorf("ImportPool()\nTest: Importing the pool with a new name\nGot GUID: %d\nWant GUID: %d", imported.GUID, pool.GUID)
+	}
+
+	fsList, err := imported.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the root file system of the renamed pool\nFailed with error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"data"}, fsNames(fsList)); diff != "" {
+		t.Errorf("FileSystems()\nTest: Listing the root file system of the renamed pool\ndiff:\n%s", diff)
+	}
+
+	if err := imported.Destroy(); err != nil {
+		t.Fatalf("Destroy()\nTest: Destroying the pool\nFailed with error: %v", err)
+	}
+
+	pools, err = sim.System().ListPools()
+	if err != nil || len(pools) != 0 {
+		t.Errorf("ListPools()\nTest: Listing after destroying the pool\nGot: %v, %v", pools, err)
+	}
+}
+
+func TestSimDatasets(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+	sim.SetClock(func() time.Time { return now })
+
+	pool := newTestPool(t, sim)
+
+	for _, name := range []string{"tank/home", "tank/home/alice"} {
+		if err := sim.CreateFileSystem(name, nil); err != nil {
+			t.Fatalf("CreateFileSystem()\nTest: Creating %q\nFailed with error: %v", name, err)
+		}
+	}
+
+	if err := sim.Snapshot("tank/home@daily", true); err != nil {
+		t.Fatalf("Snapshot()\nTest: Creating a recursive snapshot\nFailed with error: %v", err)
+	}
+
+	if err := sim.Hold("tank/home@daily", "backup", false); err != nil {
+		t.Fatalf("Hold()\nTest: Holding the snapshot\nFailed with error: %v", err)
+	}
+
+	fsList, err := pool.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the file systems\nFailed with error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"tank", "tank/home", "tank/home/alice"}, fsNames(fsList)); diff != "" {
+		t.Errorf("FileSystems()\nTest: Listing the file systems\ndiff:\n%s", diff)
+	}
+
+	home := fsList[1]
+
+	compression, err := home.GetProp("compression")
+	if err != nil || compression != "lz4" {
+		t.Errorf("GetProp()\nTest: Getting the inherited compression\nGot: %q, %v\nWant: %q", compression, err, "lz4")
+	}
+
+	if err := home.SetProp("atime", "off"); err != nil {
+		t.Errorf("SetProp()\nTest: Setting atime\nFailed with error: %v", err)
+	}
+
+	if err := home.SetProp("used", "1"); err == nil {
+		t.Errorf("SetProp()\nTest: Setting a read-only property\nGot: nil error")
+	}
+
+	snaps, err := home.Snapshots()
+	if err != nil {
+		t.Fatalf("Snapshots()\nTest: Listing the snapshots\nFailed with error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"tank/home@daily"}, snapNames(snaps)); diff != "" {
+		t.Fatalf("Snapshots()\nTest: Listing the snapshots\ndiff:\n%s", diff)
+	}
+
+	holds, err := snaps[0].Holds()
+	if err != nil {
+		t.Fatalf("Holds()\nTest: Listing the holds\nFailed with error: %v", err)
+	}
+
+	if len(holds) != 1 || holds[0].Tag != "backup" || !holds[0].Creation.Equal(now) {
+		t.Errorf("Holds()\nTest: Listing the holds\nGot: %v", holds)
+	}
+
+	clone, err := snaps[0].Clone("clone", nil)
+	if err != nil {
+		t.Fatalf("Clone()\nTest: Cloning the snapshot\nFailed with error: %v", err)
+	}
+
+	if err := clone.Promote(); err != nil {
+		t.Fatalf("Promote()\nTest: Promoting the clone\nFailed with error: %v", err)
+	}
+
+	origin, err := home.Origin()
+	if err != nil || origin == nil || origin.FullName() != "tank/clone@daily" {
+		t.Errorf("Origin()\nTest: Origin after promoting the clone\nGot: %v, %v\nWant: tank/clone@daily", origin, err)
+	}
+}
+
+func TestSimEncryption(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	props := map[string]string{"encryption": "on", "keyformat": "passphrase", "keylocation": "prompt"}
+	if err := sim.CreateFileSystem("tank/secret", props); err != nil {
+		t.Fatalf("CreateFileSystem()\nTest: Creating an encrypted file system\nFailed with error: %v", err)
+	}
+
+	if err := sim.SetKey("tank/secret", "hunter22"); err != nil {
+		t.Fatalf("SetKey()\nTest: Setting the key\nFailed with error: %v", err)
+	}
+
+	fsList, err := pool.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the file systems\nFailed with error: %v", err)
+	}
+
+	secret := fsList[1]
+
+	if err := secret.LoadKey(strings.NewReader("hunter22")); err == nil {
+		t.Errorf("LoadKey()\nTest: Loading an already loaded key\nGot: nil error")
+	}
+
+	if err := secret.UnloadKey(); err == nil {
+		t.Errorf("UnloadKey()\nTest: Unloading the key of a mounted file system\nGot: nil error")
+	}
+
+	if err := secret.Unmount(false); err != nil {
+		t.Fatalf("Unmount()\nTest: Unmounting the file system\nFailed with error: %v", err)
+	}
+
+	if err := secret.UnloadKey(); err != nil {
+		t.Fatalf("UnloadKey()\nTest: Unloading the key\nFailed with error: %v", err)
+	}
+
+	if err := secret.LoadKey(strings.NewReader("wrong key")); err == nil {
+		t.Errorf("LoadKey()\nTest: Loading an incorrect key\nGot: nil error")
+	}
+
+	if err := secret.LoadKey(strings.NewReader("hunter22")); err != nil {
+		t.Errorf("LoadKey()\nTest: Loading the correct key\nFailed with error: %v", err)
+	}
+
+	info, err := secret.Encryption()
+	if err != nil || info.KeyStatus != zfs.KeyStatusAvailable {
+		t.Errorf("Encryption()\nTest: Key status after loading the key\nGot: %v, %v", info, err)
+	}
+}
+
+func TestSimDevices(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	tests := []struct {
+		name      string
+		op        func() (*zfs.VdevTree, error)
+		wantState string
+		wantErr   bool
+	}{
+		{
+			name:      "Offline",
+			op:        func() (*zfs.VdevTree, error) { return pool.Offline(false, "sda") },
+			wantState: "DEGRADED",
+		},
+		{
+			name:    "Offline last replica",
+			op:      func() (*zfs.VdevTree, error) { return pool.Offline(false, "sdb") },
+			wantErr: true,
+		},
+		{
+			name:      "Online",
+			op:        func() (*zfs.VdevTree, error) { return pool.Online(false, "sda") },
+			wantState: "ONLINE",
+		},
+		{
+			name:      "Attach",
+			op:        func() (*zfs.VdevTree, error) { return pool.Attach("sda", "sdd", false) },
+			wantState: "ONLINE",
+		},
+		{
+			name:      "Detach",
+			op:        func() (*zfs.VdevTree, error) { return pool.Detach("sdd") },
+			wantState: "ONLINE",
+		},
+		{
+			name:    "Attach device in use",
+			op:      func() (*zfs.VdevTree, error) { return pool.Attach("sda", "sdb", false) },
+			wantErr: true,
+		},
+		{
+			name:      "Replace",
+			op:        func() (*zfs.VdevTree, error) { return pool.Replace("sdb", "sde", false) },
+			wantState: "ONLINE",
+		},
+		{
+			name:    "Unknown device",
+			op:      func() (*zfs.VdevTree, error) { return pool.Online(false, "sdz") },
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		vdevs, err := test.op()
+
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s\nTest: %s\nGot: nil error", test.name, test.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s\nTest: %s\nFailed with error: %v", test.name, test.name, err)
+		}
+
+		if vdevs.Root.State != test.wantState {
+			t.Errorf("%s\nTest: %s\nGot state: %q\nWant state: %q", test.name, test.name, vdevs.Root.State, test.wantState)
+		}
+	}
+
+	if err := sim.AddDeviceErrors("tank", "sda", 0, 0, 5); err != nil {
+		t.Fatalf("AddDeviceErrors()\nTest: Adding checksum errors\nFailed with error: %v", err)
+	}
+
+	status, err := pool.Status()
+	if err != nil {
+		t.Fatalf("Status()\nTest: Status with checksum errors\nFailed with error: %v", err)
+	}
+
+	sda := status.Vdevs.Root.Children[0].Children[0]
+	if sda.Name != "sda" || sda.ChecksumErrors != 5 || status.Status == "" {
+		t.Errorf("Status()\nTest: Status with checksum errors\nGot: %+v, %+v", status, sda)
+	}
+
+	if len(status.Vdevs.Spares) != 1 || status.Vdevs.Spares[0].Name != "sdc" {
+		t.Errorf("Status()\nTest: Status with a spare\nGot: %v", status.Vdevs.Spares)
+	}
+}
+
+func TestSimFailNext(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	sim.FailNext("zpool", "list", 1, "internal error\n")
+
+	if _, err := sim.System().ListPools(); err == nil {
+		t.Errorf("ListPools()\nTest: Injected failure\nGot: nil error")
+	}
+
+	var exitErr *zfs.ExitError
+	if _, err := pool.GetProp("health"); errors.As(err, &exitErr) {
+		t.Errorf("GetProp()\nTest: Failure only injected once\nGot: %v", err)
+	}
+
+	want := "zpool list -H -p -o name,guid,size,allocated,free,fragmentation,health,altroot"
+
+	commands := sim.Commands()
+	found := false
+
+	for _, c := range commands {
+		found = found || c == want
+	}
+
+	if !found {
+		t.Errorf("Commands()\nTest: Recording the commands\nGot: %v\nWant to include: %q", commands, want)
+	}
+}
+
+func TestSimFeatures(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	sys := sim.System()
+
+	pool, err := sys.CreatePool(
+		"legacy", zfs.NewPoolLayout().Data(zfs.DiskVdev("sda")),
+		map[string]string{"compatibility": zfs.CompatibilityLegacy}, nil)
+	if err != nil {
+		t.Fatalf("CreatePool()\nTest: Legacy pool\nFailed with error: %v", err)
+	}
+
+	features, err := pool.Features()
+	if err != nil {
+		t.Fatalf("Features()\nTest: Legacy pool\nFailed with error: %v", err)
+	}
+
+	if f := features.Lookup("async_destroy"); f == nil || f.State != zfs.FeatureDisabled || !f.ReadOnlyCompatible ||
+		f.Description == "" {
+		t.Errorf("Features()\nTest: Legacy pool\nGot: %v", f)
+	}
+
+	if err := pool.EnableFeature("feature@lz4_compress"); err != nil {
+		t.Fatalf("EnableFeature()\nTest: Legacy pool\nFailed with error: %v", err)
+	}
+
+	if err := sim.SetFeature("legacy", "lz4_compress", "active"); err != nil {
+		t.Fatalf("SetFeature()\nTest: Legacy pool\nFailed with error: %v", err)
+	}
+
+	if err := pool.SetCompatibility(); err != nil {
+		t.Fatalf("SetCompatibility()\nTest: Removing the restrictions\nFailed with error: %v", err)
+	}
+
+	if err := pool.Upgrade(); err != nil {
+		t.Fatalf("Upgrade()\nTest: Legacy pool\nFailed with error: %v", err)
+	}
+
+	upgraded, err := pool.Features()
+	if err != nil {
+		t.Fatalf("Features()\nTest: Upgraded pool\nFailed with error: %v", err)
+	}
+
+	for _, f := range upgraded {
+		want := zfs.FeatureEnabled
+		if f.Name == "lz4_compress" {
+			want = zfs.FeatureActive
+		}
+
+		if f.State != want {
+			t.Errorf("Features()\nTest: Upgraded pool\nGot: %v\nWant state: %q", f, want)
+		}
+	}
+
+	if missing := upgraded.MissingFrom(features); !cmp.Equal(missing, []string{"lz4_compress"}) {
+		t.Errorf("MissingFrom()\nTest: Legacy receiver\nGot: %v", missing)
+	}
+
+	if err := pool.EnableFeature("no_such_feature"); err == nil {
+		t.Errorf("EnableFeature()\nTest: Unsupported feature\nGot: nil error")
+	}
+}
+
+func TestSimHistory(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	sim.SetClock(func() time.Time { return time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local) })
+
+	pool := newTestPool(t, sim)
+
+	if err := sim.CreateFileSystem("tank/home", nil); err != nil {
+		t.Fatalf("CreateFileSystem()\nTest: Seeding the file system\nFailed with error: %v", err)
+	}
+
+	list, err := pool.FileSystems()
+	if err != nil || len(list) != 2 {
+		t.Fatalf("FileSystems()\nTest: Listing the file systems\nGot: %v\nFailed with error: %v", list, err)
+	}
+
+	if err := list[1].SetProp("atime", "off"); err != nil {
+		t.Fatalf("SetProp()\nTest: Setting a property\nFailed with error: %v", err)
+	}
+
+	entries, err := pool.History(&zfs.HistoryOptions{Internal: true, Dataset: "tank/home"})
+	if err != nil {
+		t.Fatalf("History()\nTest: Dataset history\nFailed with error: %v", err)
+	}
+
+	if len(entries) != 2 || !entries[0].Internal || entries[0].Dataset != "tank/home" ||
+		entries[1].Command != "zfs set atime=off tank/home" || entries[1].Hostname != "zfstest" {
+		t.Errorf("History()\nTest: Dataset history\nGot: %v", entries)
+	}
+
+	all, err := pool.History(nil)
+	if err != nil {
+		t.Fatalf("History()\nTest: Pool history\nFailed with error: %v", err)
+	}
+
+	if len(all) != 2 || !strings.HasPrefix(all[0].Command, "zpool create") || all[0].User != "root" {
+		t.Errorf("History()\nTest: Pool history\nGot: %v", all)
+	}
+}
+
+func TestSimCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	sim.SetClock(func() time.Time { return time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local) })
+
+	pool := newTestPool(t, sim)
+
+	if err := pool.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint()\nTest: Creating the checkpoint\nFailed with error: %v", err)
+	}
+
+	if err := pool.Checkpoint(); !errors.Is(err, zfs.ErrExists) {
+		t.Errorf("Checkpoint()\nTest: Existing checkpoint\nGot: %v\nWant: %v", err, zfs.ErrExists)
+	}
+
+	if err := sim.SetPoolProp("tank", "checkpoint", "1048576"); err != nil {
+		t.Fatalf("SetPoolProp()\nTest: Checkpoint space\nFailed with error: %v", err)
+	}
+
+	info, err := pool.CheckpointInfo()
+	if err != nil {
+		t.Fatalf("CheckpointInfo()\nTest: Existing checkpoint\nFailed with error: %v", err)
+	}
+
+	want := &zfs.CheckpointInfo{Exists: true, Created: time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local), Space: 1048576}
+	if diff := cmp.Diff(want, info); diff != "" {
+		t.Errorf("CheckpointInfo()\nTest: Existing checkpoint\ndiff:\n%s", diff)
+	}
+
+	if err := sim.CreateFileSystem("tank/after", nil); err != nil {
+		t.Fatalf("CreateFileSystem()\nTest: Changes after the checkpoint\nFailed with error: %v", err)
+	}
+
+	if err := pool.Export(false); err != nil {
+		t.Fatalf("Export()\nTest: Exporting the pool\nFailed with error: %v", err)
+	}
+
+	rewound, err := sim.System().ImportPool("tank", &zfs.ImportPoolOptions{RewindToCheckpoint: true})
+	if err != nil {
+		t.Fatalf("ImportPool()\nTest: Rewinding to the checkpoint\nFailed with error: %v", err)
+	}
+
+	fsList, err := rewound.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Rewinding to the checkpoint\nFailed with error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"tank"}, fsNames(fsList)); diff != "" {
+		t.Errorf("FileSystems()\nTest: Rewinding to the checkpoint\ndiff:\n%s", diff)
+	}
+
+	if info, err := rewound.CheckpointInfo(); err != nil || info.Exists {
+		t.Errorf("CheckpointInfo()\nTest: Checkpoint removed by the rewind\nGot: %v\nFailed with error: %v", info, err)
+	}
+
+	if err := rewound.DiscardCheckpoint(); !errors.Is(err, zfs.ErrNotFound) {
+		t.Errorf("DiscardCheckpoint()\nTest: Missing checkpoint\nGot: %v\nWant: %v", err, zfs.ErrNotFound)
+	}
+
+	if err := rewound.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint()\nTest: Creating another checkpoint\nFailed with error: %v", err)
+	}
+
+	if err := rewound.DiscardCheckpoint(); err != nil {
+		t.Fatalf("DiscardCheckpoint()\nTest: Discarding the checkpoint\nFailed with error: %v", err)
+	}
+
+	if info, err := rewound.CheckpointInfo(); err != nil || !info.Discarding {
+		t.Errorf("CheckpointInfo()\nTest: Discarding the checkpoint\nGot: %v\nFailed with error: %v", info, err)
+	}
+}
+
+func TestSimTrim(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+
+	sim := zfstest.New()
+	sim.SetClock(func() time.Time { return now })
+
+	pool := newTestPool(t, sim)
+
+	if err := pool.Trim(&zfs.TrimOptions{Devices: []string{"sda"}}); err != nil {
+		t.Fatalf("Trim()\nTest: Trimming a device\nFailed with error: %v", err)
+	}
+
+	if err := pool.Trim(nil); err == nil {
+		t.Errorf("Trim()\nTest: Device already being trimmed\nGot: nil error")
+	}
+
+	if err := pool.Initialize(nil); err != nil {
+		t.Fatalf("Initialize()\nTest: Initializing the pool\nFailed with error: %v", err)
+	}
+
+	if err := pool.SuspendInitialize("sdb"); err != nil {
+		t.Fatalf("SuspendInitialize()\nTest: Suspending a device\nFailed with error: %v", err)
+	}
+
+	if err := sim.SetVdevProgress("tank", "sda", "trim", 100); err != nil {
+		t.Fatalf("SetVdevProgress()\nTest: Completing the trim\nFailed with error: %v", err)
+	}
+
+	if err := sim.SetVdevProgress("tank", "sda", "initialize", 40); err != nil {
+		t.Fatalf("SetVdevProgress()\nTest: Initialize progress\nFailed with error: %v", err)
+	}
+
+	got, err := pool.VdevProgress()
+	if err != nil {
+		t.Fatalf("VdevProgress()\nTest: Trim and initialize progress\nFailed with error: %v", err)
+	}
+
+	want := zfs.VdevProgressList{
+		{
+			Name:       "sda",
+			Trim:       &zfs.VdevActivityProgress{State: zfs.VdevActivityComplete, Percent: 100, Time: now},
+			Initialize: &zfs.VdevActivityProgress{State: zfs.VdevActivityActive, Percent: 40, Time: now},
+		},
+		{
+			Name:       "sdb",
+			Trim:       &zfs.VdevActivityProgress{State: zfs.VdevActivityNone},
+			Initialize: &zfs.VdevActivityProgress{State: zfs.VdevActivitySuspended, Time: now},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("VdevProgress()\nTest: Trim and initialize progress\ndiff:\n%s", diff)
+	}
+
+	if err := pool.CancelInitialize(); err != nil {
+		t.Fatalf("CancelInitialize()\nTest: Canceling the initialize\nFailed with error: %v", err)
+	}
+
+	if err := pool.CancelTrim("sda"); err == nil {
+		t.Errorf("CancelTrim()\nTest: Completed trim\nGot: nil error")
+	}
+}
+
+func TestSimWait(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	if err := sim.SetScan("tank", "resilver in progress since Sun Oct 18 10:00:00 2026"); err != nil {
+		t.Fatalf("SetScan()\nTest: Starting a resilver\nFailed with error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := pool.Wait(ctx, zfs.WaitResilver); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait()\nTest: Resilver in progress\nGot: %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- pool.Wait(context.Background(), zfs.WaitResilver, zfs.WaitTrim)
+	}()
+
+	if err := sim.SetScan("tank", "resilvered 1.20G in 00:01:00 with 0 errors on Sun Oct 18 10:01:00 2026"); err != nil {
+		t.Fatalf("SetScan()\nTest: Completing the resilver\nFailed with error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Wait()\nTest: Resilver completed\nFailed with error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Wait()\nTest: Resilver completed\nGot: still waiting")
+	}
+
+	fsList, err := pool.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the root file system\nFailed with error: %v", err)
+	}
+
+	if err := fsList[0].Wait(context.Background()); err != nil {
+		t.Errorf("FileSystem.Wait()\nTest: Waiting for the delete queue\nFailed with error: %v", err)
+	}
+}
+
+func TestSimDiffAndUserSpace(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+	sim.SetClock(func() time.Time { return now })
+
+	pool := newTestPool(t, sim)
+
+	if importable, err := sim.System().ImportablePools(); err != nil || len(importable) != 0 {
+		t.Errorf("ImportablePools()\nTest: No exported pools\nGot: %v, %v", importable, err)
+	}
+
+	if err := sim.RecordFileChange("tank", "+", "F", "/tank/old.txt", ""); err != nil {
+		t.Fatalf("RecordFileChange()\nTest: Change before the snapshot\nFailed with error: %v", err)
+	}
+
+	if err := sim.Snapshot("tank@daily", false); err != nil {
+		t.Fatalf("Snapshot()\nTest: Creating the snapshot\nFailed with error: %v", err)
+	}
+
+	if err := sim.RecordFileChange("tank", "R", "F", "/tank/old.txt", "/tank/new name.txt"); err != nil {
+		t.Fatalf("RecordFileChange()\nTest: Renaming a file\nFailed with error: %v", err)
+	}
+
+	fsList, err := pool.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the root file system\nFailed with error: %v", err)
+	}
+
+	root := fsList[0]
+
+	snaps, err := root.Snapshots()
+	if err != nil || len(snaps) != 1 {
+		t.Fatalf("Snapshots()\nTest: Listing the snapshot\nGot: %v, %v", snaps, err)
+	}
+
+	changes, err := snaps[0].Diff(root)
+	if err != nil {
+		t.Fatalf("Diff()\nTest: Changes since the snapshot\nFailed with error: %v", err)
+	}
+
+	wantChanges := zfs.DiffEntryList{
+		{
+			Change:     zfs.DiffChangeRenamed,
+			FileType:   zfs.DiffFileRegular,
+			Path:       "/tank/old.txt",
+			NewPath:    "/tank/new name.txt",
+			ChangeTime: now,
+		},
+	}
+
+	if diff := cmp.Diff(wantChanges, changes); diff != "" {
+		t.Errorf("Diff()\nTest: Changes since the snapshot\ndiff:\n%s", diff)
+	}
+
+	if err := sim.SetUserSpace("tank", "user", "alice", 1<<20, 10); err != nil {
+		t.Fatalf("SetUserSpace()\nTest: Space used by alice\nFailed with error: %v", err)
+	}
+
+	if err := root.SetUserQuota("bob", 1<<30); err != nil {
+		t.Fatalf("SetUserQuota()\nTest: Quota of bob\nFailed with error: %v", err)
+	}
+
+	users, err := root.UserSpace()
+	if err != nil {
+		t.Fatalf("UserSpace()\nTest: Listing the users\nFailed with error: %v", err)
+	}
+
+	wantUsers := zfs.UserSpaceList{
+		{Type: "POSIX User", Name: "alice", Used: 1 << 20, ObjUsed: 10, FileSystem: root},
+		{Type: "POSIX User", Name: "bob", Quota: 1 << 30, FileSystem: root},
+	}
+
+	if diff := cmp.Diff(wantUsers, users, cmpopts.IgnoreUnexported(zfs.System{})); diff != "" {
+		t.Errorf("UserSpace()\nTest: Listing the users\ndiff:\n%s", diff)
+	}
+
+	projects, err := root.ProjectSpace()
+	if err != nil || len(projects) != 0 {
+		t.Errorf("ProjectSpace()\nTest: No projects\nGot: %v, %v", projects, err)
+	}
+}
+
+func TestSimQuota(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	if err := sim.CreateFileSystem("tank/home", map[string]string{"refquota": "1.5G"}); err != nil {
+		t.Fatalf("CreateFileSystem()\nTest: Creating with a refquota\nFailed with error: %v", err)
+	}
+
+	fsList, err := pool.FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the file systems\nFailed with error: %v", err)
+	}
+
+	home := fsList[1]
+
+	if err := home.SetProp("quota", "10G"); err != nil {
+		t.Fatalf("SetProp()\nTest: Setting a human readable quota\nFailed with error: %v", err)
+	}
+
+	if got, err := home.Quota(); err != nil || got != 10<<30 {
+		t.Errorf("Quota()\nTest: Human readable quota\nGot: %d, %v\nWant: %d", got, err, 10<<30)
+	}
+
+	if got, err := home.RefQuota(); err != nil || got != 3<<29 {
+		t.Errorf("RefQuota()\nTest: Fractional refquota\nGot: %d, %v\nWant: %d", got, err, 3<<29)
+	}
+
+	if err := home.SetUserQuota("alice", 512<<20); err != nil {
+		t.Fatalf("SetUserQuota()\nTest: Setting a user quota\nFailed with error: %v", err)
+	}
+
+	if got, err := home.UserQuota("alice"); err != nil || got != 512<<20 {
+		t.Errorf("UserQuota()\nTest: Exact user quota\nGot: %d, %v\nWant: %d", got, err, 512<<20)
+	}
+
+	if err := home.SetQuota(0); err != nil {
+		t.Fatalf("SetQuota()\nTest: Removing the quota\nFailed with error: %v", err)
+	}
+
+	if got, err := home.Quota(); err != nil || got != 0 {
+		t.Errorf("Quota()\nTest: Removed quota\nGot: %d, %v\nWant: 0", got, err)
+	}
+
+	if err := home.SetProp("quota", "10X"); err == nil {
+		t.Errorf("SetProp()\nTest: Invalid quota\nGot: nil error")
+	}
+}
+
+func TestSimJSONVersion(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	sim.SetVersion("2.3.0")
+	newTestPool(t, sim)
+
+	pools, err := sim.System().ListPools()
+	if err != nil {
+		t.Fatalf("ListPools()\nTest: Listing the pools with a JSON capable version\nFailed with error: %v", err)
+	}
+
+	if len(pools) != 1 || pools[0].Name != "tank" {
+		t.Errorf("ListPools()\nTest: Listing the pools with a JSON capable version\nFailed: got %v", pools)
+	}
+
+	if _, err := pools[0].Status(); err != nil {
+		t.Errorf("Status()\nTest: Pool status with a JSON capable version\nFailed with error: %v", err)
+	}
+}
+
+func TestSimIsMounted(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	newTestPool(t, sim)
+
+	if err := sim.CreateFileSystem("tank/home", map[string]string{"mountpoint": "/srv/home"}); err != nil {
+		t.Fatalf("CreateFileSystem()\nTest: Creating the file system\nFailed with error: %v", err)
+	}
+
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	mountInfo := "96 28 0:48 / /srv/home rw,relatime - zfs tank/home rw,xattr,noacl\n"
+
+	if err := os.WriteFile(mountInfoPath, []byte(mountInfo), 0o600); err != nil {
+		t.Fatalf("WriteFile()\nTest: Writing the mount info\nFailed with error: %v", err)
+	}
+
+	system := zfs.NewSystem(&zfs.SystemConfig{Executor: sim, DisableJSON: true, MountInfoPath: mountInfoPath})
+
+	pools, err := system.ListPools()
+	if err != nil {
+		t.Fatalf("ListPools()\nTest: Listing the pools\nFailed with error: %v", err)
+	}
+
+	fsList, err := pools[0].FileSystems()
+	if err != nil {
+		t.Fatalf("FileSystems()\nTest: Listing the file systems\nFailed with error: %v", err)
+	}
+
+	got := make(map[string]bool)
+
+	for _, fs := range fsList {
+		mounted, err := fs.IsMounted()
+		if err != nil {
+			t.Fatalf("IsMounted()\nTest: Checking whether %s is mounted\nFailed with error: %v", fs.FullName(), err)
+		}
+
+		got[fs.FullName()] = mounted
+	}
+
+	want := map[string]bool{"tank": false, "tank/home": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("IsMounted()\nTest: Checking the mounted file systems\nFailed with diff:\n%s", diff)
+	}
+}
+
+func TestSimInitializeStatus(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+
+	sim := zfstest.New()
+	sim.SetClock(func() time.Time { return now })
+
+	pool := newTestPool(t, sim)
+
+	if err := pool.Initialize(&zfs.InitializeOptions{Devices: []string{"sda"}}); err != nil {
+		t.Fatalf("Initialize()\nTest: Initializing a device\nFailed with error: %v", err)
+	}
+
+	if err := pool.Trim(&zfs.TrimOptions{Devices: []string{"sda"}}); err != nil {
+		t.Fatalf("Trim()\nTest: Trimming a device\nFailed with error: %v", err)
+	}
+
+	// Without -i and -t, only the activities in progress are reported.
+	out, err := sim.Run(context.Background(), nil, "zpool", "status", "tank")
+	if err != nil || !strings.Contains(out, " (initializing)  (trimming)\n") || strings.Contains(out, "initialized") {
+		t.Errorf("Run()\nTest: Status without -i and -t\nGot: %q\nFailed with error: %v", out, err)
+	}
+
+	// The versions older than 2.2 report the progress of the initialized
+	// devices by default and do not support -i.
+	sim.SetVersion("2.1.5")
+
+	if _, err := sim.Run(context.Background(), nil, "zpool", "status", "-i", "tank"); err == nil {
+		t.Errorf("Run()\nTest: Status with -i on OpenZFS 2.1\nGot: nil error")
+	}
+
+	got, err := sim.System().ListPools()
+	if err != nil || len(got) != 1 {
+		t.Fatalf("ListPools()\nTest: Listing the pools\nGot: %v\nFailed with error: %v", got, err)
+	}
+
+	progress, err := got[0].VdevProgress()
+	if err != nil {
+		t.Fatalf("VdevProgress()\nTest: Progress on OpenZFS 2.1\nFailed with error: %v", err)
+	}
+
+	want := zfs.VdevProgressList{
+		{
+			Name:       "sda",
+			Trim:       &zfs.VdevActivityProgress{State: zfs.VdevActivityActive, Time: now},
+			Initialize: &zfs.VdevActivityProgress{State: zfs.VdevActivityActive, Time: now},
+		},
+		{Name: "sdb", Trim: &zfs.VdevActivityProgress{State: zfs.VdevActivityNone}},
+	}
+
+	if diff := cmp.Diff(want, progress); diff != "" {
+		t.Errorf("VdevProgress()\nTest: Progress on OpenZFS 2.1\ndiff:\n%s", diff)
+	}
+}
+
+func TestSimEvents(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	newTestPool(t, sim)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := sim.System().WatchEvents(ctx)
+	if err != nil {
+		t.Fatalf("WatchEvents()\nTest: Watching the events\nFailed with error: %v", err)
+	}
+
+	if err := sim.SetDeviceState("tank", "sda", "FAULTED"); err != nil {
+		t.Fatalf("SetDeviceState()\nTest: Faulting a device\nFailed with error: %v", err)
+	}
+
+	select {
+	case e := <-events:
+		if e.Err != nil {
+			t.Fatalf("WatchEvents()\nTest: State change event\nFailed with error: %v", e.Err)
+		}
+
+		if e.Class != zfs.EventClassStateChange || e.Pool != "tank" || e.Vdev != "/dev/sda" ||
+			e.VdevState() != "FAULTED" || e.VdevLastState() != "ONLINE" {
+			t.Errorf("WatchEvents()\nTest: State change event\nGot: %v, state %q, last state %q", e, e.VdevState(), e.VdevLastState())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("WatchEvents()\nTest: State change event\nTimed out")
+	}
+}
+
+func TestSimIOStats(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	samples, err := pool.IOStats(context.Background(), time.Millisecond, 1, &zfs.IOStatsOptions{Latency: true, Queue: true})
+	if err != nil {
+		t.Fatalf("IOStats()\nTest: Sampling the statistics\nFailed with error: %v", err)
+	}
+
+	sample := <-samples
+	if sample == nil || sample.Err != nil {
+		t.Fatalf("IOStats()\nTest: Sampling the statistics\nGot sample: %v", sample)
+	}
+
+	var names []string
+	for _, s := range sample.Vdevs {
+		names = append(names, s.Name)
+	}
+
+	if diff := cmp.Diff([]string{"tank", "mirror-0", "sda", "sdb"}, names); diff != "" {
+		t.Errorf("IOStats()\nTest: Sampling the statistics\ndiff:\n%s", diff)
+	}
+}
+
+func TestSimIOStatsStream(t *testing.T) {
+	t.Parallel()
+
+	sim := zfstest.New()
+	pool := newTestPool(t, sim)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := len(sim.Commands())
+
+	samples, err := pool.IOStats(ctx, 5*time.Millisecond, 0, nil)
+	if err != nil {
+		t.Fatalf("IOStats()\nTest: Streaming the statistics\nFailed with error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case sample := <-samples:
+			if sample == nil || sample.Err != nil || len(sample.Vdevs) != 4 {
+				t.Fatalf("IOStats()\nTest: Streaming the statistics\nGot sample %d: %v", i, sample)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("IOStats()\nTest: Streaming the statistics\nTimed out waiting for sample %d", i)
+		}
+	}
+
+	cancel()
+
+	for range samples {
+	}
+
+	// All the samples are reported by a single command.
+	if diff := cmp.Diff([]string{"zpool iostat -H -p -v -y tank 0.005"}, sim.Commands()[before:]); diff != "" {
+		t.Errorf("IOStats()\nTest: Streaming the statistics\ndiff:\n%s", diff)
+	}
+}
@@ -0,0 +1,251 @@
+package zfstest
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	activityTrim       = "trim"
+	activityInitialize = "initialize"
+
+	activityActive    = "started"
+	activitySuspended = "suspended"
+	activityComplete  = "completed"
+
+	activityTimestampLayout = "Mon Jan _2 15:04:05 2006"
+)
+
+const (
+	// initializeInProgress only reports the initialize in progress, which
+	// is the default of OpenZFS 2.2 or later.
+	initializeInProgress initializeReport = iota
+	// initializeStarted reports the progress of the vdevs that were
+	// initialized, which is the default of the older versions.
+	initializeStarted
+	// initializeAll reports the progress of all the vdevs, i.e. 'zpool
+	// status -i'.
+	initializeAll
+)
+
+var (
+	// Progressive form of the activities as reported by zpool.
+	activityInProgress = map[string]string{
+		activityTrim:       "trimming",
+		activityInitialize: "initializing",
+	}
+
+	// Description of the states as reported by zpool status.
+	activityStateDesc = map[string]string{
+		activityActive:    "started",
+		activitySuspended: "suspended, started",
+		activityComplete:  "completed",
+	}
+)
+
+// initializeReport represents how the initialize progress of the leaf
+// vdevs is reported by zpool status.
+type initializeReport uint8
+
+// activity represents the trim or initialize progress of a leaf vdev.
+type activity struct {
+	// State of the activity as reported by zpool status, i.e. "started",
+	// "suspended" or "completed".
+	state   string
+	percent uint8
+	// Time of the last change of the state.
+	time time.Time
+}
+
+// SetVdevProgress sets the completion percentage of the in-progress trim
+// or initialize (i.e. "trim" or "initialize") of the device of the pool,
+// the activity completes at 100 percent.
+func (s *Sim) SetVdevProgress(poolName string, device string, activityName string, percent uint8) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, v, err := s.leafDevice(poolName, device)
+	if err != nil {
+		return err
+	}
+
+	a := v.activity(activityName)
+	if a == nil || *a == nil || (*a).state == activityComplete {
+		return fmt.Errorf("no %s in progress on device %q", activityName, device)
+	}
+
+	if percent > 100 {
+		return fmt.Errorf("invalid percentage %d", percent)
+	}
+
+	(*a).percent = percent
+	if percent == 100 {
+		(*a).state, (*a).time = activityComplete, s.now()
+	}
+
+	return nil
+}
+
+// activity returns the trim or initialize progress of the vdev.
+func (v *vdev) activity(name string) **activity {
+	switch name {
+	case activityTrim:
+		return &v.trim
+	case activityInitialize:
+		return &v.initialize
+	}
+
+	return nil
+}
+
+// annotation returns the initialize and trim progress of a leaf vdev as
+// reported by 'zpool status', where the progress of the trim is only
+// reported if trim is true (i.e. -t) and the trim is otherwise only
+// reported if in progress.
+func (v *vdev) annotation(trim bool, initialize initializeReport) string {
+	result := ""
+
+	switch a := v.initialize; {
+	case a != nil && initialize != initializeInProgress:
+		result += fmt.Sprintf("  (%d%% initialized, %s)", a.percent, a.progressTime())
+	case initialize == initializeAll:
+		result += " (uninitialized)"
+	case a != nil && a.state == activityActive:
+		result += " (initializing)"
+	}
+
+	switch a := v.trim; {
+	case a != nil && trim:
+		result += fmt.Sprintf("  (%d%% trimmed, %s)", a.percent, a.progressTime())
+	case trim:
+		result += "  (untrimmed)"
+	case a != nil && a.state == activityActive:
+		result += "  (trimming)"
+	}
+
+	return result
+}
+
+// progressTime returns the state and the time of the activity as reported
+// by 'zpool status', e.g. "started at Sun Oct 11 00:24:01 2026".
+func (a *activity) progressTime() string {
+	return activityStateDesc[a.state] + " at " + a.time.Format(activityTimestampLayout)
+}
+
+func (s *Sim) zpoolTrim(args []string) (string, error) {
+	return s.runActivity(activityTrim, args, "r")
+}
+
+func (s *Sim) zpoolInitialize(args []string) (string, error) {
+	return s.runActivity(activityInitialize, args, "")
+}
+
+// runActivity runs 'zpool trim' or 'zpool initialize', which start (or
+// resume), suspend (-s) or cancel (-c) the activity on the devices of the
+// pool.
+func (s *Sim) runActivity(name string, args []string, valueFlags string) (string, error) {
+	a, err := parseArgs(args, valueFlags)
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 1 {
+		return "", usageError("missing pool name argument")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	var devices []*vdev
+
+	if len(a.pos) > 1 {
+		for _, d := range a.pos[1:] {
+			v, _ := p.vdevs.find(d)
+			if v == nil || !v.isLeaf() {
+				return "", cmdError("cannot %s '%s': no such device in pool", name, d)
+			}
+
+			devices = append(devices, v)
+		}
+	} else {
+		devices = p.vdevs.activityLeaves()
+	}
+
+	for _, v := range devices {
+		if v.state != stateOnline {
+			return "", cmdError("cannot %s '%s': device is unavailable", name, v.name)
+		}
+	}
+
+	// Validate all the devices before changing any of them.
+	next := make([]*activity, len(devices))
+
+	for i, v := range devices {
+		next[i], err = nextActivity(name, v.name, *v.activity(name), a.has('s'), a.has('c'), s.now())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for i, v := range devices {
+		*v.activity(name) = next[i]
+	}
+
+	return "", nil
+}
+
+// nextActivity returns the progress of the activity after starting,
+// suspending or canceling the activity.
+func nextActivity(name string, device string, cur *activity, suspend bool, cancel bool, now time.Time) (*activity, error) {
+	inProgress := cur != nil && cur.state != activityComplete
+
+	switch {
+	case cancel:
+		if !inProgress {
+			return nil, cmdError("cannot cancel %s '%s': there is no active %s", name, device, name)
+		}
+
+		return nil, nil
+	case suspend:
+		if !inProgress || cur.state == activitySuspended {
+			return nil, cmdError("cannot suspend %s '%s': there is no active %s", name, device, name)
+		}
+
+		return &activity{state: activitySuspended, percent: cur.percent, time: now}, nil
+	case inProgress && cur.state == activityActive:
+		return nil, cmdError("cannot %s '%s': currently %s", name, device, activityInProgress[name])
+	case inProgress:
+		return &activity{state: activityActive, percent: cur.percent, time: now}, nil
+	}
+
+	return &activity{state: activityActive, time: now}, nil
+}
+
+// activityLeaves returns the leaf vdevs that can be trimmed or initialized,
+// i.e. excluding the cache devices and spares.
+func (t *vdevTree) activityLeaves() []*vdev {
+	var result []*vdev
+
+	var walk func(v *vdev)
+	walk = func(v *vdev) {
+		if v.isLeaf() {
+			result = append(result, v)
+		}
+
+		for _, c := range v.children {
+			walk(c)
+		}
+	}
+
+	walk(t.root)
+
+	for _, list := range [][]*vdev{t.special, t.dedup, t.logs} {
+		for _, v := range list {
+			walk(v)
+		}
+	}
+
+	return result
+}
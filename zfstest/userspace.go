@@ -0,0 +1,160 @@
+package zfstest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// spaceKind describes the entities reported by 'zfs userspace',
+// 'zfs groupspace' or 'zfs projectspace'.
+type spaceKind struct {
+	// Type of the entities as reported by zfs, e.g. "POSIX User".
+	typ string
+	// Prefixes of the quota properties of the entities.
+	quotaPrefix    string
+	objQuotaPrefix string
+}
+
+// entitySpace represents the space used by a user, group or project.
+type entitySpace struct {
+	used    uint64
+	objUsed uint64
+}
+
+var (
+	// Kinds of entities by zfs subcommand.
+	spaceKinds = map[string]*spaceKind{
+		"userspace":    {"POSIX User", "userquota@", "userobjquota@"},
+		"groupspace":   {"POSIX Group", "groupquota@", "groupobjquota@"},
+		"projectspace": {"Project", "projectquota@", "projectobjquota@"},
+	}
+	// Subcommands by the kind of entity accepted by SetUserSpace.
+	spaceSubcommands = map[string]string{
+		"user":    "userspace",
+		"group":   "groupspace",
+		"project": "projectspace",
+	}
+)
+
+// SetUserSpace sets the space (in bytes) and the number of objects used by
+// the user, group or project (i.e. kind is "user", "group" or "project")
+// within the file system, as reported by 'zfs userspace', 'zfs groupspace'
+// or 'zfs projectspace'.
+func (s *Sim) SetUserSpace(fsName string, kind string, name string, used uint64, objUsed uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := spaceSubcommands[kind]
+	if !ok {
+		return fmt.Errorf("invalid kind %q", kind)
+	}
+
+	ds, ok := s.dataset(fsName)
+	if !ok || ds.snapshot {
+		return fmt.Errorf("file system %q does not exist", fsName)
+	}
+
+	if ds.space == nil {
+		ds.space = make(map[string]map[string]*entitySpace)
+	}
+
+	if ds.space[sub] == nil {
+		ds.space[sub] = make(map[string]*entitySpace)
+	}
+
+	ds.space[sub][name] = &entitySpace{used: used, objUsed: objUsed}
+
+	return nil
+}
+
+// zfsUserSpace runs 'zfs userspace', 'zfs groupspace' or
+// 'zfs projectspace', which report the entities that either use space or
+// have a quota.
+func (s *Sim) zfsUserSpace(sub string, args []string) (string, error) {
+	a, err := parseArgs(args, "osSt")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("wrong number of arguments")
+	}
+
+	ds, err := s.lookup(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	cols := []string{"type", "name", "used", "quota"}
+	if a.has('o') {
+		cols = strings.Split(a.value('o'), ",")
+	}
+
+	kind := spaceKinds[sub]
+
+	names := make(map[string]bool)
+	for name := range ds.space[sub] {
+		names[name] = true
+	}
+
+	for prop := range ds.props {
+		for _, prefix := range []string{kind.quotaPrefix, kind.objQuotaPrefix} {
+			if strings.HasPrefix(prop, prefix) {
+				names[strings.TrimPrefix(prop, prefix)] = true
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+
+	sort.Strings(sorted)
+
+	var b strings.Builder
+
+	for _, name := range sorted {
+		usage := ds.space[sub][name]
+		if usage == nil {
+			usage = &entitySpace{}
+		}
+
+		row := make([]string, len(cols))
+
+		for i, col := range cols {
+			switch col {
+			case "type":
+				row[i] = kind.typ
+			case "name":
+				row[i] = name
+			case "used":
+				row[i] = strconv.FormatUint(usage.used, 10)
+			case "objused":
+				row[i] = strconv.FormatUint(usage.objUsed, 10)
+			case "quota":
+				row[i] = entityQuota(ds, kind.quotaPrefix+name)
+			case "objquota":
+				row[i] = entityQuota(ds, kind.objQuotaPrefix+name)
+			default:
+				return "", usageError("invalid type '%s'", col)
+			}
+		}
+
+		b.WriteString(strings.Join(row, "\t") + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// entityQuota returns the value of the quota property as reported by
+// 'zfs userspace -p', i.e. "none" if not set.
+func entityQuota(ds *dataset, prop string) string {
+	if val, ok := ds.props[prop]; ok {
+		return val
+	}
+
+	return "none"
+}
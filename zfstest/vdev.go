@@ -0,0 +1,474 @@
+package zfstest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	vdevRoot   = "root"
+	vdevDisk   = "disk"
+	vdevMirror = "mirror"
+	vdevRaidZ  = "raidz"
+	vdevDRaid  = "draid"
+
+	stateOnline   = "ONLINE"
+	stateDegraded = "DEGRADED"
+	stateFaulted  = "FAULTED"
+	stateOffline  = "OFFLINE"
+	stateUnavail  = "UNAVAIL"
+	stateRemoved  = "REMOVED"
+	stateAvail    = "AVAIL"
+)
+
+var (
+	// Numeric vdev states (vdev_state_t) as reported in the events.
+	vdevStateNums = map[string]uint64{
+		stateOffline:  2,
+		stateRemoved:  3,
+		stateUnavail:  4,
+		stateFaulted:  5,
+		stateDegraded: 6,
+		stateOnline:   7,
+	}
+)
+
+// vdev represents a simulated vdev.
+type vdev struct {
+	// Name as reported by zpool status, e.g. "mirror-0" or "sda".
+	name string
+	typ  string
+	// Parity of raidz and draid vdevs.
+	parity int
+	// Number of distributed spares of draid vdevs.
+	spares int
+	guid   uint64
+	// ID of a top-level vdev, used to name the vdev.
+	id int
+	// State of a leaf vdev, the state of the other vdevs is computed from
+	// the state of their children.
+	state    string
+	read     uint64
+	write    uint64
+	checksum uint64
+	// Trim and initialize progress of a leaf vdev, nil if never started or
+	// canceled.
+	trim       *activity
+	initialize *activity
+	children   []*vdev
+}
+
+// vdevTree represents the vdevs of a simulated pool.
+type vdevTree struct {
+	root    *vdev
+	special []*vdev
+	dedup   []*vdev
+	logs    []*vdev
+	cache   []*vdev
+	spares  []*vdev
+	// Number of top-level vdevs ever added, used to name the vdevs.
+	nextID int
+}
+
+// vdevClass represents an allocation class of the vdev tree in the order
+// reported by zpool status.
+type vdevClass struct {
+	header string
+	vdevs  *[]*vdev
+}
+
+func (t *vdevTree) classes() []vdevClass {
+	return []vdevClass{
+		{"special", &t.special},
+		{"dedup", &t.dedup},
+		{"logs", &t.logs},
+		{"cache", &t.cache},
+		{"spares", &t.spares},
+	}
+}
+
+// isLeaf returns true if the vdev is a device.
+func (v *vdev) isLeaf() bool {
+	return v.typ == vdevDisk
+}
+
+// computedState returns the state of the vdev, which is derived from the
+// state of the children for the non-leaf vdevs.
+func (v *vdev) computedState() string {
+	if v.isLeaf() {
+		return v.state
+	}
+
+	unusable := 0
+	degraded := false
+
+	for _, c := range v.children {
+		switch c.computedState() {
+		case stateOnline:
+		case stateDegraded:
+			degraded = true
+		default:
+			unusable++
+		}
+	}
+
+	tolerance := 0
+
+	switch v.typ {
+	case vdevRoot:
+		if unusable > 0 {
+			return stateUnavail
+		}
+	case vdevMirror:
+		tolerance = len(v.children) - 1
+	case vdevRaidZ, vdevDRaid:
+		tolerance = v.parity
+	}
+
+	switch {
+	case unusable > tolerance:
+		return stateUnavail
+	case unusable > 0 || degraded:
+		return stateDegraded
+	}
+
+	return stateOnline
+}
+
+// capacity returns the usable capacity of a top-level vdev.
+func (v *vdev) capacity() uint64 {
+	switch v.typ {
+	case vdevMirror:
+		return DeviceSize
+	case vdevRaidZ, vdevDRaid:
+		data := len(v.children) - v.parity - v.spares
+		if data < 1 {
+			data = 1
+		}
+
+		return uint64(data) * DeviceSize
+	}
+
+	return DeviceSize
+}
+
+// all returns all the vdevs of the tree in depth first order.
+func (t *vdevTree) all() []*vdev {
+	var result []*vdev
+
+	var walk func(v *vdev)
+	walk = func(v *vdev) {
+		result = append(result, v)
+		for _, c := range v.children {
+			walk(c)
+		}
+	}
+
+	walk(t.root)
+
+	for _, c := range t.classes() {
+		for _, v := range *c.vdevs {
+			walk(v)
+		}
+	}
+
+	return result
+}
+
+// find returns the vdev with the specified name (or path of the device)
+// along with its parent, the parent is nil for the top-level vdevs of the
+// allocation classes.
+func (t *vdevTree) find(name string) (*vdev, *vdev) {
+	name = deviceName(name)
+
+	var parentOf func(parent *vdev, list []*vdev) (*vdev, *vdev)
+	parentOf = func(parent *vdev, list []*vdev) (*vdev, *vdev) {
+		for _, v := range list {
+			if v.name == name || strconv.FormatUint(v.guid, 10) == name {
+				return v, parent
+			}
+
+			if found, p := parentOf(v, v.children); found != nil {
+				return found, p
+			}
+		}
+
+		return nil, nil
+	}
+
+	if found, p := parentOf(nil, []*vdev{t.root}); found != nil {
+		return found, p
+	}
+
+	for _, c := range t.classes() {
+		if found, p := parentOf(nil, *c.vdevs); found != nil {
+			return found, p
+		}
+	}
+
+	return nil, nil
+}
+
+// deviceName returns the name of the device as reported by zpool status,
+// which omits the "/dev/" prefix.
+func deviceName(device string) string {
+	return strings.TrimPrefix(device, "/dev/")
+}
+
+// parseVdevLayout parses the vdev arguments of 'zpool create' or 'zpool
+// add', e.g. "mirror sda sdb log sdc".
+func (s *Sim) parseVdevLayout(poolName string, args []string) (*vdevTree, error) {
+	tree := &vdevTree{root: &vdev{name: poolName, typ: vdevRoot, guid: s.guid()}}
+
+	if err := s.addVdevs(tree, args); err != nil {
+		return nil, err
+	}
+
+	if len(tree.root.children) == 0 {
+		return nil, usageError("invalid vdev specification: at least one toplevel vdev must be specified")
+	}
+
+	return tree, nil
+}
+
+// addVdevs adds the vdevs of the layout arguments to the tree.
+func (s *Sim) addVdevs(tree *vdevTree, args []string) error {
+	class := &tree.root.children
+	isDevicesOnly := false
+
+	var group *vdev
+
+	seen := make(map[string]bool)
+	for _, v := range tree.all() {
+		seen[v.name] = true
+	}
+
+	for _, arg := range args {
+		switch {
+		case arg == "special" || arg == "dedup" || arg == "log" || arg == "logs":
+			class, isDevicesOnly, group = tree.classList(arg), false, nil
+			continue
+		case arg == "cache" || arg == "spare" || arg == "spares":
+			class, isDevicesOnly, group = tree.classList(arg), true, nil
+			continue
+		case !isDevicesOnly && (arg == vdevMirror || strings.HasPrefix(arg, vdevRaidZ) || strings.HasPrefix(arg, vdevDRaid)):
+			v, err := newGroupVdev(arg)
+			if err != nil {
+				return err
+			}
+
+			v.id = tree.nextID
+			v.name = fmt.Sprintf("%s-%d", v.name, v.id)
+			v.guid = s.guid()
+			tree.nextID++
+			group = v
+			*class = append(*class, v)
+
+			continue
+		}
+
+		name := deviceName(arg)
+		if seen[name] {
+			return cmdError("invalid vdev specification: %s is part of the pool or specified more than once", arg)
+		}
+
+		for _, p := range s.pools {
+			if d, _ := p.vdevs.find(name); d != nil && d.isLeaf() {
+				return cmdError("invalid vdev specification: %s is part of active pool '%s'", arg, p.name)
+			}
+		}
+
+		seen[name] = true
+
+		dev := &vdev{name: name, typ: vdevDisk, guid: s.guid(), state: stateOnline}
+
+		if group != nil {
+			group.children = append(group.children, dev)
+		} else {
+			if !isDevicesOnly {
+				dev.id = tree.nextID
+				tree.nextID++
+			}
+
+			*class = append(*class, dev)
+		}
+	}
+
+	for _, v := range tree.all() {
+		minDevices := 0
+
+		switch v.typ {
+		case vdevMirror:
+			minDevices = 2
+		case vdevRaidZ:
+			minDevices = v.parity + 1
+		case vdevDRaid:
+			minDevices = v.parity + v.spares + 1
+		}
+
+		if len(v.children) < minDevices {
+			return cmdError("invalid vdev specification: %s requires at least %d devices", v.name, minDevices)
+		}
+	}
+
+	return nil
+}
+
+func (t *vdevTree) classList(keyword string) *[]*vdev {
+	switch keyword {
+	case "special":
+		return &t.special
+	case "dedup":
+		return &t.dedup
+	case "log", "logs":
+		return &t.logs
+	case "cache":
+		return &t.cache
+	default:
+		return &t.spares
+	}
+}
+
+// newGroupVdev returns a new mirror, raidz or draid vdev for the type
+// specification, e.g. "raidz2" or "draid1:4d:6c:1s".
+func newGroupVdev(spec string) (*vdev, error) {
+	if spec == vdevMirror {
+		return &vdev{name: vdevMirror, typ: vdevMirror}, nil
+	}
+
+	if strings.HasPrefix(spec, vdevRaidZ) {
+		parity := 1
+		if p := strings.TrimPrefix(spec, vdevRaidZ); p != "" {
+			val, err := strconv.Atoi(p)
+			if err != nil || val < 1 || val > 3 {
+				return nil, usageError("invalid vdev specification: invalid raidz type '%s'", spec)
+			}
+
+			parity = val
+		}
+
+		return &vdev{name: fmt.Sprintf("%s%d", vdevRaidZ, parity), typ: vdevRaidZ, parity: parity}, nil
+	}
+
+	parts := strings.Split(spec, ":")
+
+	parity := 1
+	if p := strings.TrimPrefix(parts[0], vdevDRaid); p != "" {
+		val, err := strconv.Atoi(p)
+		if err != nil || val < 1 || val > 3 {
+			return nil, usageError("invalid vdev specification: invalid draid type '%s'", spec)
+		}
+
+		parity = val
+	}
+
+	v := &vdev{name: spec, typ: vdevDRaid, parity: parity}
+
+	for _, part := range parts[1:] {
+		if strings.HasSuffix(part, "s") {
+			val, err := strconv.Atoi(strings.TrimSuffix(part, "s"))
+			if err != nil {
+				return nil, usageError("invalid vdev specification: invalid draid type '%s'", spec)
+			}
+
+			v.spares = val
+		}
+	}
+
+	return v, nil
+}
+
+// writeConfig writes the vdev configuration as reported by zpool status,
+// or by zpool import (i.e. without the error counters) if counters is
+// false. The trim progress of the leaf vdevs is included if trim is true,
+// and the initialize progress as specified by initialize.
+func (t *vdevTree) writeConfig(b *strings.Builder, counters bool, trim bool, initialize initializeReport) {
+	width := 10
+	for _, v := range t.all() {
+		if l := len(v.name) + 4; l > width {
+			width = l
+		}
+	}
+
+	var write func(v *vdev, depth int, spare bool)
+	write = func(v *vdev, depth int, spare bool) {
+		name := strings.Repeat("  ", depth) + v.name
+
+		state := v.computedState()
+		if spare {
+			state = stateAvail
+		}
+
+		switch {
+		case counters && !spare && v.isLeaf():
+			fmt.Fprintf(
+				b, "\t%-*s  %-8s %5d %5d %5d%s\n", width, name, state, v.read, v.write, v.checksum, v.annotation(trim, initialize))
+		case counters && !spare:
+			fmt.Fprintf(b, "\t%-*s  %-8s %5d %5d %5d\n", width, name, state, v.read, v.write, v.checksum)
+		default:
+			fmt.Fprintf(b, "\t%-*s  %s\n", width, name, state)
+		}
+
+		for _, c := range v.children {
+			write(c, depth+1, false)
+		}
+	}
+
+	if counters {
+		fmt.Fprintf(b, "\t%-*s  %-8s %5s %5s %5s\n", width, "NAME", "STATE", "READ", "WRITE", "CKSUM")
+	}
+
+	write(t.root, 0, false)
+
+	for _, c := range t.classes() {
+		if len(*c.vdevs) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(b, "\t%s\n", c.header)
+
+		for _, v := range *c.vdevs {
+			write(v, 1, c.header == "spares")
+		}
+	}
+}
+
+// topLevelList returns the list of top-level vdevs (of the normal or any
+// other allocation class) that includes the vdev, or nil if the vdev is
+// not a top-level vdev.
+func (t *vdevTree) topLevelList(v *vdev) *[]*vdev {
+	lists := []*[]*vdev{&t.root.children}
+	for _, c := range t.classes() {
+		lists = append(lists, c.vdevs)
+	}
+
+	for _, l := range lists {
+		for _, c := range *l {
+			if c == v {
+				return l
+			}
+		}
+	}
+
+	return nil
+}
+
+// replaceIn replaces the old vdev with the new vdev in the list.
+func replaceIn(list *[]*vdev, old *vdev, v *vdev) {
+	for i, c := range *list {
+		if c == old {
+			(*list)[i] = v
+		}
+	}
+}
+
+// removeFrom removes the vdev from the list.
+func removeFrom(list *[]*vdev, v *vdev) {
+	for i, c := range *list {
+		if c == v {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package zfstest
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	// Interval between the checks of the state by 'zpool wait'.
+	waitPollInterval = 10 * time.Millisecond
+)
+
+var (
+	// Activities supported by 'zpool wait -t'.
+	waitActivities = map[string]bool{
+		"discard":    true,
+		"free":       true,
+		"initialize": true,
+		"replace":    true,
+		"remove":     true,
+		"resilver":   true,
+		"scrub":      true,
+		"trim":       true,
+	}
+)
+
+// zpoolWait runs 'zpool wait', which blocks until none of the activities are
+// in progress. The replacements and removals complete immediately in the
+// simulator, hence are never waited for.
+func (s *Sim) zpoolWait(ctx context.Context, args []string) (string, error) {
+	s.mu.Lock()
+
+	if err := s.begin("zpool", append([]string{"wait"}, args...)); err != nil {
+		s.mu.Unlock()
+		return "", err
+	}
+
+	a, err := parseArgs(args, "tT")
+	if err != nil {
+		s.mu.Unlock()
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		s.mu.Unlock()
+		return "", usageError("missing pool argument")
+	}
+
+	var activities []string
+
+	for _, val := range a.flags['t'] {
+		for _, name := range strings.Split(val, ",") {
+			if !waitActivities[name] {
+				s.mu.Unlock()
+				return "", usageError("invalid activity '%s'", name)
+			}
+
+			activities = append(activities, name)
+		}
+	}
+
+	if len(activities) == 0 {
+		for name := range waitActivities {
+			activities = append(activities, name)
+		}
+	}
+
+	poolName := a.pos[0]
+
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+
+		p, err := s.lookupPool(poolName)
+		if err != nil {
+			s.mu.Unlock()
+			return "", err
+		}
+
+		busy := false
+		for _, name := range activities {
+			busy = busy || p.inProgress(name)
+		}
+
+		s.mu.Unlock()
+
+		if !busy {
+			return "", nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// inProgress returns true if the activity (as named by 'zpool wait -t') is
+// in progress on the pool.
+func (p *pool) inProgress(name string) bool {
+	switch name {
+	case "discard":
+		return p.checkpoint != nil && p.checkpoint.discarding
+	case "free":
+		freeing, _ := p.get("freeing")
+		return freeing != "0"
+	case "scrub", "resilver":
+		return strings.HasPrefix(p.scan, name+" in progress")
+	case activityTrim, activityInitialize:
+		for _, v := range p.vdevs.activityLeaves() {
+			if a := *v.activity(name); a != nil && a.state == activityActive {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// zfsWait runs 'zfs wait', the simulated file systems never have pending
+// deletions.
+func (s *Sim) zfsWait(args []string) (string, error) {
+	a, err := parseArgs(args, "t")
+	if err != nil {
+		return "", err
+	}
+
+	for _, val := range a.flags['t'] {
+		if val != "deleteq" {
+			return "", usageError("invalid activity '%s'", val)
+		}
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing filesystem argument")
+	}
+
+	_, err = s.lookupFileSystem(a.pos[0])
+
+	return "", err
+}
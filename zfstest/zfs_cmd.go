@@ -0,0 +1,664 @@
+package zfstest
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	holdTimestampLayout = "Mon Jan _2 15:04 2006"
+)
+
+// runZfs runs the zfs subcommand.
+func (s *Sim) runZfs(stdin io.Reader, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", usageError("missing command")
+	}
+
+	sub, rest := args[0], args[1:]
+
+	switch sub {
+	case "list":
+		return s.zfsList(rest)
+	case "get":
+		return s.zfsGet(rest)
+	case "set":
+		return s.zfsSet(rest)
+	case "holds":
+		return s.zfsHolds(rest)
+	case "clone":
+		return s.zfsClone(rest)
+	case "promote":
+		return s.zfsPromote(rest)
+	case "diff":
+		return s.zfsDiff(rest)
+	case "userspace", "groupspace", "projectspace":
+		return s.zfsUserSpace(sub, rest)
+	case "load-key":
+		return s.zfsLoadKey(stdin, rest)
+	case "unload-key":
+		return s.zfsUnloadKey(rest)
+	case "change-key":
+		return s.zfsChangeKey(stdin, rest)
+	case "mount":
+		return s.zfsMount(rest)
+	case "unmount", "umount":
+		return s.zfsUnmount(rest)
+	case "version":
+		return s.zfsVersion(rest)
+	case "wait":
+		return s.zfsWait(rest)
+	}
+
+	return "", usageError("unrecognized command '%s'", sub)
+}
+
+// zfsVersion runs 'zfs version'.
+func (s *Sim) zfsVersion(args []string) (string, error) {
+	if len(args) > 0 {
+		return "", usageError("invalid option '%s'", strings.TrimPrefix(args[0], "-"))
+	}
+
+	return "zfs-" + s.version + "-1\nzfs-kmod-" + s.version + "-1\n", nil
+}
+
+// lookup returns the dataset with the specified full name, or the error
+// reported by zfs for a missing dataset.
+func (s *Sim) lookup(name string) (*dataset, error) {
+	ds, ok := s.dataset(name)
+	if !ok {
+		return nil, cmdError("cannot open '%s': dataset does not exist", name)
+	}
+
+	return ds, nil
+}
+
+// lookupFileSystem is similar to lookup, but fails for snapshots.
+func (s *Sim) lookupFileSystem(name string) (*dataset, error) {
+	ds, err := s.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if ds.snapshot {
+		return nil, cmdError("cannot open '%s': operation not applicable to datasets of this type", name)
+	}
+
+	return ds, nil
+}
+
+func (s *Sim) zfsList(args []string) (string, error) {
+	a, err := parseArgs(args, "otdsS")
+	if err != nil {
+		return "", err
+	}
+
+	cols := a.list('o')
+	if len(cols) == 0 {
+		cols = []string{"name", "used", "available", "referenced", "mountpoint"}
+	}
+
+	types := map[string]bool{}
+	for _, t := range a.list('t') {
+		switch t {
+		case "all":
+			types[typeFileSystem], types[typeSnapshot] = true, true
+		case "snap":
+			types[typeSnapshot] = true
+		case "fs":
+			types[typeFileSystem] = true
+		default:
+			types[t] = true
+		}
+	}
+
+	if len(types) == 0 {
+		types[typeFileSystem] = true
+	}
+
+	var targets []*dataset
+
+	// Explicitly specified snapshots are listed regardless of the types.
+	explicit := make(map[*dataset]bool)
+
+	if len(a.pos) == 0 {
+		for _, name := range s.sortedPoolNames() {
+			p := s.pools[name]
+			targets = append(targets, p.descendants(p.datasets[name], true)...)
+		}
+	}
+
+	for _, name := range a.pos {
+		ds, err := s.lookup(name)
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case ds.snapshot:
+			targets = append(targets, ds)
+			explicit[ds] = true
+		case a.has('r'):
+			targets = append(targets, ds.pool.descendants(ds, true)...)
+		default:
+			targets = append(targets, ds)
+			if !types[typeFileSystem] && types[typeSnapshot] {
+				// Listing only the snapshots of a file system lists the
+				// snapshots of the file system.
+				targets = append(targets, ds.pool.snapshotsOf(ds.name)...)
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	for _, ds := range targets {
+		if !types[ds.get("type").value] && !explicit[ds] {
+			continue
+		}
+
+		vals := make([]string, len(cols))
+
+		for i, c := range cols {
+			if !isValidProp(c) {
+				return "", usageError("invalid property '%s'", c)
+			}
+
+			vals[i] = ds.get(c).format(a.has('p'))
+		}
+
+		b.WriteString(strings.Join(vals, "\t"))
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+func (s *Sim) zfsGet(args []string) (string, error) {
+	a, err := parseArgs(args, "otsd")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 2 {
+		return "", usageError("missing property argument")
+	}
+
+	cols := a.list('o')
+	if len(cols) == 0 {
+		cols = []string{"name", "property", "value", "source"}
+	}
+
+	props := strings.Split(a.pos[0], ",")
+	for _, p := range props {
+		if !isValidProp(p) {
+			return "", usageError("bad property list: invalid property '%s'", p)
+		}
+	}
+
+	var b strings.Builder
+
+	for _, name := range a.pos[1:] {
+		ds, err := s.lookup(name)
+		if err != nil {
+			return "", err
+		}
+
+		for _, prop := range props {
+			val := ds.get(prop)
+
+			vals := make([]string, len(cols))
+
+			for i, c := range cols {
+				switch c {
+				case "name":
+					vals[i] = ds.name
+				case "property":
+					vals[i] = prop
+				case "value":
+					vals[i] = val.format(a.has('p'))
+				case "received":
+					vals[i] = "-"
+				case "source":
+					vals[i] = val.source
+				default:
+					return "", usageError("invalid column name '%s'", c)
+				}
+			}
+
+			b.WriteString(strings.Join(vals, "\t"))
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (s *Sim) zfsSet(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", usageError("missing dataset name")
+	}
+
+	name := args[len(args)-1]
+
+	ds, err := s.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	props := make(map[string]string)
+
+	for _, kv := range args[:len(args)-1] {
+		k, v, found := strings.Cut(kv, "=")
+		if !found {
+			return "", usageError("missing '=' for property=value argument")
+		}
+
+		k = canonicalProp(k)
+
+		switch {
+		case !isValidProp(k):
+			return "", cmdError("cannot set property for '%s': invalid property '%s'", name, k)
+		case datasetPropReadOnly[k] || k == "keyformat":
+			return "", cmdError("cannot set property for '%s': '%s' is readonly", name, k)
+		case ds.snapshot && datasetPropFileSystemOnly[k]:
+			return "", cmdError("cannot set property for '%s': this property can not be modified for snapshots", name)
+		case k == "keylocation" && !ds.encRoot:
+			return "", cmdError("cannot set property for '%s': keylocation must only be set on encryption roots", name)
+		}
+
+		v, err := normalizeProp(k, v)
+		if err != nil {
+			return "", cmdError("cannot set property for '%s': %v", name, err)
+		}
+
+		props[k] = v
+	}
+
+	for k, v := range props {
+		if v == "none" && (datasetPropSizes[k] || strings.Contains(k, "@")) {
+			delete(ds.props, k)
+			continue
+		}
+
+		ds.props[k] = v
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zfsHolds(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for _, name := range a.pos {
+		ds, err := s.lookup(name)
+		if err != nil {
+			return "", err
+		}
+
+		tags := make([]string, 0, len(ds.holds))
+		for t := range ds.holds {
+			tags = append(tags, t)
+		}
+
+		sort.Strings(tags)
+
+		for _, t := range tags {
+			b.WriteString(strings.Join([]string{ds.name, t, ds.holds[t].Local().Format(holdTimestampLayout)}, "\t"))
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (s *Sim) zfsClone(args []string) (string, error) {
+	a, err := parseArgs(args, "o")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 2 {
+		return "", usageError("missing source snapshot or target dataset")
+	}
+
+	props, err := a.props('o')
+	if err != nil {
+		return "", err
+	}
+
+	snap, err := s.lookup(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	if !snap.snapshot {
+		return "", cmdError("cannot open '%s': operation only applies to snapshots", a.pos[0])
+	}
+
+	target := a.pos[1]
+
+	p, ok := s.poolOf(target)
+	if !ok || p != snap.pool {
+		return "", cmdError("cannot create '%s': source and target pools differ", target)
+	}
+
+	if _, ok := p.datasets[parentName(target)]; !ok || !strings.Contains(target, "/") {
+		return "", cmdError("cannot create '%s': parent does not exist", target)
+	}
+
+	ds, err := s.createFileSystem(p, target, props)
+	if err != nil {
+		return "", err
+	}
+
+	ds.origin = snap.name
+	ds.props["referenced"] = snap.propOrDefault("referenced", "0")
+
+	return "", nil
+}
+
+// zfsPromote promotes the clone, which transfers the snapshots of the
+// origin file system up to and including the origin snapshot to the clone,
+// and makes the origin file system a clone of the transferred origin
+// snapshot.
+func (s *Sim) zfsPromote(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", usageError("missing clone filesystem argument")
+	}
+
+	clone, err := s.lookupFileSystem(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if clone.origin == "" {
+		return "", cmdError("cannot promote '%s': not a cloned filesystem", clone.name)
+	}
+
+	p := clone.pool
+	origin := p.datasets[clone.origin]
+	originFsName, _, _ := strings.Cut(origin.name, "@")
+	originFs := p.datasets[originFsName]
+
+	var moved []*dataset
+
+	for _, snap := range p.snapshotsOf(originFsName) {
+		if snap.txg <= origin.txg {
+			moved = append(moved, snap)
+		}
+	}
+
+	for _, snap := range moved {
+		_, snapName, _ := strings.Cut(snap.name, "@")
+		if _, ok := p.datasets[clone.name+"@"+snapName]; ok {
+			return "", cmdError("cannot promote '%s': snapshot name '%s' from origin conflicts with '%s' from target",
+				clone.name, snapName, snapName)
+		}
+	}
+
+	renames := make(map[string]string)
+
+	for _, snap := range moved {
+		_, snapName, _ := strings.Cut(snap.name, "@")
+		newName := clone.name + "@" + snapName
+
+		delete(p.datasets, snap.name)
+		renames[snap.name] = newName
+		snap.name = newName
+		p.datasets[newName] = snap
+	}
+
+	for _, d := range p.datasets {
+		if newName, ok := renames[d.origin]; ok {
+			d.origin = newName
+		}
+	}
+
+	// The origin snapshot was renamed to be a snapshot of the clone.
+	clone.origin, originFs.origin = originFs.origin, origin.name
+
+	return "", nil
+}
+
+func (s *Sim) zfsLoadKey(stdin io.Reader, args []string) (string, error) {
+	a, err := parseArgs(args, "L")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing dataset argument")
+	}
+
+	ds, err := s.lookupFileSystem(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	root := ds.encryptionRoot()
+
+	switch {
+	case root == nil:
+		return "", cmdError("Key load error: Keys can only be loaded for encrypted datasets.")
+	case root != ds:
+		return "", cmdError("Key load error: Keys must be loaded for encryption root of '%s' (%s).", ds.name, root.name)
+	case ds.keyLoaded:
+		return "", cmdError("Key load error: Key already loaded for '%s'.", ds.name)
+	}
+
+	location := a.value('L')
+	if location == "" {
+		location = ds.props["keylocation"]
+	}
+
+	if location == "prompt" {
+		if stdin == nil {
+			return "", cmdError("Key load error: Failed to get key material from stdin.")
+		}
+
+		key, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", cmdError("Key load error: Failed to read key material from stdin.")
+		}
+
+		if ds.key != "" && strings.TrimRight(string(key), "\n") != ds.key {
+			return "", cmdError("Key load error: Incorrect key provided for '%s'.", ds.name)
+		}
+	}
+
+	ds.keyLoaded = true
+
+	return "", nil
+}
+
+func (s *Sim) zfsUnloadKey(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", usageError("missing dataset argument")
+	}
+
+	ds, err := s.lookupFileSystem(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case ds.encryptionRoot() == nil:
+		return "", cmdError("Key unload error: Keys can only be unloaded for encrypted datasets.")
+	case !ds.encRoot:
+		return "", cmdError("Key unload error: Keys must be unloaded for encryption root of '%s' (%s).",
+			ds.name, ds.encryptionRoot().name)
+	case !ds.keyLoaded:
+		return "", cmdError("Key unload error: Key already unloaded for '%s'.", ds.name)
+	}
+
+	for _, d := range ds.pool.descendants(ds, false) {
+		if d.mounted && d.encryptionRoot() == ds {
+			return "", cmdError("Key unload error: '%s' is busy.", ds.name)
+		}
+	}
+
+	ds.keyLoaded = false
+
+	return "", nil
+}
+
+func (s *Sim) zfsChangeKey(stdin io.Reader, args []string) (string, error) {
+	a, err := parseArgs(args, "o")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing dataset argument")
+	}
+
+	props, err := a.props('o')
+	if err != nil {
+		return "", err
+	}
+
+	ds, err := s.lookupFileSystem(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	root := ds.encryptionRoot()
+	if root == nil {
+		return "", cmdError("Key change error: Dataset not encrypted.")
+	}
+
+	if a.has('l') {
+		root.keyLoaded = true
+	}
+
+	if !root.keyLoaded {
+		return "", cmdError("Key change error: Key must be loaded.")
+	}
+
+	if a.has('i') {
+		parent := ds.parent()
+		if !ds.encRoot || parent == nil || parent.encryptionRoot() == nil {
+			return "", cmdError("Key change error: Root dataset cannot inherit key.")
+		}
+
+		if !parent.encryptionRoot().keyLoaded {
+			return "", cmdError("Key change error: Parent key must be loaded.")
+		}
+
+		ds.encRoot = false
+		ds.key = ""
+
+		delete(ds.props, "keyformat")
+		delete(ds.props, "keylocation")
+
+		return "", nil
+	}
+
+	format := props["keyformat"]
+	if format == "" {
+		format = root.props["keyformat"]
+	}
+
+	location := props["keylocation"]
+	if location == "" {
+		location = "prompt"
+		if ds.encRoot {
+			location = ds.props["keylocation"]
+		}
+	}
+
+	if location == "prompt" && stdin != nil {
+		key, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", cmdError("Key change error: Failed to read key material from stdin.")
+		}
+
+		ds.key = strings.TrimRight(string(key), "\n")
+	} else if ds != root {
+		ds.key = root.key
+	}
+
+	if !ds.encRoot {
+		ds.props["encryption"] = root.props["encryption"]
+	}
+
+	ds.encRoot = true
+	ds.keyLoaded = true
+	ds.props["keyformat"] = format
+	ds.props["keylocation"] = location
+
+	if iters, ok := props["pbkdf2iters"]; ok {
+		ds.props["pbkdf2iters"] = iters
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zfsMount(args []string) (string, error) {
+	a, err := parseArgs(args, "o")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing dataset argument")
+	}
+
+	ds, err := s.lookupFileSystem(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	mp := ds.get("mountpoint").value
+
+	switch {
+	case ds.mounted:
+		return "", cmdError("cannot mount '%s': filesystem already mounted", ds.name)
+	case mp == "legacy":
+		return "", cmdError("cannot mount '%s': legacy mountpoint\nuse mount(8) to mount this filesystem", ds.name)
+	case mp == "none":
+		return "", cmdError("cannot mount '%s': no mountpoint set", ds.name)
+	case ds.get("canmount").value == "off":
+		return "", cmdError("cannot mount '%s': 'canmount' property is set to 'off'", ds.name)
+	case ds.get("keystatus").value == "unavailable":
+		return "", cmdError("cannot mount '%s': encryption key not loaded", ds.name)
+	}
+
+	ds.mounted = true
+
+	return "", nil
+}
+
+func (s *Sim) zfsUnmount(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing filesystem argument")
+	}
+
+	ds, err := s.lookupFileSystem(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case !ds.mounted:
+		return "", cmdError("cannot unmount '%s': not currently mounted", ds.name)
+	case ds.busy && !a.has('f'):
+		return "", cmdError("cannot unmount '%s': pool or dataset is busy", ds.get("mountpoint").value)
+	}
+
+	ds.mounted = false
+
+	return "", nil
+}
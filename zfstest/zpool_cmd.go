@@ -0,0 +1,855 @@
+package zfstest
+
+import (
+	"strconv"
+	"strings"
+)
+
+// runZpool runs the zpool subcommand, except iostat.
+func (s *Sim) runZpool(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", usageError("missing command")
+	}
+
+	sub, rest := args[0], args[1:]
+
+	switch sub {
+	case "list":
+		return s.zpoolList(rest)
+	case "get":
+		return s.zpoolGet(rest)
+	case "set":
+		return s.zpoolSet(rest)
+	case "upgrade":
+		return s.zpoolUpgrade(rest)
+	case "import":
+		return s.zpoolImport(rest)
+	case "export":
+		return s.zpoolExport(rest)
+	case "create":
+		return s.zpoolCreate(rest)
+	case "destroy":
+		return s.zpoolDestroy(rest)
+	case "status":
+		return s.zpoolStatus(rest)
+	case "attach":
+		return s.zpoolAttach(rest)
+	case "detach":
+		return s.zpoolDetach(rest)
+	case "replace":
+		return s.zpoolReplace(rest)
+	case "online":
+		return s.zpoolOnline(rest)
+	case "offline":
+		return s.zpoolOffline(rest)
+	case "clear":
+		return s.zpoolClear(rest)
+	case "remove":
+		return s.zpoolRemove(rest)
+	case "history":
+		return s.zpoolHistory(rest)
+	case "checkpoint":
+		return s.zpoolCheckpoint(rest)
+	case "trim":
+		return s.zpoolTrim(rest)
+	case "initialize":
+		return s.zpoolInitialize(rest)
+	}
+
+	return "", usageError("unrecognized command '%s'", sub)
+}
+
+// lookupPool returns the imported pool with the specified name, or the
+// error reported by zpool for a missing pool.
+func (s *Sim) lookupPool(name string) (*pool, error) {
+	p, ok := s.pools[name]
+	if !ok {
+		return nil, cmdError("cannot open '%s': no such pool", name)
+	}
+
+	return p, nil
+}
+
+// lookupDevice returns the vdev of the pool with the specified name along
+// with its parent, or the error reported by zpool for a missing device.
+func lookupDevice(p *pool, op string, name string) (*vdev, *vdev, error) {
+	v, parent := p.vdevs.find(name)
+	if v == nil || v == p.vdevs.root {
+		return nil, nil, cmdError("cannot %s %s: no such device in pool", op, name)
+	}
+
+	return v, parent, nil
+}
+
+// checkDeviceUnused returns an error if the device is part of any pool.
+func (s *Sim) checkDeviceUnused(op string, device string) error {
+	for _, p := range s.pools {
+		if v, _ := p.vdevs.find(device); v != nil && v.isLeaf() {
+			return cmdError("cannot %s %s: %s is part of active pool '%s'", op, device, device, p.name)
+		}
+	}
+
+	return nil
+}
+
+func (s *Sim) zpoolList(args []string) (string, error) {
+	a, err := parseArgs(args, "oLTg")
+	if err != nil {
+		return "", err
+	}
+
+	cols := a.list('o')
+	if len(cols) == 0 {
+		cols = []string{"name", "size", "allocated", "free", "fragmentation", "capacity", "health", "altroot"}
+	}
+
+	names := a.pos
+	if len(names) == 0 {
+		names = s.sortedPoolNames()
+	}
+
+	var b strings.Builder
+
+	for _, name := range names {
+		p, err := s.lookupPool(name)
+		if err != nil {
+			return "", err
+		}
+
+		vals := make([]string, len(cols))
+
+		for i, c := range cols {
+			val, ok := p.get(c)
+			if !ok {
+				return "", usageError("invalid property '%s'", c)
+			}
+
+			vals[i] = val
+		}
+
+		b.WriteString(strings.Join(vals, "\t"))
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+func (s *Sim) zpoolGet(args []string) (string, error) {
+	a, err := parseArgs(args, "o")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 1 {
+		return "", usageError("missing property argument")
+	}
+
+	cols := a.list('o')
+	if len(cols) == 0 {
+		cols = []string{"name", "property", "value", "source"}
+	}
+
+	names := a.pos[1:]
+	if len(names) == 0 {
+		names = s.sortedPoolNames()
+	}
+
+	var b strings.Builder
+
+	for _, name := range names {
+		p, err := s.lookupPool(name)
+		if err != nil {
+			return "", err
+		}
+
+		props := strings.Split(a.pos[0], ",")
+		if a.pos[0] == "all" {
+			props = p.allPoolProps()
+		}
+
+		for _, prop := range props {
+			val, ok := p.get(prop)
+			if !ok {
+				return "", usageError("bad property list: invalid property '%s'", prop)
+			}
+
+			vals := make([]string, len(cols))
+
+			for i, c := range cols {
+				switch c {
+				case "name":
+					vals[i] = p.name
+				case "property":
+					vals[i] = prop
+				case "value":
+					vals[i] = val
+				case "source":
+					vals[i] = sourceDefault
+					if _, ok := p.props[prop]; ok {
+						vals[i] = sourceLocal
+					}
+				default:
+					return "", usageError("invalid column name '%s'", c)
+				}
+			}
+
+			b.WriteString(strings.Join(vals, "\t"))
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (s *Sim) zpoolImport(args []string) (string, error) {
+	args, rewind := stripArg(args, rewindToCheckpointArg)
+
+	a, err := parseArgs(args, "dRocT")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) == 0 && !a.has('a') {
+		if len(s.exported) == 0 {
+			return "", cmdError("no pools available to import")
+		}
+
+		return s.importList(), nil
+	}
+
+	props, err := a.props('o')
+	if err != nil {
+		return "", err
+	}
+
+	var targets []*pool
+
+	if a.has('a') {
+		for _, p := range s.exported {
+			targets = append(targets, p)
+		}
+	} else {
+		for _, p := range s.exported {
+			if p.name == a.pos[0] || strconv.FormatUint(p.guid, 10) == a.pos[0] {
+				targets = append(targets, p)
+			}
+		}
+
+		if len(targets) == 0 {
+			return "", cmdError("cannot import '%s': no such pool available", a.pos[0])
+		}
+
+		if len(targets) > 1 {
+			return "", cmdError("cannot import '%s': more than one matching pool\nimport by numeric ID instead", a.pos[0])
+		}
+	}
+
+	for _, p := range targets {
+		name := p.name
+		if len(a.pos) > 1 {
+			name = a.pos[1]
+		}
+
+		if _, ok := s.pools[name]; ok {
+			return "", cmdError("cannot import '%s': a pool with that name already exists", name)
+		}
+
+		if rewind {
+			if err := p.rewindToCheckpoint(); err != nil {
+				return "", err
+			}
+		}
+
+		delete(s.exported, strconv.FormatUint(p.guid, 10))
+		s.renamePool(p, name)
+
+		for k, v := range props {
+			p.props[k] = v
+		}
+
+		if altRoot := a.value('R'); altRoot != "" {
+			p.props["altroot"] = altRoot
+		}
+
+		s.pools[name] = p
+
+		if !a.has('N') {
+			p.mountAll()
+		}
+	}
+
+	return "", nil
+}
+
+// importList returns the pools available to be imported as listed by
+// 'zpool import'.
+func (s *Sim) importList() string {
+	var b strings.Builder
+
+	for _, p := range s.sortedExported() {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString("   pool: " + p.name + "\n")
+		b.WriteString("     id: " + strconv.FormatUint(p.guid, 10) + "\n")
+		b.WriteString("  state: " + p.vdevs.root.computedState() + "\n")
+		b.WriteString(" action: The pool can be imported using its name or numeric identifier.\n")
+		b.WriteString(" config:\n\n")
+		p.vdevs.writeConfig(&b, false, false, initializeInProgress)
+	}
+
+	return b.String()
+}
+
+// renamePool renames the pool along with its file systems and snapshots.
+func (s *Sim) renamePool(p *pool, name string) {
+	if p.name == name {
+		return
+	}
+
+	rename := func(n string) string {
+		return name + strings.TrimPrefix(n, p.name)
+	}
+
+	renameAll := func(all map[string]*dataset) map[string]*dataset {
+		datasets := make(map[string]*dataset, len(all))
+
+		for _, d := range all {
+			d.name = rename(d.name)
+			if d.origin != "" {
+				d.origin = rename(d.origin)
+			}
+
+			datasets[d.name] = d
+		}
+
+		return datasets
+	}
+
+	p.datasets = renameAll(p.datasets)
+	if p.checkpoint != nil {
+		p.checkpoint.datasets = renameAll(p.checkpoint.datasets)
+	}
+	p.name = name
+	p.vdevs.root.name = name
+}
+
+func (s *Sim) zpoolExport(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) == 0 {
+		return "", usageError("missing pool argument")
+	}
+
+	for _, name := range a.pos {
+		p, err := s.lookupPool(name)
+		if err != nil {
+			return "", err
+		}
+
+		if err := p.unmountAll(a.has('f')); err != nil {
+			return "", err
+		}
+
+		delete(s.pools, name)
+		delete(p.props, "altroot")
+		s.exported[strconv.FormatUint(p.guid, 10)] = p
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zpoolCreate(args []string) (string, error) {
+	a, err := parseArgs(args, "oOmRt")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 2 {
+		return "", usageError("missing vdev specification")
+	}
+
+	poolProps, err := a.props('o')
+	if err != nil {
+		return "", err
+	}
+
+	fsProps, err := a.props('O')
+	if err != nil {
+		return "", err
+	}
+
+	name := a.pos[0]
+	if _, ok := s.pools[name]; ok {
+		return "", cmdError("cannot create '%s': pool already exists", name)
+	}
+
+	for prop := range poolProps {
+		if _, ok := poolPropDefaults[prop]; !ok && !strings.Contains(prop, ":") && !strings.HasPrefix(prop, "feature@") {
+			return "", cmdError("property '%s' is not a valid pool property", prop)
+		}
+	}
+
+	for prop := range fsProps {
+		if !isValidProp(prop) || datasetPropReadOnly[canonicalProp(prop)] && prop != "encryption" {
+			return "", cmdError("property '%s' is not a valid filesystem property", prop)
+		}
+	}
+
+	vdevs, err := s.parseVdevLayout(name, a.pos[1:])
+	if err != nil {
+		return "", err
+	}
+
+	if mp := a.value('m'); mp != "" {
+		fsProps["mountpoint"] = mp
+	}
+
+	if altRoot := a.value('R'); altRoot != "" {
+		poolProps["altroot"] = altRoot
+	}
+
+	features := newFeatures(a.has('d') || poolProps["compatibility"] == "legacy")
+
+	for prop, val := range poolProps {
+		if name := strings.TrimPrefix(prop, "feature@"); name != prop {
+			if _, ok := features[name]; !ok || val != featureEnabled {
+				return "", cmdError("property '%s' is not a valid pool property", prop)
+			}
+
+			features[name] = val
+			delete(poolProps, prop)
+		}
+	}
+
+	p := &pool{
+		name:     name,
+		guid:     s.guid(),
+		props:    poolProps,
+		features: features,
+		vdevs:    vdevs,
+		datasets: make(map[string]*dataset),
+	}
+
+	s.pools[name] = p
+
+	if _, err := s.createFileSystem(p, name, fsProps); err != nil {
+		delete(s.pools, name)
+		return "", err
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zpoolDestroy(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 1 {
+		return "", usageError("missing pool argument")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.unmountAll(a.has('f')); err != nil {
+		return "", err
+	}
+
+	delete(s.pools, p.name)
+
+	return "", nil
+}
+
+func (s *Sim) zpoolStatus(args []string) (string, error) {
+	a, err := parseArgs(args, "cT")
+	if err != nil {
+		return "", err
+	}
+
+	initialize := initializeInProgress
+
+	switch {
+	case !s.versionAtLeast(2, 2) && a.has('i'):
+		return "", usageError("invalid option 'i'")
+	case !s.versionAtLeast(2, 2):
+		initialize = initializeStarted
+	case a.has('i'):
+		initialize = initializeAll
+	}
+
+	names := a.pos
+	if len(names) == 0 {
+		names = s.sortedPoolNames()
+	}
+
+	var b strings.Builder
+
+	for _, name := range names {
+		p, err := s.lookupPool(name)
+		if err != nil {
+			return "", err
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		state := p.vdevs.root.computedState()
+
+		b.WriteString("  pool: " + p.name + "\n")
+		b.WriteString(" state: " + state + "\n")
+
+		if status, action := p.statusMessage(); status != "" {
+			b.WriteString("status: " + status + "\n")
+			b.WriteString("action: " + action + "\n")
+		}
+
+		scan := p.scan
+		if scan == "" {
+			scan = "none requested"
+		}
+
+		b.WriteString("  scan: " + scan + "\n")
+
+		if checkpoint := p.checkpointStatus(); checkpoint != "" {
+			b.WriteString("checkpoint: " + checkpoint + "\n")
+		}
+		b.WriteString("config:\n\n")
+		p.vdevs.writeConfig(&b, true, a.has('t'), initialize)
+		b.WriteString("\nerrors: No known data errors\n")
+	}
+
+	return b.String(), nil
+}
+
+// statusMessage returns the status and action reported for the pool, or
+// empty strings if there are no issues.
+func (p *pool) statusMessage() (string, string) {
+	offline, faulted, errors := false, false, false
+
+	for _, v := range p.vdevs.all() {
+		if !v.isLeaf() {
+			continue
+		}
+
+		switch v.state {
+		case stateOffline:
+			offline = true
+		case stateFaulted, stateUnavail, stateRemoved:
+			faulted = true
+		}
+
+		if v.read+v.write+v.checksum > 0 {
+			errors = true
+		}
+	}
+
+	switch {
+	case faulted:
+		return "One or more devices are faulted in response to persistent errors.",
+			"Replace the faulted device, or use 'zpool clear' to mark the device repaired."
+	case offline:
+		return "One or more devices has been taken offline by the administrator.",
+			"Online the device using 'zpool online' or replace the device with 'zpool replace'."
+	case errors:
+		return "One or more devices has experienced an unrecoverable error.",
+			"Determine if the device needs to be replaced, and clear the errors using " +
+				"'zpool clear' or replace the device with 'zpool replace'."
+	}
+
+	return "", ""
+}
+
+func (s *Sim) zpoolAttach(args []string) (string, error) {
+	a, err := parseArgs(args, "o")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) != 3 {
+		return "", usageError("missing <device> or <new_device> specification")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	device, newDevice := a.pos[1], a.pos[2]
+
+	v, parent, err := lookupDevice(p, "attach "+newDevice+" to", device)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.checkDeviceUnused("attach "+newDevice+" to", newDevice); err != nil {
+		return "", err
+	}
+
+	dev := &vdev{name: deviceName(newDevice), typ: vdevDisk, guid: s.guid(), state: stateOnline}
+
+	switch {
+	case parent != nil && parent.typ == vdevMirror:
+		parent.children = append(parent.children, dev)
+	case v.isLeaf() && (parent == p.vdevs.root || isTopLevel(p, v, "special", "dedup", "logs")):
+		mirror := &vdev{typ: vdevMirror, guid: s.guid(), id: v.id, children: []*vdev{v, dev}}
+		mirror.name = vdevMirror + "-" + strconv.Itoa(v.id)
+		replaceIn(p.vdevs.topLevelList(v), v, mirror)
+	default:
+		return "", cmdError("cannot attach %s to %s: can only attach to mirrors and top-level disks", newDevice, device)
+	}
+
+	return "", nil
+}
+
+// isTopLevel returns true if the vdev is a top-level vdev of any of the
+// specified allocation classes.
+func isTopLevel(p *pool, v *vdev, classes ...string) bool {
+	for _, c := range p.vdevs.classes() {
+		for _, name := range classes {
+			if c.header != name {
+				continue
+			}
+
+			for _, t := range *c.vdevs {
+				if t == v {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *Sim) zpoolDetach(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", usageError("missing <device> specification")
+	}
+
+	p, err := s.lookupPool(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	v, parent, err := lookupDevice(p, "detach", args[1])
+	if err != nil {
+		return "", err
+	}
+
+	if parent == nil || parent.typ != vdevMirror {
+		return "", cmdError("cannot detach %s: only applicable to mirror and replacing vdevs", args[1])
+	}
+
+	removeFrom(&parent.children, v)
+
+	if len(parent.children) == 1 {
+		// A mirror with a single device is replaced by the device.
+		child := parent.children[0]
+		child.id = parent.id
+		replaceIn(p.vdevs.topLevelList(parent), parent, child)
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zpoolReplace(args []string) (string, error) {
+	a, err := parseArgs(args, "o")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 2 {
+		return "", usageError("missing <device> specification")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	device := a.pos[1]
+
+	newDevice := device
+	if len(a.pos) > 2 {
+		newDevice = a.pos[2]
+	}
+
+	v, _, err := lookupDevice(p, "replace "+device+" with", device)
+	if err != nil {
+		return "", err
+	}
+
+	if !v.isLeaf() {
+		return "", cmdError("cannot replace %s with %s: can only be replaced by a device", device, newDevice)
+	}
+
+	if deviceName(newDevice) != v.name {
+		if err := s.checkDeviceUnused("replace "+device+" with", newDevice); err != nil {
+			return "", err
+		}
+	}
+
+	// The resilver completes instantly.
+	v.name = deviceName(newDevice)
+	v.guid = s.guid()
+	v.read, v.write, v.checksum = 0, 0, 0
+	s.setDeviceState(p, v, stateOnline)
+
+	return "", nil
+}
+
+func (s *Sim) zpoolOnline(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 2 {
+		return "", usageError("missing device name")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	for _, device := range a.pos[1:] {
+		v, _, err := lookupDevice(p, "online", device)
+		if err != nil {
+			return "", err
+		}
+
+		if !v.isLeaf() {
+			return "", cmdError("cannot online %s: operation not supported on this type of vdev", device)
+		}
+
+		s.setDeviceState(p, v, stateOnline)
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zpoolOffline(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 2 {
+		return "", usageError("missing device name")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	for _, device := range a.pos[1:] {
+		v, _, err := lookupDevice(p, "offline", device)
+		if err != nil {
+			return "", err
+		}
+
+		if !v.isLeaf() {
+			return "", cmdError("cannot offline %s: operation not supported on this type of vdev", device)
+		}
+
+		prev := v.state
+		v.state = stateOffline
+
+		if p.vdevs.root.computedState() == stateUnavail {
+			v.state = prev
+			return "", cmdError("cannot offline %s: no valid replicas", device)
+		}
+
+		v.state = prev
+		s.setDeviceState(p, v, stateOffline)
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zpoolClear(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 1 {
+		return "", usageError("missing pool name")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	targets := p.vdevs.all()
+
+	if len(a.pos) > 1 {
+		v, _, err := lookupDevice(p, "clear errors for", a.pos[1])
+		if err != nil {
+			return "", err
+		}
+
+		targets = (&vdevTree{root: v}).all()
+	}
+
+	for _, v := range targets {
+		v.read, v.write, v.checksum = 0, 0, 0
+
+		if v.isLeaf() && v.state == stateFaulted {
+			s.setDeviceState(p, v, stateOnline)
+		}
+	}
+
+	return "", nil
+}
+
+func (s *Sim) zpoolRemove(args []string) (string, error) {
+	a, err := parseArgs(args, "")
+	if err != nil {
+		return "", err
+	}
+
+	if len(a.pos) < 2 {
+		return "", usageError("missing device")
+	}
+
+	p, err := s.lookupPool(a.pos[0])
+	if err != nil {
+		return "", err
+	}
+
+	for _, device := range a.pos[1:] {
+		v, _, err := lookupDevice(p, "remove", device)
+		if err != nil {
+			return "", err
+		}
+
+		list := p.vdevs.topLevelList(v)
+
+		switch {
+		case list == nil:
+			return "", cmdError("cannot remove %s: operation not supported on this type of vdev", device)
+		case list == &p.vdevs.root.children && len(*list) == 1:
+			return "", cmdError("cannot remove %s: root pool can not have removed devices", device)
+		case v.typ == vdevRaidZ || v.typ == vdevDRaid:
+			return "", cmdError("cannot remove %s: invalid config; all top-level vdevs must have the same sector size and not be raidz.", device)
+		}
+
+		removeFrom(list, v)
+	}
+
+	return "", nil
+}